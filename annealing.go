@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+const (
+	AnnealingMoveTranslate = iota
+	AnnealingMoveRotate
+	AnnealingMoveSwap
+	AnnealingMoveDelete
+	AnnealingMoveAdd
+)
+
+// Refines the placed shapes with simulated annealing. Each iteration proposes a random move (translate, rotate,
+// swap, delete or add a shape) and accepts it if it raises the score of the configured scoringFunction or, with a
+// probability that decreases with the temperature, even if it lowers the score. Stops after annealingIterations
+// iterations or after annealingTimeout seconds.
+func annealShapes(randSource *rand.Rand) {
+	start := time.Now()
+	acceptedMoves := 0
+
+	for iteration := 0; iteration < Config.annealingIterations; iteration++ {
+		if time.Since(start) > time.Duration(Config.annealingTimeout)*time.Second {
+			if Config.debug {
+				fmt.Printf("Annealing stopped after %d iterations because the annealingTimeout was reached.\n", iteration)
+			}
+			break
+		}
+		if generationCancelled.Load() {
+			break
+		}
+
+		progress := float64(iteration) / float64(Config.annealingIterations)
+		temperature := Config.annealingStartTemperature * math.Pow(Config.annealingEndTemperature/Config.annealingStartTemperature, progress)
+
+		quadrant := quadrants[randSource.IntN(len(quadrants))]
+		undo, delta := proposeAnnealingMove(quadrant, randSource)
+		if undo == nil {
+			continue
+		}
+
+		if delta <= 0 || randSource.Float64() < math.Exp(-delta/temperature) {
+			acceptedMoves++
+			continue
+		}
+
+		undo()
+	}
+
+	if Config.debug {
+		fmt.Printf("Annealing accepted %d moves.\n", acceptedMoves)
+	}
+}
+
+// Applies a random move to the shapes of the quadrant and returns a function that reverts it and by how much the move
+// lowers the score. Returns nil if no move could be applied (e.g. adding a shape to a quadrant that is already dark
+// enough or a shape that the gradient orientation does not allow).
+func proposeAnnealingMove(quadrant *Quadrant, randSource *rand.Rand) (func(), float64) {
+	move := randSource.IntN(5)
+
+	if move == AnnealingMoveAdd || len(quadrant.Shapes) == 0 {
+		// Like during the placement shapes are only added to quadrants that are not dark enough yet
+		if quadrant.isDone() {
+			return nil, 0
+		}
+		pixel := quadrant.FlattenPixels[randSource.IntN(len(quadrant.FlattenPixels))]
+		newShape := getRandomVariant(quadrant.region, randSource)
+		if newShape == nil {
+			return nil, 0
+		}
+		newShape = newShape.transformCopy(pixel.midpoint.X, pixel.midpoint.Y)
+		newShape.region = quadrant.region
+
+		newScore, ok := getAnnealingScore(quadrant, newShape)
+		if !ok {
+			return nil, 0
+		}
+		quadrant.addShape(newShape)
+		return func() {
+			quadrant.removeShape(len(quadrant.Shapes) - 1)
+		}, -newScore
+	}
+
+	shapeIndex := randSource.IntN(len(quadrant.Shapes))
+	oldShape := quadrant.Shapes[shapeIndex].copy()
+
+	var newShape *Shape
+	switch move {
+	case AnnealingMoveTranslate:
+		newShape = oldShape.transformCopy(randSource.Float64()*2-1, randSource.Float64()*2-1)
+		if newShape.centroid.X < float64(quadrant.X1) || newShape.centroid.X > float64(quadrant.X2) ||
+			newShape.centroid.Y < float64(quadrant.Y1) || newShape.centroid.Y > float64(quadrant.Y2) {
+			// Shapes must stay inside their quadrant since only the neighbors of the quadrant are updated
+			return nil, 0
+		}
+	case AnnealingMoveRotate:
+		// Like the variants the shape may only be rotated by up to shapeAngleDeviationRange from the configured shape
+		if Config.shapeAngleDeviationRange <= 0 {
+			return nil, 0
+		}
+		steps := math.Floor(Config.shapeAngleDeviationRange / Config.shapeAngleDeviationStep)
+		rotation := float64(randSource.IntN(int(2*steps)+1)-int(steps)) * Config.shapeAngleDeviationStep
+		if rotation == oldShape.rotation {
+			return nil, 0
+		}
+		newShape = oldShape.rotateCopy(rotation-oldShape.rotation, oldShape.centroid)
+		newShape.rotation = rotation
+		newShape.calculateOrientation()
+	case AnnealingMoveSwap:
+		variant := getRandomVariant(quadrant.region, randSource)
+		if variant == nil {
+			return nil, 0
+		}
+		newShape = variant.transformCopy(oldShape.centroid.X, oldShape.centroid.Y)
+		newShape.region = oldShape.region
+	}
+
+	// The old shape is rated like a newly placed shape so that it can be compared with the new shape
+	quadrant.removeShape(shapeIndex)
+	oldScore, ok := getAnnealingScore(quadrant, &oldShape)
+	if !ok {
+		oldScore = 0
+	}
+
+	if move == AnnealingMoveDelete {
+		return func() {
+			quadrant.addShape(&oldShape)
+		}, oldScore
+	}
+
+	newScore, ok := getAnnealingScore(quadrant, newShape)
+	if !ok {
+		quadrant.addShape(&oldShape)
+		return nil, 0
+	}
+	quadrant.addShape(newShape)
+	return func() {
+		quadrant.removeShape(len(quadrant.Shapes) - 1)
+		quadrant.addShape(&oldShape)
+	}, oldScore - newScore
+}
+
+// Returns the score of the configured scoringFunction for adding the shape to the quadrant, including the bias of the
+// gradient orientation. Returns false if the score is not finite (e.g. the shape intersects no pixels) or if the
+// restrict mode of the gradient orientation does not allow the shape.
+func getAnnealingScore(quadrant *Quadrant, shape *Shape) (float64, bool) {
+	if !isOrientationAllowed(quadrant, shape) {
+		return 0, false
+	}
+
+	scores := []float64{scoreShape(quadrant, shape)}
+	if math.IsNaN(scores[0]) || math.IsInf(scores[0], 0) {
+		return 0, false
+	}
+	applyOrientationBias(quadrant, []*Shape{shape}, scores)
+
+	return scores[0], true
+}
+
+func getRandomVariant(region *Region, randSource *rand.Rand) *Shape {
+	shapes := region.getShapes()
+	if len(shapes) == 0 {
+		return nil
+	}
+
+	shape := &shapes[randSource.IntN(len(shapes))]
+	if len(shape.Variants) == 0 {
+		return nil
+	}
+
+	return &shape.Variants[randSource.IntN(len(shape.Variants))]
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func initializeAnnealingTest(gradientOrientation string) {
+	resetStaticVariables()
+	Config.gradientOrientation = gradientOrientation
+	Config.gradientOrientationMode = GradientOrientationModeRestrict
+	Config.scoringFunction = ScoringSquaredError
+	Config.shapeAngleDeviationRange = 30
+	Config.annealingIterations = 3000
+	Config.annealingStartTemperature = 1e-9
+	Config.annealingEndTemperature = 1e-9
+
+	// The image gets darker from left to right
+	img := image.NewGray(image.Rect(0, 0, 40, 40))
+	for y := range 40 {
+		for x := range 40 {
+			img.SetGray(x, y, color.Gray{uint8(255 - x*6)})
+		}
+	}
+	initialize(img, calculateNeighborRange())
+}
+
+func getTotalSquaredError() float64 {
+	var residuals []float64
+	for index := range quadrants {
+		residuals = quadrants[index].appendResiduals(residuals)
+	}
+
+	return sumOfSquares(residuals)
+}
+
+func TestAnnealing(t *testing.T) {
+	initializeAnnealingTest(GradientOrientationNone)
+
+	randSource := rand.New(rand.NewPCG(1, 2))
+	for index := range quadrants {
+		if index%3 == 0 {
+			variant := getRandomVariant(quadrants[index].region, randSource)
+			shape := variant.transformCopy(quadrants[index].Pixels[2][2].midpoint.X, quadrants[index].Pixels[2][2].midpoint.Y)
+			shape.region = quadrants[index].region
+			quadrants[index].addShape(shape)
+		}
+	}
+
+	for range 500 {
+		errorBefore := getTotalSquaredError()
+		undo, delta := proposeAnnealingMove(quadrants[randSource.IntN(len(quadrants))], randSource)
+		if undo == nil {
+			continue
+		}
+
+		// The score of the squaredError scoring function is the error reduction in the neighborhood of the quadrant
+		errorAfter := getTotalSquaredError()
+		if (delta > 1e-9 && errorAfter <= errorBefore) || (delta < -1e-9 && errorAfter >= errorBefore) {
+			t.Errorf("The move lowers the score by %v but changes the squared error from %v to %v", delta, errorBefore, errorAfter)
+		}
+
+		undo()
+		if errorUndone := getTotalSquaredError(); math.Abs(errorUndone-errorBefore) > 1e-6 {
+			t.Fatalf("Undoing the move changed the squared error from %v to %v", errorBefore, errorUndone)
+		}
+	}
+
+	errorBefore := getTotalSquaredError()
+	annealShapes(randSource)
+	// Without temperature only moves that lower the squared error are accepted
+	if errorAfter := getTotalSquaredError(); errorAfter > errorBefore+1e-6 {
+		t.Errorf("The annealing raised the squared error from %v to %v", errorBefore, errorAfter)
+	}
+
+	for _, quadrant := range quadrants {
+		for index := range quadrant.Shapes {
+			shape := quadrant.Shapes[index]
+			if math.Abs(shape.rotation) > Config.shapeAngleDeviationRange {
+				t.Errorf("The shape is rotated by %v outside of the shapeAngleDeviationRange", shape.rotation)
+			}
+
+			orientation, anisotropy := shape.orientation, shape.anisotropy
+			shape.calculateOrientation()
+			if math.Abs(orientation-shape.orientation) > 1e-9 || math.Abs(anisotropy-shape.anisotropy) > 1e-9 {
+				t.Errorf("The orientation %v of the shape was not updated to %v", orientation, shape.orientation)
+			}
+		}
+	}
+	resetStaticVariables()
+}
+
+func TestAnnealingRestrictedOrientation(t *testing.T) {
+	initializeAnnealingTest(GradientOrientationEdge)
+
+	annealShapes(rand.New(rand.NewPCG(3, 4)))
+
+	shapeCount := 0
+	for _, quadrant := range quadrants {
+		for index := range quadrant.Shapes {
+			shapeCount++
+			if !isOrientationAllowed(quadrant, &quadrant.Shapes[index]) {
+				t.Errorf("The shape with the orientation %v is not allowed in a quadrant with the gradient angle %v",
+					quadrant.Shapes[index].orientation, quadrant.gradientAngle)
+			}
+		}
+	}
+	if shapeCount == 0 {
+		t.Error("The annealing did not add any shapes")
+	}
+	resetStaticVariables()
+}
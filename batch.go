@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var supportedImageExtensions = []string{".png", ".jpg", ".jpeg"}
+
+// Generates an artwork for every input image of the config. The shape variants are only initialized for the first
+// image and reused for all following images. Returns ExitSuccess if every artwork was written, ExitIOError if an image
+// could not be read or an artwork could not be written and ExitFailure otherwise.
+func runBatch() int {
+	inputPaths := Config.getInputImagePaths()
+	if len(inputPaths) == 0 {
+		fmt.Fprintln(os.Stderr, "No input images found!")
+		return ExitIOError
+	}
+
+	outputPathTemplate := Config.outputPath
+	if len(inputPaths) > 1 && !isOutputPathTemplate(outputPathTemplate) {
+		outputPathTemplate = strings.TrimSuffix(outputPathTemplate, filepath.Ext(outputPathTemplate)) + "_{name}" + filepath.Ext(outputPathTemplate)
+		fmt.Printf("The outputPath contains no placeholder. Using '%s' to prevent overwriting outputs.\n", outputPathTemplate)
+	}
+
+	finishedArtworks := 0
+	ioErrorOcurred := false
+	for index, inputPath := range inputPaths {
+		Config.inputPath = inputPath
+		Config.outputPath = getBatchOutputPath(outputPathTemplate, inputPath, index, Config.randomSeed)
+
+		if len(inputPaths) > 1 {
+			fmt.Printf("\n[%d/%d] %s -> %s\n", index+1, len(inputPaths), inputPath, Config.outputPath)
+		}
+
+		svg, err := startVecart()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Could not generate the artwork: %s\n", err)
+			ioErrorOcurred = true
+			continue
+		}
+		if generationCancelled.Load() {
+			break
+		}
+		if svg == "" {
+			continue
+		}
+
+		if writeStringToFile(svg, Config.outputPath) != nil {
+			ioErrorOcurred = true
+			continue
+		}
+		writePreviews(inputPaths)
+		reportPlotTime()
+		reportMetrics()
+		finishedArtworks++
+	}
+
+	if len(inputPaths) > 1 {
+		fmt.Printf("\nFinished %d of %d artworks\n", finishedArtworks, len(inputPaths))
+	}
+
+	switch {
+	case finishedArtworks == len(inputPaths):
+		return ExitSuccess
+	case ioErrorOcurred:
+		return ExitIOError
+	default:
+		return ExitFailure
+	}
+}
+
+// Returns the input path patterns of the config. The inputPath can either be a single path or an array of paths.
+func (config *VecartConfig) getInputPathPatterns() []string {
+	if len(config.inputPaths) > 0 {
+		return config.inputPaths
+	}
+
+	if config.inputPath != "" {
+		return []string{config.inputPath}
+	}
+
+	return nil
+}
+
+// Returns the paths of all input images. Directories are expanded to the images they contain and globs to the images
+// they match. An empty path stands for the example image.
+func (config *VecartConfig) getInputImagePaths() []string {
+	patterns := config.getInputPathPatterns()
+	if len(patterns) == 0 {
+		return []string{""}
+	}
+
+	var inputPaths []string
+	for _, pattern := range patterns {
+		inputPaths = append(inputPaths, expandInputPath(pattern)...)
+	}
+
+	return inputPaths
+}
+
+func expandInputPath(path string) []string {
+	if strings.ContainsAny(path, "*?[") {
+		matches, err := filepath.Glob(path)
+		if err != nil {
+			return nil
+		}
+
+		var imagePaths []string
+		for _, match := range matches {
+			if isSupportedImage(match) {
+				imagePaths = append(imagePaths, match)
+			}
+		}
+		return imagePaths
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		return []string{path}
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil
+	}
+
+	var imagePaths []string
+	for _, entry := range entries {
+		if !entry.IsDir() && isSupportedImage(entry.Name()) {
+			imagePaths = append(imagePaths, filepath.Join(path, entry.Name()))
+		}
+	}
+	slices.Sort(imagePaths)
+
+	return imagePaths
+}
+
+func isSupportedImage(path string) bool {
+	return slices.Contains(supportedImageExtensions, strings.ToLower(filepath.Ext(path)))
+}
+
+func isOutputPathTemplate(path string) bool {
+	return strings.Contains(path, "{name}") || strings.Contains(path, "{index}")
+}
+
+// Replaces the placeholders {name} (file name of the input image without extension), {index} (position of the image
+// in the batch starting at 1) and {seed} (randomSeed) in the outputPath.
+func getBatchOutputPath(template, inputPath string, index, seed int) string {
+	name := "ellie"
+	if inputPath != "" {
+		name = strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
+	}
+
+	return strings.NewReplacer(
+		"{name}", name,
+		"{index}", strconv.Itoa(index+1),
+		"{seed}", strconv.Itoa(seed),
+	).Replace(template)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"image"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestImage(t *testing.T, path string) {
+	// A white image needs no shapes, which keeps the test fast
+	img := image.NewGray(image.Rect(0, 0, 40, 40))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if err = png.Encode(file, img); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func initializeBatchTest(t *testing.T) string {
+	resetStaticVariables()
+	directory := t.TempDir()
+	writeTestImage(t, filepath.Join(directory, "a.png"))
+	writeTestImage(t, filepath.Join(directory, "b.png"))
+
+	Config.inputPath = directory
+	Config.outputPath = filepath.Join(directory, "out", "{name}.svg")
+	Config.artworkWidth = 20
+	Config.artworkHeight = 0
+
+	return directory
+}
+
+func TestBatch(t *testing.T) {
+	directory := initializeBatchTest(t)
+	if err := os.Mkdir(filepath.Join(directory, "out"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if exitCode := runBatch(); exitCode != ExitSuccess {
+		t.Errorf("The batch failed with %d although every artwork was written", exitCode)
+	}
+	for _, name := range []string{"a.svg", "b.svg"} {
+		if _, err := os.Stat(filepath.Join(directory, "out", name)); err != nil {
+			t.Errorf("The artwork '%s' was not written", name)
+		}
+	}
+	resetStaticVariables()
+}
+
+func TestBatchWithFailedArtwork(t *testing.T) {
+	directory := initializeBatchTest(t)
+	if err := os.Mkdir(filepath.Join(directory, "out"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// Sorted between the valid images, so the batch has to continue after it
+	if err := os.WriteFile(filepath.Join(directory, "aa.png"), []byte("no image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if exitCode := runBatch(); exitCode != ExitIOError {
+		t.Errorf("Expected the exit code %d for an image that could not be decoded, got %d", ExitIOError, exitCode)
+	}
+	for _, name := range []string{"a.svg", "b.svg"} {
+		if _, err := os.Stat(filepath.Join(directory, "out", name)); err != nil {
+			t.Errorf("The artwork '%s' was not written", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(directory, "out", "aa.svg")); err == nil {
+		t.Error("An artwork was written for the invalid image")
+	}
+	resetStaticVariables()
+}
+
+func TestBatchWithUnwritableOutput(t *testing.T) {
+	initializeBatchTest(t)
+
+	// The output directory does not exist
+	if exitCode := runBatch(); exitCode != ExitIOError {
+		t.Errorf("Expected the exit code %d if no artwork could be written, got %d", ExitIOError, exitCode)
+	}
+	resetStaticVariables()
+}
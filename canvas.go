@@ -0,0 +1,579 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	CanvasRectangle        = "rectangle"
+	CanvasCircle           = "circle"
+	CanvasEllipse          = "ellipse"
+	CanvasRoundedRectangle = "roundedRectangle"
+	CanvasPolygon          = "polygon"
+	CanvasPath             = "path"
+)
+
+// The number of line segments used for approximating curves of the canvas border
+const canvasCurveSegments = 180
+
+// The shape of the canvas. Circles and ellipses are inscribed into the artwork, polygons and paths are given in
+// millimetres relative to the top left corner of the artwork.
+type CanvasShape struct {
+	shapeType    string
+	cornerRadius float64
+	points       []Point
+	path         string
+}
+
+// The border of the canvas in processing pixels as closed rings. Points are inside if they are enclosed by an odd
+// number of rings, which allows paths with holes.
+type CanvasBoundary struct {
+	rings [][]Point
+}
+
+// The boundary of the current artwork. Nil for rectangular canvases, which are handled by canvasContains and
+// getCanvasBorderIntersect.
+var canvasBoundary *CanvasBoundary
+
+func NewCanvasShape() CanvasShape {
+	return CanvasShape{shapeType: CanvasRectangle}
+}
+
+func (canvas *CanvasShape) equalTo(otherCanvas *CanvasShape) bool {
+	if canvas.shapeType != otherCanvas.shapeType || canvas.cornerRadius != otherCanvas.cornerRadius || canvas.path != otherCanvas.path {
+		return false
+	}
+
+	return slices.Equal(canvas.points, otherCanvas.points)
+}
+
+func (canvas *CanvasShape) toJSON() any {
+	switch canvas.shapeType {
+	case CanvasRoundedRectangle:
+		return map[string]any{"type": canvas.shapeType, "cornerRadius": canvas.cornerRadius}
+	case CanvasPolygon:
+		var points [][]float64
+		for index := range canvas.points {
+			points = append(points, canvas.points[index].toJSON())
+		}
+		return map[string]any{"type": canvas.shapeType, "points": points}
+	case CanvasPath:
+		return map[string]any{"type": canvas.shapeType, "d": canvas.path}
+	}
+
+	return canvas.shapeType
+}
+
+// Reads the canvas. Rectangles, circles and ellipses can be given by their name, all shapes can be given as object
+// with a 'type' attribute.
+func getCanvas(jsonData map[string]any, key string, canvas *CanvasShape, dpi float64) {
+	value, ok := jsonData[key]
+	if !ok {
+		return
+	}
+
+	switch typedValue := value.(type) {
+	default:
+		errorsOcurred = true
+		errors = append(errors, "Unexpected type for '"+key+"' | expected string or object")
+	case string:
+		canvas.shapeType = typedValue
+	case map[string]any:
+		for parameter := range typedValue {
+			if !slices.Contains([]string{"type", "cornerRadius", "points", "d"}, parameter) {
+				errorsOcurred = true
+				errors = append(errors, "Unkown Key in canvas definition '"+parameter+"'")
+			}
+		}
+
+		getString(typedValue, "type", &canvas.shapeType)
+		getLength(typedValue, "cornerRadius", &canvas.cornerRadius, "mm", dpi)
+		getString(typedValue, "d", &canvas.path)
+
+		pointsAny, ok := getArray(typedValue, "points")
+		if ok {
+			for _, point := range pointsAny {
+				getPoint(point, &canvas.points, dpi)
+			}
+		}
+	}
+}
+
+func (canvas *CanvasShape) validate() bool {
+	switch canvas.shapeType {
+	case CanvasRectangle, CanvasCircle, CanvasEllipse:
+		return true
+	case CanvasRoundedRectangle:
+		if canvas.cornerRadius < 0 {
+			errors = append(errors, "The cornerRadius of the canvas must be greater or equal to 0!")
+			return false
+		}
+		return true
+	case CanvasPolygon:
+		if len(canvas.points) < 3 {
+			errors = append(errors, "A polygon canvas needs at least 3 points!")
+			return false
+		}
+		return true
+	case CanvasPath:
+		rings, err := parseSVGPath(canvas.path)
+		if err != nil {
+			errors = append(errors, "Invalid canvas path: "+err.Error())
+			return false
+		}
+		if len(rings) == 0 {
+			errors = append(errors, "The canvas path does not enclose an area!")
+			return false
+		}
+		return true
+	}
+
+	errors = append(errors, "Invalid canvas type '"+canvas.shapeType+"'! Valid options are '"+strings.Join([]string{CanvasRectangle,
+		CanvasCircle, CanvasEllipse, CanvasRoundedRectangle, CanvasPolygon, CanvasPath}, "', '")+"'.")
+	return false
+}
+
+// Returns the boundary of the canvas for an artwork of the given size in processing pixels. Returns nil for
+// rectangular canvases.
+func (canvas *CanvasShape) getBoundary(width, height float64) *CanvasBoundary {
+	var rings [][]Point
+
+	switch canvas.shapeType {
+	case CanvasCircle:
+		radius := math.Min(width, height) / 2
+		rings = append(rings, getEllipsePoints(width/2, height/2, radius, radius))
+	case CanvasEllipse:
+		rings = append(rings, getEllipsePoints(width/2, height/2, width/2, height/2))
+	case CanvasRoundedRectangle:
+		radius := math.Min(mmToPixel(canvas.cornerRadius, Config.processingDpi), math.Min(width, height)/2)
+		rings = append(rings, getRoundedRectanglePoints(width, height, radius))
+	case CanvasPolygon:
+		rings = append(rings, canvasPointsToPixel(canvas.points))
+	case CanvasPath:
+		pathRings, _ := parseSVGPath(canvas.path)
+		for _, ring := range pathRings {
+			rings = append(rings, canvasPointsToPixel(ring))
+		}
+	default:
+		return nil
+	}
+
+	return &CanvasBoundary{rings}
+}
+
+func canvasPointsToPixel(points []Point) []Point {
+	var pixelPoints []Point
+	for _, point := range points {
+		pixelPoint := point.copy()
+		pixelPoint.mmToPixel(Config.processingDpi)
+		pixelPoints = append(pixelPoints, pixelPoint)
+	}
+
+	return pixelPoints
+}
+
+func getEllipsePoints(centerX, centerY, radiusX, radiusY float64) []Point {
+	var points []Point
+	for index := range canvasCurveSegments {
+		angle := 2 * math.Pi * float64(index) / canvasCurveSegments
+		points = append(points, Point{centerX + radiusX*math.Cos(angle), centerY + radiusY*math.Sin(angle)})
+	}
+
+	return points
+}
+
+func getRoundedRectanglePoints(width, height, radius float64) []Point {
+	if radius == 0 {
+		return []Point{{0, 0}, {width, 0}, {width, height}, {0, height}}
+	}
+
+	corners := []Point{{width - radius, radius}, {width - radius, height - radius}, {radius, height - radius}, {radius, radius}}
+	segmentsPerCorner := canvasCurveSegments / 4
+
+	var points []Point
+	for cornerIndex, corner := range corners {
+		startAngle := -math.Pi/2 + float64(cornerIndex)*math.Pi/2
+		for index := 0; index <= segmentsPerCorner; index++ {
+			angle := startAngle + math.Pi/2*float64(index)/float64(segmentsPerCorner)
+			points = append(points, Point{corner.X + radius*math.Cos(angle), corner.Y + radius*math.Sin(angle)})
+		}
+	}
+
+	return points
+}
+
+func (boundary *CanvasBoundary) contains(point *Point) bool {
+	inside := false
+	for _, ring := range boundary.rings {
+		if pointInPolygon(point, ring) {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
+
+// Makes all pixels outside of the canvas white so that no shapes are placed there
+func (boundary *CanvasBoundary) maskImage(img *image.Gray) {
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if !boundary.contains(&Point{float64(x) + 0.5, float64(y) + 0.5}) {
+				img.SetGray(x, y, color.Gray{255})
+			}
+		}
+	}
+}
+
+// Disables the quadrants that lie completely outside of the canvas. They count as done, so no shapes are placed in
+// them, and they are left out when shapes are scored.
+func (boundary *CanvasBoundary) disableQuadrants(quadrants []*Quadrant) {
+	for _, quadrant := range quadrants {
+		quadrant.outsideCanvas = !slices.ContainsFunc(quadrant.FlattenPixels, func(pixel *Pixel) bool {
+			return boundary.contains(&pixel.midpoint)
+		})
+	}
+}
+
+// Returns a mask of the canvas for an image of the given size. The scale converts the pixels of the image to processing
+// pixels. Pixels inside of the canvas are white.
+func (boundary *CanvasBoundary) getMask(width, height int, scale float64) *image.Gray {
+	mask := image.NewGray(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			if boundary.contains(&Point{(float64(x) + 0.5) * scale, (float64(y) + 0.5) * scale}) {
+				mask.SetGray(x, y, color.Gray{255})
+			}
+		}
+	}
+
+	return mask
+}
+
+// Cuts the line segments at the border of the canvas and returns the parts inside of the canvas. In contrast to
+// rectangular canvases a segment can cross the border several times.
+func (boundary *CanvasBoundary) clipSegments(segments []Polyline) []Polyline {
+	var clippedSegments []Polyline
+
+	for _, segment := range segments {
+		p1, p2 := &segment.points[0], &segment.points[1]
+		length := p1.distanceTo(p2)
+		if length == 0 {
+			if boundary.contains(p1) {
+				clippedSegments = append(clippedSegments, segment)
+			}
+			continue
+		}
+
+		positions := []float64{0, 1}
+		for _, ring := range boundary.rings {
+			for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+				intersection, ok := calculateLineIntersection(p1, p2, &ring[j], &ring[i])
+				if ok {
+					positions = append(positions, p1.distanceTo(&intersection)/length)
+				}
+			}
+		}
+		slices.Sort(positions)
+
+		for index := 1; index < len(positions); index++ {
+			start, end := positions[index-1], positions[index]
+			if end-start < 1e-9 {
+				continue
+			}
+
+			midpoint := interpolatePoint(p1, p2, (start+end)/2)
+			if boundary.contains(&midpoint) {
+				clippedSegments = append(clippedSegments, Polyline{[]Point{interpolatePoint(p1, p2, start), interpolatePoint(p1, p2, end)}, nil})
+			}
+		}
+	}
+
+	return clippedSegments
+}
+
+func interpolatePoint(p1, p2 *Point, position float64) Point {
+	return Point{p1.X + (p2.X-p1.X)*position, p1.Y + (p2.Y-p1.Y)*position}
+}
+
+// Parses the outline of an SVG path into closed rings. Supports the commands M, L, H, V, C, S, Q, T, A and Z in their
+// absolute and relative form. Curves are approximated with line segments.
+func parseSVGPath(path string) ([][]Point, error) {
+	tokens, err := tokenizeSVGPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rings [][]Point
+	var ring []Point
+	var current, start, lastControl Point
+	var command byte
+	var lastCommand byte
+
+	finishRing := func() {
+		if len(ring) >= 3 {
+			rings = append(rings, ring)
+		}
+		ring = nil
+	}
+
+	index := 0
+	numbers := func(count int) ([]float64, error) {
+		var values []float64
+		for range count {
+			if index >= len(tokens) || tokens[index].command != 0 {
+				return nil, fmt.Errorf("missing parameters for command '%c'", command)
+			}
+			values = append(values, tokens[index].value)
+			index++
+		}
+		return values, nil
+	}
+
+	for index < len(tokens) {
+		if tokens[index].command != 0 {
+			command = tokens[index].command
+			index++
+		} else if command == 0 {
+			return nil, fmt.Errorf("path has to start with a command")
+		}
+
+		relative := command >= 'a' && command <= 'z'
+		offset := Point{0, 0}
+		if relative {
+			offset = current
+		}
+
+		switch command {
+		case 'M', 'm':
+			values, err := numbers(2)
+			if err != nil {
+				return nil, err
+			}
+			finishRing()
+			current = Point{offset.X + values[0], offset.Y + values[1]}
+			start = current
+			ring = []Point{current}
+			// Further coordinate pairs are implicit line commands
+			command = 'L'
+			if relative {
+				command = 'l'
+			}
+		case 'L', 'l':
+			values, err := numbers(2)
+			if err != nil {
+				return nil, err
+			}
+			current = Point{offset.X + values[0], offset.Y + values[1]}
+			ring = append(ring, current)
+		case 'H', 'h':
+			values, err := numbers(1)
+			if err != nil {
+				return nil, err
+			}
+			current = Point{offset.X + values[0], current.Y}
+			ring = append(ring, current)
+		case 'V', 'v':
+			values, err := numbers(1)
+			if err != nil {
+				return nil, err
+			}
+			current = Point{current.X, offset.Y + values[0]}
+			ring = append(ring, current)
+		case 'C', 'c', 'S', 's':
+			var control1 Point
+			var values []float64
+			var err error
+			if command == 'C' || command == 'c' {
+				values, err = numbers(6)
+				if err != nil {
+					return nil, err
+				}
+				control1 = Point{offset.X + values[0], offset.Y + values[1]}
+				values = values[2:]
+			} else {
+				values, err = numbers(4)
+				if err != nil {
+					return nil, err
+				}
+				control1 = current
+				if strings.ContainsRune("CcSs", rune(lastCommand)) {
+					control1 = Point{2*current.X - lastControl.X, 2*current.Y - lastControl.Y}
+				}
+			}
+			control2 := Point{offset.X + values[0], offset.Y + values[1]}
+			end := Point{offset.X + values[2], offset.Y + values[3]}
+			ring = append(ring, getCubicBezierPoints(current, control1, control2, end)...)
+			current, lastControl = end, control2
+		case 'Q', 'q', 'T', 't':
+			var control Point
+			var end Point
+			if command == 'Q' || command == 'q' {
+				values, err := numbers(4)
+				if err != nil {
+					return nil, err
+				}
+				control = Point{offset.X + values[0], offset.Y + values[1]}
+				end = Point{offset.X + values[2], offset.Y + values[3]}
+			} else {
+				values, err := numbers(2)
+				if err != nil {
+					return nil, err
+				}
+				control = current
+				if strings.ContainsRune("QqTt", rune(lastCommand)) {
+					control = Point{2*current.X - lastControl.X, 2*current.Y - lastControl.Y}
+				}
+				end = Point{offset.X + values[0], offset.Y + values[1]}
+			}
+			control1 := Point{current.X + 2.0/3*(control.X-current.X), current.Y + 2.0/3*(control.Y-current.Y)}
+			control2 := Point{end.X + 2.0/3*(control.X-end.X), end.Y + 2.0/3*(control.Y-end.Y)}
+			ring = append(ring, getCubicBezierPoints(current, control1, control2, end)...)
+			current, lastControl = end, control
+		case 'A', 'a':
+			values, err := numbers(7)
+			if err != nil {
+				return nil, err
+			}
+			end := Point{offset.X + values[5], offset.Y + values[6]}
+			ring = append(ring, getArcPoints(current, end, values[0], values[1], values[2], values[3] != 0, values[4] != 0)...)
+			current = end
+		case 'Z', 'z':
+			finishRing()
+			current = start
+			ring = []Point{current}
+		default:
+			return nil, fmt.Errorf("unsupported command '%c'", command)
+		}
+
+		lastCommand = command
+	}
+	finishRing()
+
+	return rings, nil
+}
+
+type SVGPathToken struct {
+	command byte
+	value   float64
+}
+
+func tokenizeSVGPath(path string) ([]SVGPathToken, error) {
+	var tokens []SVGPathToken
+
+	index := 0
+	for index < len(path) {
+		char := path[index]
+		switch {
+		case char == ' ' || char == ',' || char == '\t' || char == '\n' || char == '\r':
+			index++
+		case strings.ContainsRune("MmLlHhVvCcSsQqTtAaZz", rune(char)):
+			tokens = append(tokens, SVGPathToken{command: char})
+			index++
+		case strings.ContainsRune("0123456789+-.", rune(char)):
+			start := index
+			index++
+			seenDot := char == '.'
+			for index < len(path) {
+				char = path[index]
+				if char >= '0' && char <= '9' {
+					index++
+				} else if char == '.' && !seenDot {
+					seenDot = true
+					index++
+				} else if (char == 'e' || char == 'E') && index+1 < len(path) {
+					index++
+					if path[index] == '+' || path[index] == '-' {
+						index++
+					}
+				} else {
+					break
+				}
+			}
+			value, err := strconv.ParseFloat(path[start:index], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number '%s'", path[start:index])
+			}
+			tokens = append(tokens, SVGPathToken{value: value})
+		default:
+			return nil, fmt.Errorf("unexpected character '%c'", char)
+		}
+	}
+
+	return tokens, nil
+}
+
+func getCubicBezierPoints(start, control1, control2, end Point) []Point {
+	var points []Point
+	steps := canvasCurveSegments / 8
+	for step := 1; step <= steps; step++ {
+		t := float64(step) / float64(steps)
+		u := 1 - t
+		points = append(points, Point{
+			u*u*u*start.X + 3*u*u*t*control1.X + 3*u*t*t*control2.X + t*t*t*end.X,
+			u*u*u*start.Y + 3*u*u*t*control1.Y + 3*u*t*t*control2.Y + t*t*t*end.Y,
+		})
+	}
+
+	return points
+}
+
+// Approximates an elliptical arc given in the endpoint parameterization of SVG (see the SVG specification, appendix
+// F.6.5)
+func getArcPoints(start, end Point, radiusX, radiusY, rotation float64, largeArc, sweep bool) []Point {
+	radiusX, radiusY = math.Abs(radiusX), math.Abs(radiusY)
+	if radiusX == 0 || radiusY == 0 || start == end {
+		return []Point{end}
+	}
+
+	phi := rotation * math.Pi / 180
+	cosPhi, sinPhi := math.Cos(phi), math.Sin(phi)
+	dx, dy := (start.X-end.X)/2, (start.Y-end.Y)/2
+	x1 := cosPhi*dx + sinPhi*dy
+	y1 := -sinPhi*dx + cosPhi*dy
+
+	// Radii that are too small are scaled up until the arc reaches the end point
+	lambda := (x1*x1)/(radiusX*radiusX) + (y1*y1)/(radiusY*radiusY)
+	if lambda > 1 {
+		radiusX *= math.Sqrt(lambda)
+		radiusY *= math.Sqrt(lambda)
+	}
+
+	numerator := radiusX*radiusX*radiusY*radiusY - radiusX*radiusX*y1*y1 - radiusY*radiusY*x1*x1
+	denominator := radiusX*radiusX*y1*y1 + radiusY*radiusY*x1*x1
+	factor := math.Sqrt(math.Max(numerator/denominator, 0))
+	if largeArc == sweep {
+		factor = -factor
+	}
+	centerX1 := factor * radiusX * y1 / radiusY
+	centerY1 := -factor * radiusY * x1 / radiusX
+
+	centerX := cosPhi*centerX1 - sinPhi*centerY1 + (start.X+end.X)/2
+	centerY := sinPhi*centerX1 + cosPhi*centerY1 + (start.Y+end.Y)/2
+
+	startAngle := math.Atan2((y1-centerY1)/radiusY, (x1-centerX1)/radiusX)
+	deltaAngle := math.Atan2((-y1-centerY1)/radiusY, (-x1-centerX1)/radiusX) - startAngle
+	if sweep && deltaAngle < 0 {
+		deltaAngle += 2 * math.Pi
+	} else if !sweep && deltaAngle > 0 {
+		deltaAngle -= 2 * math.Pi
+	}
+
+	steps := max(int(math.Ceil(math.Abs(deltaAngle)/(2*math.Pi)*canvasCurveSegments)), 1)
+	var points []Point
+	for step := 1; step <= steps; step++ {
+		angle := startAngle + deltaAngle*float64(step)/float64(steps)
+		x := radiusX * math.Cos(angle)
+		y := radiusY * math.Sin(angle)
+		points = append(points, Point{cosPhi*x - sinPhi*y + centerX, sinPhi*x + cosPhi*y + centerY})
+	}
+	points[len(points)-1] = end
+
+	return points
+}
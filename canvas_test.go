@@ -0,0 +1,90 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestCanvasShapes(t *testing.T) {
+	resetStaticVariables()
+
+	config := NewConfig()
+	ok := config.fromJSON(`{"canvas": {"type": "path", "d": "M0 0 H10 V10 H0 Z m2 2 h6 v6 h-6 z"}}`)
+	if !ok || config.canvas.shapeType != CanvasPath {
+		t.Fatalf("Parsing the canvas failed! %v", errors)
+	}
+
+	Config.processingDpi = 25.4
+	boundary := config.canvas.getBoundary(10, 10)
+	if !boundary.contains(NewPoint(1, 1)) || boundary.contains(NewPoint(5, 5)) || boundary.contains(NewPoint(11, 5)) {
+		t.Error("The canvas path has to contain the frame but not the hole")
+	}
+
+	// A line through the frame and the hole is cut into the two parts on the frame
+	clipped := boundary.clipSegments([]Polyline{{[]Point{{-5, 5}, {15, 5}}, nil}})
+	if len(clipped) != 2 || !clipped[0].points[0].equalTo(NewPoint(0, 5), 6) || !clipped[0].points[1].equalTo(NewPoint(2, 5), 6) ||
+		!clipped[1].points[0].equalTo(NewPoint(8, 5), 6) || !clipped[1].points[1].equalTo(NewPoint(10, 5), 6) {
+		t.Errorf("Clipping at the canvas border failed! %v", clipped)
+	}
+
+	circle := CanvasShape{shapeType: CanvasCircle}
+	boundary = circle.getBoundary(20, 10)
+	if !boundary.contains(NewPoint(10, 5)) || boundary.contains(NewPoint(4, 5)) {
+		t.Error("The circle has to be inscribed into the artwork")
+	}
+
+	resetStaticVariables()
+	config = NewConfig()
+	if config.fromJSON(`{"canvas": "hexagon"}`) || config.fromJSON(`{"canvas": {"type": "path", "d": "M0 0 X"}}`) {
+		t.Error("Invalid canvas definitions have to be rejected")
+	}
+	resetStaticVariables()
+}
+
+func TestCanvasQuadrants(t *testing.T) {
+	resetStaticVariables()
+
+	circle := CanvasShape{shapeType: CanvasCircle}
+	boundary := circle.getBoundary(30, 30)
+
+	// The quadrant in the corner lies completely outside of the circle
+	corner := &Quadrant{FlattenPixels: []*Pixel{{midpoint: Point{0.5, 0.5}}, {midpoint: Point{2.5, 2.5}}}}
+	center := &Quadrant{FlattenPixels: []*Pixel{{midpoint: Point{15.5, 15.5}}}, Neighbors: []*Quadrant{corner}}
+	corner.Neighbors = []*Quadrant{center}
+	boundary.disableQuadrants([]*Quadrant{corner, center})
+
+	if !corner.outsideCanvas || center.outsideCanvas {
+		t.Fatal("Only quadrants completely outside of the canvas have to be disabled")
+	}
+	if !corner.isDone() {
+		t.Error("Quadrants outside of the canvas have to be done from the start")
+	}
+	if neighborhood := center.getNeighborhood(); len(neighborhood) != 1 || neighborhood[0] != center {
+		t.Errorf("Quadrants outside of the canvas must not be part of the neighborhood, got %d quadrants", len(neighborhood))
+	}
+
+	// Every pixel of the mask covers 2x2 processing pixels
+	mask := boundary.getMask(15, 15, 2)
+	if mask.GrayAt(7, 7).Y != 255 || mask.GrayAt(0, 0).Y != 0 {
+		t.Error("The mask has to be white inside of the canvas and black outside")
+	}
+}
+
+func TestCanvasNotchClipping(t *testing.T) {
+	resetStaticVariables()
+
+	// An L-shaped canvas with a notch in the upper right corner
+	Config.processingDpi = 25.4
+	canvas := CanvasShape{shapeType: CanvasPolygon, points: []Point{{0, 0}, {4, 0}, {4, 6}, {10, 6}, {10, 10}, {0, 10}}}
+	canvasBoundary = canvas.getBoundary(10, 10)
+
+	// Both ends of the line are inside the canvas but the line crosses the notch
+	line := Polyline{[]Point{{2, 2}, {8, 8}}, nil}
+	quadrants = []*Quadrant{{Shapes: []Shape{*NewShape([]Polyline{line})}}}
+	smoothEdges(10, 10)
+
+	lines := quadrants[0].Shapes[0].Lines
+	if len(lines) != 2 || !lines[0].points[1].equalTo(NewPoint(4, 4), 6) || !lines[1].points[0].equalTo(NewPoint(6, 6), 6) {
+		t.Errorf("Lines crossing a notch of the canvas have to be cut! %v", lines)
+	}
+	resetStaticVariables()
+}
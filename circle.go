@@ -35,11 +35,7 @@ func (circle *Circle) toPolyline(edges int) *Polyline {
 }
 
 func (circle *Circle) toShape() *Shape {
-	line := circle.toPolyline(6)
-	shape := Shape{[]Polyline{*line}, nil, Point{0, 0}}
-	shape.calculateCentroid()
-
-	return &shape
+	return NewSingleLineShape(*circle.toPolyline(6))
 }
 
 func (circle *Circle) rotate(angle float64, origin Point) {
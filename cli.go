@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	ExitSuccess     = 0
+	ExitFailure     = 1
+	ExitUsage       = 2
+	ExitConfigError = 3
+	ExitIOError     = 4
+)
+
+// The options given on the command line. Input paths, output path, seed and the '--set' overrides replace the
+// corresponding values of the config before it is parsed so that they are validated like the rest of the config.
+type CommandLineOptions struct {
+	configPath string
+	inputPaths []string
+	outputPath string
+	seed       int
+	seedSet    bool
+	overrides  []string
+	yes        bool
+	strict     bool
+	quiet      bool
+}
+
+// A flag that can be given multiple times
+type stringListFlag []string
+
+func (list *stringListFlag) String() string {
+	return strings.Join(*list, ", ")
+}
+
+func (list *stringListFlag) Set(value string) error {
+	*list = append(*list, value)
+	return nil
+}
+
+// Parses the command line. The config can either be given with '--config' or as first positional argument. All other
+// positional arguments are input paths. Flags and positional arguments can be mixed.
+func parseCommandLine(args []string) (*CommandLineOptions, error) {
+	options := CommandLineOptions{}
+	var inputPaths, overrides stringListFlag
+
+	flags := flag.NewFlagSet("Vecart", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.StringVar(&options.configPath, "config", "", "")
+	flags.Var(&inputPaths, "input", "")
+	flags.StringVar(&options.outputPath, "output", "", "")
+	flags.IntVar(&options.seed, "seed", 0, "")
+	flags.Var(&overrides, "set", "")
+	flags.BoolVar(&options.yes, "yes", false, "")
+	flags.BoolVar(&options.strict, "strict", false, "")
+	flags.BoolVar(&options.quiet, "quiet", false, "")
+
+	var positional []string
+	for {
+		err := flags.Parse(args)
+		if err != nil {
+			return nil, err
+		}
+		args = flags.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			options.seedSet = true
+		}
+	})
+
+	if options.configPath == "" && len(positional) > 0 {
+		options.configPath = positional[0]
+		positional = positional[1:]
+	}
+	options.inputPaths = append(inputPaths, positional...)
+	options.overrides = overrides
+
+	if options.yes && options.strict {
+		return nil, fmt.Errorf("--yes and --strict can not be combined")
+	}
+
+	for _, override := range options.overrides {
+		if !strings.Contains(override, "=") {
+			return nil, fmt.Errorf("invalid override '%s' | expected key=value", override)
+		}
+	}
+
+	return &options, nil
+}
+
+// Returns true if the options change the config
+func (options *CommandLineOptions) hasOverrides() bool {
+	return len(options.inputPaths) > 0 || options.outputPath != "" || options.seedSet || len(options.overrides) > 0
+}
+
+// Applies the command line options to the JSON config. Values of '--set' are parsed as JSON if possible and used as
+// strings otherwise (e.g. '--set strokeColor=red').
+func (options *CommandLineOptions) applyOverrides(content string) (string, error) {
+	jsonData := make(map[string]any)
+	if strings.TrimSpace(content) != "" {
+		err := json.Unmarshal([]byte(content), &jsonData)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if len(options.inputPaths) == 1 {
+		jsonData["inputPath"] = options.inputPaths[0]
+	} else if len(options.inputPaths) > 1 {
+		jsonData["inputPath"] = options.inputPaths
+	}
+
+	if options.outputPath != "" {
+		jsonData["outputPath"] = options.outputPath
+	}
+
+	if options.seedSet {
+		jsonData["randomSeed"] = options.seed
+	}
+
+	for _, override := range options.overrides {
+		key, value, _ := strings.Cut(override, "=")
+		var parsedValue any
+		if json.Unmarshal([]byte(value), &parsedValue) != nil {
+			parsedValue = value
+		}
+		jsonData[strings.TrimSpace(key)] = parsedValue
+	}
+
+	jsonBytes, err := json.MarshalIndent(jsonData, "", "    ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonBytes), nil
+}
+
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Discards everything written to stdout. Errors are written to stderr and remain visible.
+func silenceStdout() {
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		return
+	}
+
+	os.Stdout = devNull
+}
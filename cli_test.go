@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Runs main instead of the tests if the test binary was started by runVecart
+func TestMain(m *testing.M) {
+	if encodedArgs := os.Getenv("VECART_TEST_ARGS"); encodedArgs != "" {
+		var args []string
+		json.Unmarshal([]byte(encodedArgs), &args)
+		os.Args = append([]string{"vecart"}, args...)
+		main()
+		os.Exit(ExitSuccess)
+	}
+
+	os.Exit(m.Run())
+}
+
+// Runs main with the arguments in a separate process and returns the exit code, stdout and stderr
+func runVecart(t *testing.T, args ...string) (int, string, string) {
+	encodedArgs, err := json.Marshal(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	command := exec.Command(os.Args[0])
+	command.Env = append(os.Environ(), "VECART_TEST_ARGS="+string(encodedArgs))
+	var stdout, stderr bytes.Buffer
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+
+	err = command.Run()
+	if exitError, ok := err.(*exec.ExitError); ok {
+		return exitError.ExitCode(), stdout.String(), stderr.String()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return 0, stdout.String(), stderr.String()
+}
+
+func TestExitCodes(t *testing.T) {
+	directory := t.TempDir()
+	configPath := filepath.Join(directory, "config.json")
+	imagePath := filepath.Join(directory, "image.png")
+	writeTestImage(t, imagePath)
+	if err := os.WriteFile(configPath, []byte(`{"artworkWidth": 20, "artworkHeight": 0, "metrics": false}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(directory, "invalid.png"), []byte("no image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// The artwork can not be written since a directory has its path
+	if err := os.Mkdir(filepath.Join(directory, "directory.svg"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		args           []string
+		exitCode       int
+		expectedStderr string
+	}{
+		{[]string{"--quiet", "--output", filepath.Join(directory, "art.svg"), configPath, imagePath}, ExitSuccess, ""},
+		{[]string{"--unknown"}, ExitUsage, "unknown"},
+		{[]string{"sweep"}, ExitUsage, ""},
+		{[]string{"watch"}, ExitUsage, ""},
+		{[]string{"--strict", "--set", "darknessThreshold=\"dark\"", configPath, imagePath}, ExitConfigError, "darknessThreshold"},
+		{[]string{"--quiet", filepath.Join(directory, "missing.json")}, ExitIOError, "Could not read config"},
+		{[]string{"--quiet", configPath, filepath.Join(directory, "invalid.png")}, ExitIOError, "invalid.png"},
+		{[]string{"--quiet", "--output", filepath.Join(directory, "directory.svg"), configPath, imagePath}, ExitIOError, "Could not create file"},
+	}
+
+	for _, testCase := range testCases {
+		exitCode, stdout, stderr := runVecart(t, testCase.args...)
+		if exitCode != testCase.exitCode {
+			t.Errorf("%v: expected exit code %d, got %d\n%s%s", testCase.args, testCase.exitCode, exitCode, stdout, stderr)
+		}
+		if !strings.Contains(stderr, testCase.expectedStderr) {
+			t.Errorf("%v: expected '%s' on stderr, got '%s'", testCase.args, testCase.expectedStderr, stderr)
+		}
+		if strings.Contains(strings.Join(testCase.args, " "), "--quiet") && stdout != "" {
+			t.Errorf("%v: --quiet must suppress the output, got '%s'", testCase.args, stdout)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(directory, "art.svg")); err != nil {
+		t.Error("The artwork was not written")
+	}
+}
+
+func TestCommandLineOverrides(t *testing.T) {
+	options, err := parseCommandLine([]string{"config.json", "--seed", "3", "--set", "strokeColor=red", "--set", "quadrantWidth=7", "a.png", "--output", "out.svg"})
+	if err != nil {
+		t.Fatal("Parsing the command line failed!")
+	}
+
+	if options.configPath != "config.json" || len(options.inputPaths) != 1 || options.inputPaths[0] != "a.png" {
+		t.Error("Parsing the positional arguments failed!")
+	}
+
+	content, err := options.applyOverrides(`{"quadrantWidth": 3, "darknessThreshold": 12}`)
+	if err != nil {
+		t.Fatal("Applying the command line options failed!")
+	}
+
+	baseConfig := NewConfig()
+	baseConfig.inputPath = "a.png"
+	baseConfig.outputPath = "out.svg"
+	baseConfig.randomSeed = 3
+	baseConfig.strokeColor = "red"
+	baseConfig.quadrantWidth = 7
+	baseConfig.darknessThreshold = 12
+
+	config := NewConfig()
+	config.fromJSON(content)
+
+	if !config.equalTo(&baseConfig) {
+		t.Error("Overriding the config with command line options failed!")
+	}
+
+	_, err = parseCommandLine([]string{"--yes", "--strict"})
+	if err == nil {
+		t.Error("Combining --yes and --strict should fail!")
+	}
+}
@@ -3,20 +3,34 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
 	"math"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 )
 
 var errorsOcurred bool
 var errors []string
+
+// The errors of the last parsed config that concern a specific parameter. They are contained in errors as well.
+var configErrors []ConfigError
 var debug bool
 
 type VecartConfig struct {
 	inputPath     string
+	inputPaths    []string
 	outputPath    string
-	artworkWidth  int
-	artworkHeight int
+	artworkWidth  float64
+	artworkHeight float64
+
+	paper       string
+	orientation string
+	margins     [4]float64
+	fit         string
+	canvas      CanvasShape
 
 	quadrantWidth          int
 	quadrantHeight         int
@@ -37,6 +51,19 @@ type VecartConfig struct {
 	combineShapesIterations       int
 	strokeWidth                   float64
 	strokeColor                   string
+	fillClosedShapes              bool
+	laserLayers                   bool
+	laserCutOutline               bool
+	hatchFill                     bool
+	hatchSpacing                  float64
+	hatchAngle                    float64
+	offsetStrokes                 bool
+	penWidth                      float64
+	offsetJoin                    string
+	offsetCap                     string
+	dxfLayers                     string
+	ebbPenUpPosition              float64
+	ebbPenDownPosition            float64
 	reverseShapeOrder             bool
 	configInOutput                bool
 	processingDpi                 float64
@@ -44,6 +71,36 @@ type VecartConfig struct {
 	debug                         bool
 	timeout                       int
 
+	gradientOrientation          string
+	gradientOrientationMode      string
+	gradientOrientationWeight    float64
+	gradientOrientationTolerance float64
+
+	scoringFunction        string
+	overlapPunishmentValue float64
+
+	toneModel     string
+	toneModelBlur float64
+
+	annealing                 bool
+	annealingIterations       int
+	annealingTimeout          int
+	annealingStartTemperature float64
+	annealingEndTemperature   float64
+
+	preview           bool
+	previewComparison bool
+
+	metrics     bool
+	metricsFile bool
+
+	penDownSpeed float64
+	penUpSpeed   float64
+	acceleration float64
+	penLiftTime  float64
+
+	regions []Region
+
 	shapes                   []Shape
 	shapeAngleDeviationRange float64
 	shapeAngleDeviationStep  float64
@@ -57,6 +114,12 @@ func NewConfig() VecartConfig {
 	config.artworkWidth = 255
 	config.artworkHeight = 370
 
+	config.paper = ""
+	config.orientation = OrientationPortrait
+	config.margins = [4]float64{0, 0, 0, 0}
+	config.fit = FitContain
+	config.canvas = NewCanvasShape()
+
 	config.quadrantWidth = 5
 	config.quadrantHeight = 5
 	config.darknessThreshold = 18
@@ -76,6 +139,19 @@ func NewConfig() VecartConfig {
 	config.combineShapesIterations = 5
 	config.strokeWidth = 0.75
 	config.strokeColor = "black"
+	config.fillClosedShapes = false
+	config.laserLayers = false
+	config.laserCutOutline = false
+	config.hatchFill = false
+	config.hatchSpacing = 0.5
+	config.hatchAngle = 45
+	config.offsetStrokes = false
+	config.penWidth = 0.3
+	config.offsetJoin = JoinRound
+	config.offsetCap = CapRound
+	config.dxfLayers = DXFLayersPen
+	config.ebbPenUpPosition = 60
+	config.ebbPenDownPosition = 30
 	config.reverseShapeOrder = false
 	config.configInOutput = true
 	config.processingDpi = 25
@@ -83,9 +159,39 @@ func NewConfig() VecartConfig {
 	config.debug = false
 	config.timeout = 30
 
-	config.shapes = append(Config.shapes, *NewShape([]Polyline{{[]Point{{0, 0}, {0, 2}}, nil}}))
-	config.shapes = append(Config.shapes, *NewShape([]Polyline{{[]Point{{0, 0}, {0, 4}}, nil}}))
-	config.shapes = append(Config.shapes, *NewShape([]Polyline{{[]Point{{0, 0}, {0, 8}}, nil}}))
+	config.gradientOrientation = GradientOrientationNone
+	config.gradientOrientationMode = GradientOrientationModeBias
+	config.gradientOrientationWeight = 1
+	config.gradientOrientationTolerance = 20
+
+	config.scoringFunction = ScoringDarkness
+	config.overlapPunishmentValue = 1
+
+	config.toneModel = ToneModelIntersections
+	config.toneModelBlur = 1
+
+	config.annealing = false
+	config.annealingIterations = 10000
+	config.annealingTimeout = 60
+	config.annealingStartTemperature = 1
+	config.annealingEndTemperature = 0.001
+
+	config.preview = false
+	config.previewComparison = false
+
+	config.metrics = false
+	config.metricsFile = false
+
+	config.penDownSpeed = 25
+	config.penUpSpeed = 75
+	config.acceleration = 500
+	config.penLiftTime = 0.15
+
+	config.regions = nil
+
+	config.shapes = append(config.shapes, *NewShape([]Polyline{{[]Point{{0, 0}, {0, 2}}, nil}}))
+	config.shapes = append(config.shapes, *NewShape([]Polyline{{[]Point{{0, 0}, {0, 4}}, nil}}))
+	config.shapes = append(config.shapes, *NewShape([]Polyline{{[]Point{{0, 0}, {0, 8}}, nil}}))
 	config.shapeAngleDeviationRange = 180
 	config.shapeAngleDeviationStep = 10
 
@@ -96,6 +202,9 @@ func (config *VecartConfig) equalTo(otherConfig *VecartConfig) bool {
 	if config.inputPath != otherConfig.inputPath {
 		return false
 	}
+	if !slices.Equal(config.inputPaths, otherConfig.inputPaths) {
+		return false
+	}
 	if config.outputPath != otherConfig.outputPath {
 		return false
 	}
@@ -105,6 +214,21 @@ func (config *VecartConfig) equalTo(otherConfig *VecartConfig) bool {
 	if config.artworkHeight != otherConfig.artworkHeight {
 		return false
 	}
+	if config.paper != otherConfig.paper {
+		return false
+	}
+	if config.orientation != otherConfig.orientation {
+		return false
+	}
+	if config.margins != otherConfig.margins {
+		return false
+	}
+	if config.fit != otherConfig.fit {
+		return false
+	}
+	if !config.canvas.equalTo(&otherConfig.canvas) {
+		return false
+	}
 	if config.quadrantWidth != otherConfig.quadrantWidth {
 		return false
 	}
@@ -159,6 +283,45 @@ func (config *VecartConfig) equalTo(otherConfig *VecartConfig) bool {
 	if config.strokeColor != otherConfig.strokeColor {
 		return false
 	}
+	if config.fillClosedShapes != otherConfig.fillClosedShapes {
+		return false
+	}
+	if config.laserLayers != otherConfig.laserLayers {
+		return false
+	}
+	if config.laserCutOutline != otherConfig.laserCutOutline {
+		return false
+	}
+	if config.hatchFill != otherConfig.hatchFill {
+		return false
+	}
+	if config.hatchSpacing != otherConfig.hatchSpacing {
+		return false
+	}
+	if config.hatchAngle != otherConfig.hatchAngle {
+		return false
+	}
+	if config.offsetStrokes != otherConfig.offsetStrokes {
+		return false
+	}
+	if config.penWidth != otherConfig.penWidth {
+		return false
+	}
+	if config.offsetJoin != otherConfig.offsetJoin {
+		return false
+	}
+	if config.offsetCap != otherConfig.offsetCap {
+		return false
+	}
+	if config.dxfLayers != otherConfig.dxfLayers {
+		return false
+	}
+	if config.ebbPenUpPosition != otherConfig.ebbPenUpPosition {
+		return false
+	}
+	if config.ebbPenDownPosition != otherConfig.ebbPenDownPosition {
+		return false
+	}
 	if config.reverseShapeOrder != otherConfig.reverseShapeOrder {
 		return false
 	}
@@ -178,6 +341,80 @@ func (config *VecartConfig) equalTo(otherConfig *VecartConfig) bool {
 		return false
 	}
 
+	if config.gradientOrientation != otherConfig.gradientOrientation {
+		return false
+	}
+	if config.gradientOrientationMode != otherConfig.gradientOrientationMode {
+		return false
+	}
+	if config.gradientOrientationWeight != otherConfig.gradientOrientationWeight {
+		return false
+	}
+	if config.gradientOrientationTolerance != otherConfig.gradientOrientationTolerance {
+		return false
+	}
+
+	if config.scoringFunction != otherConfig.scoringFunction {
+		return false
+	}
+	if config.overlapPunishmentValue != otherConfig.overlapPunishmentValue {
+		return false
+	}
+
+	if config.toneModel != otherConfig.toneModel {
+		return false
+	}
+	if config.toneModelBlur != otherConfig.toneModelBlur {
+		return false
+	}
+
+	if config.annealing != otherConfig.annealing {
+		return false
+	}
+	if config.annealingIterations != otherConfig.annealingIterations {
+		return false
+	}
+	if config.annealingTimeout != otherConfig.annealingTimeout {
+		return false
+	}
+	if config.annealingStartTemperature != otherConfig.annealingStartTemperature {
+		return false
+	}
+	if config.annealingEndTemperature != otherConfig.annealingEndTemperature {
+		return false
+	}
+
+	if config.preview != otherConfig.preview {
+		return false
+	}
+	if config.previewComparison != otherConfig.previewComparison {
+		return false
+	}
+
+	if config.metrics != otherConfig.metrics {
+		return false
+	}
+	if config.metricsFile != otherConfig.metricsFile {
+		return false
+	}
+
+	if config.penDownSpeed != otherConfig.penDownSpeed {
+		return false
+	}
+	if config.penUpSpeed != otherConfig.penUpSpeed {
+		return false
+	}
+	if config.acceleration != otherConfig.acceleration {
+		return false
+	}
+	if config.penLiftTime != otherConfig.penLiftTime {
+		return false
+	}
+
+	if !regionsEqual(config.regions, otherConfig.regions) {
+		return false
+	}
+
 	if !shapesEqual(&config.shapes, &otherConfig.shapes, 10, false) {
 		return false
 	}
@@ -245,17 +482,41 @@ func (config *VecartConfig) fromJSON(jsonString string) bool {
 	}
 
 	errorsOcurred = false
+	configErrors = nil
+	parsingErrors := len(errors)
+	schemaErrors := validateConfigSchema(jsonData)
 
 	getBool(jsonData, "debug", &config.debug)
 	debug = config.debug
 
-	getString(jsonData, "inputPath", &config.inputPath)
+	if _, ok := jsonData["inputPath"].([]any); ok {
+		inputPaths, _ := getArray(jsonData, "inputPath")
+		for _, value := range inputPaths {
+			inputPath, ok := getStringFromAny(value)
+			if ok {
+				config.inputPaths = append(config.inputPaths, inputPath)
+			}
+		}
+	} else {
+		getString(jsonData, "inputPath", &config.inputPath)
+	}
 	getString(jsonData, "outputPath", &config.outputPath)
-	getInt(jsonData, "artworkWidth", &config.artworkWidth)
-	getInt(jsonData, "artworkHeight", &config.artworkHeight)
 
-	getInt(jsonData, "quadrantWidth", &config.quadrantWidth)
-	getInt(jsonData, "quadrantHeight", &config.quadrantHeight)
+	// The dpi values are needed for converting lengths that are given in pixels
+	getFloat(jsonData, "processingDpi", &config.processingDpi)
+	getFloat(jsonData, "outputDpi", &config.outputDpi)
+
+	getLength(jsonData, "artworkWidth", &config.artworkWidth, "mm", config.outputDpi)
+	getLength(jsonData, "artworkHeight", &config.artworkHeight, "mm", config.outputDpi)
+
+	getString(jsonData, "paper", &config.paper)
+	getString(jsonData, "orientation", &config.orientation)
+	getMargins(jsonData, "margin", &config.margins, config.outputDpi)
+	getString(jsonData, "fit", &config.fit)
+	getCanvas(jsonData, "canvas", &config.canvas, config.outputDpi)
+
+	getPixelLength(jsonData, "quadrantWidth", &config.quadrantWidth, config.processingDpi)
+	getPixelLength(jsonData, "quadrantHeight", &config.quadrantHeight, config.processingDpi)
 	getFloat(jsonData, "darknessThreshold", &config.darknessThreshold)
 	getFloat(jsonData, "shapeDarknessFactor", &config.shapeDarknessFactor)
 	getInt(jsonData, "whitePunishmentBoundry", &config.whitePunishmentBoundry)
@@ -270,26 +531,67 @@ func (config *VecartConfig) fromJSON(jsonString string) bool {
 	getFloat(jsonData, "shapeRefinementPercentage", &config.shapeRefinementPercentage)
 	getBool(jsonData, "smoothEdges", &config.smoothEdges)
 	getBool(jsonData, "combineShapes", &config.combineShapes)
-	getFloat(jsonData, "combineShapesTolerance", &config.combineShapesTolerance)
+	getLength(jsonData, "combineShapesTolerance", &config.combineShapesTolerance, "mm", config.outputDpi)
 	getInt(jsonData, "combineShapesIterations", &config.combineShapesIterations)
-	getFloat(jsonData, "strokeWidth", &config.strokeWidth)
+	getLength(jsonData, "strokeWidth", &config.strokeWidth, "px", config.outputDpi)
 	getString(jsonData, "strokeColor", &config.strokeColor)
+	getBool(jsonData, "fillClosedShapes", &config.fillClosedShapes)
+	getBool(jsonData, "laserLayers", &config.laserLayers)
+	getBool(jsonData, "laserCutOutline", &config.laserCutOutline)
+	getBool(jsonData, "hatchFill", &config.hatchFill)
+	getLength(jsonData, "hatchSpacing", &config.hatchSpacing, "mm", config.outputDpi)
+	getFloat(jsonData, "hatchAngle", &config.hatchAngle)
+	getBool(jsonData, "offsetStrokes", &config.offsetStrokes)
+	getLength(jsonData, "penWidth", &config.penWidth, "mm", config.outputDpi)
+	getString(jsonData, "offsetJoin", &config.offsetJoin)
+	getString(jsonData, "offsetCap", &config.offsetCap)
+	getString(jsonData, "dxfLayers", &config.dxfLayers)
+	getFloat(jsonData, "ebbPenUpPosition", &config.ebbPenUpPosition)
+	getFloat(jsonData, "ebbPenDownPosition", &config.ebbPenDownPosition)
 	getBool(jsonData, "reverseShapeOrder", &config.reverseShapeOrder)
 	getBool(jsonData, "configInOutput", &config.configInOutput)
-	getFloat(jsonData, "processingDpi", &config.processingDpi)
-	getFloat(jsonData, "outputDpi", &config.outputDpi)
 	getInt(jsonData, "timeout", &config.timeout)
 
 	getFloat(jsonData, "shapeAngleDeviationRange", &config.shapeAngleDeviationRange)
 	getFloat(jsonData, "shapeAngleDeviationStep", &config.shapeAngleDeviationStep)
 
-	getShapes(jsonData, "shapes", &config.shapes)
+	getString(jsonData, "gradientOrientation", &config.gradientOrientation)
+	getString(jsonData, "gradientOrientationMode", &config.gradientOrientationMode)
+	getFloat(jsonData, "gradientOrientationWeight", &config.gradientOrientationWeight)
+	getFloat(jsonData, "gradientOrientationTolerance", &config.gradientOrientationTolerance)
+
+	getString(jsonData, "scoringFunction", &config.scoringFunction)
+	getFloat(jsonData, "overlapPunishmentValue", &config.overlapPunishmentValue)
+
+	getString(jsonData, "toneModel", &config.toneModel)
+	getFloat(jsonData, "toneModelBlur", &config.toneModelBlur)
+
+	getBool(jsonData, "annealing", &config.annealing)
+	getInt(jsonData, "annealingIterations", &config.annealingIterations)
+	getInt(jsonData, "annealingTimeout", &config.annealingTimeout)
+	getFloat(jsonData, "annealingStartTemperature", &config.annealingStartTemperature)
+	getFloat(jsonData, "annealingEndTemperature", &config.annealingEndTemperature)
+
+	getBool(jsonData, "preview", &config.preview)
+	getBool(jsonData, "previewComparison", &config.previewComparison)
+
+	getBool(jsonData, "metrics", &config.metrics)
+	getBool(jsonData, "metricsFile", &config.metricsFile)
+
+	getFloat(jsonData, "penDownSpeed", &config.penDownSpeed)
+	getFloat(jsonData, "penUpSpeed", &config.penUpSpeed)
+	getFloat(jsonData, "acceleration", &config.acceleration)
+	getFloat(jsonData, "penLiftTime", &config.penLiftTime)
+
+	getRegions(jsonData, "regions", config)
+
+	getShapes(jsonData, "shapes", &config.shapes, config.outputDpi)
 
 	configMap := config.toMap()
 
 	for key := range jsonData {
 		_, ok := configMap[key]
-		if !ok && key != "shapes" {
+		if !ok && key != "shapes" && key != "$schema" {
 			errorsOcurred = true
 			bestDistance := math.MaxInt
 			bestCorrectKey := ""
@@ -310,6 +612,15 @@ func (config *VecartConfig) fromJSON(jsonString string) bool {
 		}
 	}
 
+	// The schema errors describe the same problems as the errors of parsing but include the path of the invalid value
+	if len(schemaErrors) > 0 {
+		errorsOcurred = true
+		errors = errors[:parsingErrors]
+		for _, configError := range schemaErrors {
+			addConfigError(configError)
+		}
+	}
+
 	if !config.validate() {
 		errorsOcurred = true
 	}
@@ -326,122 +637,97 @@ func (config *VecartConfig) fromJSON(jsonString string) bool {
 func (config *VecartConfig) validate() bool {
 	valid := true
 
-	if config.inputPath != "" {
-		if !pathValid(config.inputPath) {
+	for _, inputPath := range config.getInputPathPatterns() {
+		if strings.ContainsAny(inputPath, "*?[") {
+			if len(expandInputPath(inputPath)) == 0 {
+				valid = false
+				errors = append(errors, "Input path '"+inputPath+"' does not match any images!")
+			}
+		} else if !pathValid(inputPath) {
 			valid = false
-			errors = append(errors, "Input path '"+config.inputPath+"' is not a valid!")
+			errors = append(errors, "Input path '"+inputPath+"' is not a valid!")
 		}
 	}
 
-	if !pathValid(filepath.Dir(config.outputPath)) {
+	if !pathValid(filepath.Dir(getBatchOutputPath(config.outputPath, "", 0, config.randomSeed))) {
 		valid = false
 		errors = append(errors, "Output path '"+config.outputPath+"' is not a valid!")
 
 	}
 
-	if config.artworkHeight == 0 && config.artworkWidth == 0 {
-		valid = false
-		errors = append(errors, "Both artworkWidth and artworkHeight parameters are 0. This is not allowed!")
-
-	}
-
-	if config.quadrantWidth == 0 {
-		valid = false
-		errors = append(errors, "A quadrantWidth of 0 is invalid!")
-
-	}
-
-	if config.quadrantHeight == 0 {
+	if !config.validatePaper() {
 		valid = false
-		errors = append(errors, "A quadrantHeight below 0 is invalid!")
-
-	}
-
-	if config.darknessThreshold < 0 {
-		valid = false
-		errors = append(errors, "A darknessThreshold below 0 is invalid!")
-
-	}
-
-	if config.shapeDarknessFactor <= 0 {
-		valid = false
-		errors = append(errors, "A shapeDarknessFactor below or equal to 0 is invalid!")
-
-	}
-
-	if config.whitePunishmentBoundry < 0 {
-		valid = false
-		errors = append(errors, "A whitePunishmentBoundry below 0 is invalid!")
-
-	}
-
-	if config.parallelRoutines < 1 {
-		valid = false
-		errors = append(errors, "parallelRoutines must be greater than 0!")
-
-	}
-
-	if config.shapeRefinementIterations <= 0 {
-		valid = false
-		errors = append(errors, "shapeRefinementIterations must be greater than 0!")
-
 	}
 
-	if config.shapeRefinementPercentage <= 0 {
+	if !config.canvas.validate() {
 		valid = false
-		errors = append(errors, "shapeRefinementPercentage must be greater than 0!")
-
 	}
 
-	if config.combineShapesTolerance <= 0 {
+	if config.paper == "" && config.artworkHeight == 0 && config.artworkWidth == 0 {
 		valid = false
-		errors = append(errors, "combineShapesTolerance must be greater than 0!")
+		errors = append(errors, "Both artworkWidth and artworkHeight parameters are 0. This is not allowed!")
 
 	}
 
-	if config.combineShapesIterations <= 0 {
+	if !config.validateConstraints() {
 		valid = false
-		errors = append(errors, "combineShapesIterations must be greater than 0!")
-
 	}
 
-	if config.strokeWidth < 0 {
+	if config.annealingEndTemperature > config.annealingStartTemperature {
 		valid = false
-		errors = append(errors, "strokeWidth must be greater or equal to 0!")
+		errors = append(errors, "annealingEndTemperature must be less or equal to annealingStartTemperature!")
 
 	}
 
-	if config.processingDpi <= 0 {
+	if config.toneModel == ToneModelCoverage && config.strokeWidth <= 0 {
 		valid = false
-		errors = append(errors, "processingDpi must be greater than 0!")
+		errors = append(errors, "The 'coverage' toneModel requires a strokeWidth greater than 0!")
 
 	}
 
-	if config.outputDpi <= 0 {
-		valid = false
-		errors = append(errors, "outputDpi must be greater than 0!")
-
+	for index := range config.regions {
+		if !config.regions[index].validate(index) {
+			valid = false
+		}
+		if config.toneModel == ToneModelCoverage && config.regions[index].strokeWidth <= 0 {
+			valid = false
+			errors = append(errors, "Region "+strconv.Itoa(index)+": The 'coverage' toneModel requires a strokeWidth greater than 0!")
+		}
 	}
 
-	if config.timeout <= 0 {
-		valid = false
-		errors = append(errors, "timeout must be greater than 0!")
+	return valid
+}
 
-	}
+// Checks the parsed parameters against configConstraints, the same table the JSON Schema is built from. Lengths are
+// checked after they were converted, so that e.g. a quadrantWidth of "0mm" is rejected as well.
+func (config *VecartConfig) validateConstraints() bool {
+	valid := true
+	configMap := config.toMap()
 
-	if config.shapeAngleDeviationRange < 0 {
-		valid = false
-		errors = append(errors, "shapeAngleDeviationRange must be greater or equal to 0!")
+	for _, key := range slices.Sorted(maps.Keys(configConstraints)) {
+		value := configMap[key]
+		if integer, ok := value.(int); ok {
+			value = float64(integer)
+		}
 
+		for _, configError := range validateAgainstSchema(value, configConstraints[key], key, nil) {
+			valid = false
+			addConfigError(configError)
+		}
 	}
 
-	if config.shapeAngleDeviationStep <= 0 {
-		valid = false
-		errors = append(errors, "shapeAngleDeviationStep must be greater than 0!")
+	return valid
+}
 
+// Adds an error that concerns a specific parameter. Errors that were already reported (e.g. by the schema validation
+// of the JSON data) are skipped.
+func addConfigError(configError ConfigError) {
+	if slices.Contains(configErrors, configError) {
+		return
 	}
 
-	return valid
+	configErrors = append(configErrors, configError)
+	errors = append(errors, configError.String())
 }
 
 func pathValid(path string) bool {
@@ -475,11 +761,19 @@ func (config *VecartConfig) toMap() map[string]any {
 	jsonData := make(map[string]any)
 
 	jsonData["inputPath"] = config.inputPath
-	jsonData["inputPath"] = config.inputPath
+	if len(config.inputPaths) > 0 {
+		jsonData["inputPath"] = config.inputPaths
+	}
 	jsonData["outputPath"] = config.outputPath
 	jsonData["artworkWidth"] = config.artworkWidth
 	jsonData["artworkHeight"] = config.artworkHeight
 
+	jsonData["paper"] = config.paper
+	jsonData["orientation"] = config.orientation
+	jsonData["margin"] = config.getMarginValue()
+	jsonData["fit"] = config.fit
+	jsonData["canvas"] = config.canvas.toJSON()
+
 	jsonData["quadrantWidth"] = config.quadrantWidth
 	jsonData["quadrantHeight"] = config.quadrantHeight
 	jsonData["darknessThreshold"] = config.darknessThreshold
@@ -499,6 +793,19 @@ func (config *VecartConfig) toMap() map[string]any {
 	jsonData["combineShapesIterations"] = config.combineShapesIterations
 	jsonData["strokeWidth"] = config.strokeWidth
 	jsonData["strokeColor"] = config.strokeColor
+	jsonData["fillClosedShapes"] = config.fillClosedShapes
+	jsonData["laserLayers"] = config.laserLayers
+	jsonData["laserCutOutline"] = config.laserCutOutline
+	jsonData["hatchFill"] = config.hatchFill
+	jsonData["hatchSpacing"] = config.hatchSpacing
+	jsonData["hatchAngle"] = config.hatchAngle
+	jsonData["offsetStrokes"] = config.offsetStrokes
+	jsonData["penWidth"] = config.penWidth
+	jsonData["offsetJoin"] = config.offsetJoin
+	jsonData["offsetCap"] = config.offsetCap
+	jsonData["dxfLayers"] = config.dxfLayers
+	jsonData["ebbPenUpPosition"] = config.ebbPenUpPosition
+	jsonData["ebbPenDownPosition"] = config.ebbPenDownPosition
 	jsonData["reverseShapeOrder"] = config.reverseShapeOrder
 	jsonData["configInOutput"] = config.configInOutput
 	jsonData["processingDpi"] = config.processingDpi
@@ -509,6 +816,40 @@ func (config *VecartConfig) toMap() map[string]any {
 	jsonData["shapeAngleDeviationRange"] = config.shapeAngleDeviationRange
 	jsonData["shapeAngleDeviationStep"] = config.shapeAngleDeviationStep
 
+	jsonData["gradientOrientation"] = config.gradientOrientation
+	jsonData["gradientOrientationMode"] = config.gradientOrientationMode
+	jsonData["gradientOrientationWeight"] = config.gradientOrientationWeight
+	jsonData["gradientOrientationTolerance"] = config.gradientOrientationTolerance
+
+	jsonData["scoringFunction"] = config.scoringFunction
+	jsonData["overlapPunishmentValue"] = config.overlapPunishmentValue
+
+	jsonData["toneModel"] = config.toneModel
+	jsonData["toneModelBlur"] = config.toneModelBlur
+
+	jsonData["annealing"] = config.annealing
+	jsonData["annealingIterations"] = config.annealingIterations
+	jsonData["annealingTimeout"] = config.annealingTimeout
+	jsonData["annealingStartTemperature"] = config.annealingStartTemperature
+	jsonData["annealingEndTemperature"] = config.annealingEndTemperature
+
+	jsonData["preview"] = config.preview
+	jsonData["previewComparison"] = config.previewComparison
+
+	jsonData["metrics"] = config.metrics
+	jsonData["metricsFile"] = config.metricsFile
+
+	jsonData["penDownSpeed"] = config.penDownSpeed
+	jsonData["penUpSpeed"] = config.penUpSpeed
+	jsonData["acceleration"] = config.acceleration
+	jsonData["penLiftTime"] = config.penLiftTime
+
+	regionMaps := []any{}
+	for index := range config.regions {
+		regionMaps = append(regionMaps, config.regions[index].toJSON())
+	}
+	jsonData["regions"] = regionMaps
+
 	var shapes []any
 	for _, shape := range config.shapes {
 		shapes = append(shapes, shape.toJSON())
@@ -518,7 +859,7 @@ func (config *VecartConfig) toMap() map[string]any {
 	return jsonData
 }
 
-func getShapes(jsonData map[string]any, key string, configOption *[]Shape) {
+func getShapes(jsonData map[string]any, key string, configOption *[]Shape, dpi float64) {
 	shapeArray, success := getArray(jsonData, key)
 	if !success {
 		return
@@ -526,7 +867,7 @@ func getShapes(jsonData map[string]any, key string, configOption *[]Shape) {
 
 	shapes := &[]Shape{}
 	for _, shape := range shapeArray {
-		getShape(shape, shapes)
+		getShape(shape, shapes, dpi)
 	}
 
 	if len(*shapes) != 0 {
@@ -534,18 +875,18 @@ func getShapes(jsonData map[string]any, key string, configOption *[]Shape) {
 	}
 }
 
-func getShape(shape any, targetArray *[]Shape) {
+func getShape(shape any, targetArray *[]Shape, dpi float64) {
 	switch shape.(type) {
 	default:
 		errorsOcurred = true
 		errors = append(errors, "Unexpected type for shape definition | expected object or array of polylines")
 		return
 	case map[string]any:
-		parseShape(shape.(map[string]any), targetArray)
+		parseShape(shape.(map[string]any), targetArray, dpi)
 	}
 }
 
-func parseShape(shapeParameters map[string]any, targetArray *[]Shape) {
+func parseShape(shapeParameters map[string]any, targetArray *[]Shape, dpi float64) {
 	shapeType, ok := shapeParameters["type"]
 	if !ok {
 		errorsOcurred = true
@@ -565,27 +906,27 @@ func parseShape(shapeParameters map[string]any, targetArray *[]Shape) {
 			errors = append(errors, "Invalid shape type")
 			return
 		case "line", "Line", "LINE":
-			getLine(shapeParameters, targetArray)
+			getLine(shapeParameters, targetArray, dpi)
 		case "rectangle", "Rectangle", "RECTANGLE":
-			getRectangle(shapeParameters, targetArray)
+			getRectangle(shapeParameters, targetArray, dpi)
 		case "triangle", "Triangle", "TRIANGLE":
-			getTriangle(shapeParameters, targetArray)
+			getTriangle(shapeParameters, targetArray, dpi)
 		case "circle", "Circle", "CIRCLE":
-			getCircle(shapeParameters, targetArray)
+			getCircle(shapeParameters, targetArray, dpi)
 		case "polyline", "Polyline", "POLYLINE":
-			getPolyline(shapeParameters, targetArray)
+			getPolyline(shapeParameters, targetArray, dpi)
 		case "polygon", "Polygon", "POLYGON":
-			getPolygon(shapeParameters, targetArray)
+			getPolygon(shapeParameters, targetArray, dpi)
 		case "text", "Text", "TEXT":
-			getText(shapeParameters, targetArray)
+			getText(shapeParameters, targetArray, dpi)
 		case "group", "Group", "GROUP":
-			getGroup(shapeParameters, targetArray)
+			getGroup(shapeParameters, targetArray, dpi)
 		}
 
 	}
 }
 
-func getLine(shapeParameters map[string]any, targetArray *[]Shape) {
+func getLine(shapeParameters map[string]any, targetArray *[]Shape, dpi float64) {
 	p1, ok := shapeParameters["p1"]
 	if !ok {
 		errorsOcurred = true
@@ -600,8 +941,8 @@ func getLine(shapeParameters map[string]any, targetArray *[]Shape) {
 		return
 	}
 	var points []Point
-	getPoint(p1, &points)
-	getPoint(p2, &points)
+	getPoint(p1, &points, dpi)
+	getPoint(p2, &points, dpi)
 	if len(points) != 2 {
 		errorsOcurred = true
 		errors = append(errors, "Invalid point definition for line definition!")
@@ -610,7 +951,7 @@ func getLine(shapeParameters map[string]any, targetArray *[]Shape) {
 	*targetArray = append(*targetArray, *NewLine(&points[0], &points[1]))
 }
 
-func getRectangle(shapeParameters map[string]any, targetArray *[]Shape) {
+func getRectangle(shapeParameters map[string]any, targetArray *[]Shape, dpi float64) {
 	topLeftArray, ok := getArray(shapeParameters, "topLeft")
 	if !ok {
 		errorsOcurred = true
@@ -624,13 +965,13 @@ func getRectangle(shapeParameters map[string]any, targetArray *[]Shape) {
 		return
 	}
 
-	topLeftX, ok := getFloatFromAny(topLeftArray[0])
+	topLeftX, ok := getLengthFromAny(topLeftArray[0], dpi)
 	if !ok {
 		errorsOcurred = true
 		errors = append(errors, "Invalid topLeft definition for rectangle definition!")
 		return
 	}
-	topLeftY, ok := getFloatFromAny(topLeftArray[1])
+	topLeftY, ok := getLengthFromAny(topLeftArray[1], dpi)
 	if !ok {
 		errorsOcurred = true
 		errors = append(errors, "Invalid topLeft definition for rectangle definition!")
@@ -651,13 +992,13 @@ func getRectangle(shapeParameters map[string]any, targetArray *[]Shape) {
 		return
 	}
 
-	widthValue, ok := getFloatFromAny(width)
+	widthValue, ok := getLengthFromAny(width, dpi)
 	if !ok {
 		errorsOcurred = true
 		errors = append(errors, "Invalid width value for rectangle definition!")
 		return
 	}
-	heigthValue, ok := getFloatFromAny(height)
+	heigthValue, ok := getLengthFromAny(height, dpi)
 	if !ok {
 		errorsOcurred = true
 		errors = append(errors, "Invalid width value for rectangle definition!")
@@ -673,7 +1014,7 @@ func getRectangle(shapeParameters map[string]any, targetArray *[]Shape) {
 
 }
 
-func getTriangle(shapeParameters map[string]any, targetArray *[]Shape) {
+func getTriangle(shapeParameters map[string]any, targetArray *[]Shape, dpi float64) {
 	p1, ok := shapeParameters["p1"]
 	if !ok {
 		errorsOcurred = true
@@ -696,9 +1037,9 @@ func getTriangle(shapeParameters map[string]any, targetArray *[]Shape) {
 	}
 
 	var points []Point
-	getPoint(p1, &points)
-	getPoint(p2, &points)
-	getPoint(p3, &points)
+	getPoint(p1, &points, dpi)
+	getPoint(p2, &points, dpi)
+	getPoint(p3, &points, dpi)
 	if len(points) != 3 {
 		errorsOcurred = true
 		errors = append(errors, "Invalid point definition for triangle definition!")
@@ -707,7 +1048,7 @@ func getTriangle(shapeParameters map[string]any, targetArray *[]Shape) {
 
 	*targetArray = append(*targetArray, *NewPolygon(&points).toShape())
 }
-func getCircle(shapeParameters map[string]any, targetArray *[]Shape) {
+func getCircle(shapeParameters map[string]any, targetArray *[]Shape, dpi float64) {
 	center, ok := getArray(shapeParameters, "center")
 	if !ok {
 		errorsOcurred = true
@@ -715,7 +1056,7 @@ func getCircle(shapeParameters map[string]any, targetArray *[]Shape) {
 		return
 	}
 
-	radius, ok := getFloatFromAny(shapeParameters["radius"])
+	radius, ok := getLengthFromAny(shapeParameters["radius"], dpi)
 	if !ok {
 		errorsOcurred = true
 		errors = append(errors, "Missing 'radius' attribute for circle definition!")
@@ -728,19 +1069,19 @@ func getCircle(shapeParameters map[string]any, targetArray *[]Shape) {
 		return
 	}
 
-	centerX, ok := getFloatFromAny(center[0])
+	centerX, ok := getLengthFromAny(center[0], dpi)
 	if !ok {
 		errorsOcurred = true
 		errors = append(errors, "Invalid center value for circle definition!")
 		return
 	}
-	centerY, ok := getFloatFromAny(center[1])
+	centerY, ok := getLengthFromAny(center[1], dpi)
 	if !ok {
 		errorsOcurred = true
 		errors = append(errors, "Invalid center value for circle definition!")
 		return
 	}
-	radiusFloat, ok := getFloatFromAny(radius)
+	radiusFloat, ok := getLengthFromAny(radius, dpi)
 	if !ok {
 		errorsOcurred = true
 		errors = append(errors, "Invalid radius value for circle definition!")
@@ -752,7 +1093,7 @@ func getCircle(shapeParameters map[string]any, targetArray *[]Shape) {
 	}
 }
 
-func getPolyline(shapeParameters map[string]any, targetArray *[]Shape) {
+func getPolyline(shapeParameters map[string]any, targetArray *[]Shape, dpi float64) {
 	pointsAny, ok := getArray(shapeParameters, "points")
 	if !ok {
 		errorsOcurred = true
@@ -762,7 +1103,7 @@ func getPolyline(shapeParameters map[string]any, targetArray *[]Shape) {
 
 	var points []Point
 	for _, point := range pointsAny {
-		getPoint(point, &points)
+		getPoint(point, &points, dpi)
 	}
 
 	if len(points) < 3 {
@@ -774,7 +1115,7 @@ func getPolyline(shapeParameters map[string]any, targetArray *[]Shape) {
 	*targetArray = append(*targetArray, *NewSingleLineShape(*NewPolyline(&points, nil)))
 }
 
-func getPolygon(shapeParameters map[string]any, targetArray *[]Shape) {
+func getPolygon(shapeParameters map[string]any, targetArray *[]Shape, dpi float64) {
 	pointsAny, ok := getArray(shapeParameters, "points")
 	if !ok {
 		errorsOcurred = true
@@ -784,7 +1125,7 @@ func getPolygon(shapeParameters map[string]any, targetArray *[]Shape) {
 
 	var points []Point
 	for _, point := range pointsAny {
-		getPoint(point, &points)
+		getPoint(point, &points, dpi)
 	}
 
 	if len(points) < 3 {
@@ -796,14 +1137,14 @@ func getPolygon(shapeParameters map[string]any, targetArray *[]Shape) {
 	*targetArray = append(*targetArray, *NewPolygon(&points).toShape())
 }
 
-func getText(shapeParameters map[string]any, targetArray *[]Shape) {
+func getText(shapeParameters map[string]any, targetArray *[]Shape, dpi float64) {
 	lineHeightAny, ok := shapeParameters["lineHeight"]
 	if !ok {
 		errorsOcurred = true
 		errors = append(errors, "Missing 'lineHeight' attribute for text definition!")
 		return
 	}
-	lineHeight, ok := getFloatFromAny(lineHeightAny)
+	lineHeight, ok := getLengthFromAny(lineHeightAny, dpi)
 	if !ok {
 		errorsOcurred = true
 		errors = append(errors, "Invalid value for 'lineHeight' attribute for text definition")
@@ -823,13 +1164,13 @@ func getText(shapeParameters map[string]any, targetArray *[]Shape) {
 		return
 	}
 
-	centerX, ok := getFloatFromAny(center[0])
+	centerX, ok := getLengthFromAny(center[0], dpi)
 	if !ok {
 		errorsOcurred = true
 		errors = append(errors, "Invalid center value for text definition!")
 		return
 	}
-	centerY, ok := getFloatFromAny(center[1])
+	centerY, ok := getLengthFromAny(center[1], dpi)
 	if !ok {
 		errorsOcurred = true
 		errors = append(errors, "Invalid center value for text definition!")
@@ -860,7 +1201,15 @@ func getText(shapeParameters map[string]any, targetArray *[]Shape) {
 			return
 		}
 		font, ok = Fonts[fontString]
-		if !ok {
+		if !ok && strings.EqualFold(filepath.Ext(fontString), ".svg") {
+			var err error
+			font, err = loadFontFile(fontString)
+			if err != nil {
+				errorsOcurred = true
+				errors = append(errors, fmt.Sprintf("Could not load font file '%s' for text definition: %s", fontString, err))
+				return
+			}
+		} else if !ok {
 			errorsOcurred = true
 			errors = append(errors, "Unkown font '"+fontString+"' attribute for text definition")
 			return
@@ -870,7 +1219,7 @@ func getText(shapeParameters map[string]any, targetArray *[]Shape) {
 	*targetArray = append(*targetArray, font.getText(text, lineHeight, *NewPoint(centerX, centerY)))
 }
 
-func getGroup(shapeParameters map[string]any, targetArray *[]Shape) {
+func getGroup(shapeParameters map[string]any, targetArray *[]Shape, dpi float64) {
 	shapesAny, ok := getArray(shapeParameters, "shapes")
 	if !ok {
 		errorsOcurred = true
@@ -880,7 +1229,7 @@ func getGroup(shapeParameters map[string]any, targetArray *[]Shape) {
 
 	shapes := &[]Shape{}
 	for _, shape := range shapesAny {
-		getShape(shape, shapes)
+		getShape(shape, shapes, dpi)
 	}
 
 	if len(*shapes) < 1 {
@@ -913,20 +1262,19 @@ func getStringFromAny(value any) (string, bool) {
 	}
 }
 
-func getFloatFromAny(value any) (float64, bool) {
-	switch value.(type) {
-	default:
+// Converts a length of a shape definition to millimetres. Lengths in pixels are converted with the dpi.
+func getLengthFromAny(value any, dpi float64) (float64, bool) {
+	length, err := convertLength(value, "mm", dpi)
+	if err != nil {
 		errorsOcurred = true
-		errors = append(errors, "Unexpected type for expected float value")
+		errors = append(errors, "Unexpected value for expected length: "+err.Error())
 		return 0, false
-	case float64:
-		return value.(float64), true
-	case int:
-		return float64(value.(int)), true
 	}
+
+	return length, true
 }
 
-func getPoint(point any, points *[]Point) {
+func getPoint(point any, points *[]Point, dpi float64) {
 	switch point.(type) {
 	default:
 		errorsOcurred = true
@@ -944,6 +1292,16 @@ func getPoint(point any, points *[]Point) {
 				errorsOcurred = true
 				errors = append(errors, "Unexpected type for Point x or y value | expected float")
 				return
+			case string:
+				length, ok := getLengthFromAny(pointValue, dpi)
+				if !ok {
+					return
+				}
+				if index == 0 {
+					currentPoint.X = length
+				} else {
+					currentPoint.Y = length
+				}
 			case float64:
 				if index == 0 {
 					currentPoint.X = pointValue.(float64)
@@ -1023,6 +1381,29 @@ func getInt(jsonData map[string]any, key string, configOption *int) {
 	}
 }
 
+// Reads a length that is either a number in the given unit or a string with a unit (see convertLength)
+func getLength(jsonData map[string]any, key string, configOption *float64, unit string, dpi float64) {
+	if _, ok := jsonData[key]; !ok {
+		return
+	}
+
+	length, err := convertLength(jsonData[key], unit, dpi)
+	if err != nil {
+		errorsOcurred = true
+		errors = append(errors, "Unexpected value for '"+key+"': "+err.Error())
+		return
+	}
+
+	*configOption = length
+}
+
+// Reads a length in whole pixels of the given dpi
+func getPixelLength(jsonData map[string]any, key string, configOption *int, dpi float64) {
+	length := float64(*configOption)
+	getLength(jsonData, key, &length, "px", dpi)
+	*configOption = int(math.Round(length))
+}
+
 func getFloat(jsonData map[string]any, key string, configOption *float64) {
 	if _, ok := jsonData[key]; !ok {
 		return
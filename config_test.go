@@ -1,6 +1,16 @@
 package main
 
-import "testing"
+import (
+	"encoding/json"
+	"image"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
 
 func TestEmptyConfigJson(t *testing.T) {
 	baseConfig := NewConfig()
@@ -13,6 +23,23 @@ func TestEmptyConfigJson(t *testing.T) {
 	}
 }
 
+func TestInputPathArrayConfigJson(t *testing.T) {
+	baseConfig := NewConfig()
+	baseConfig.inputPaths = []string{"static/ellie.png", "examples/*.jpg"}
+	baseConfig.outputPath = "{name}_{seed}.svg"
+
+	config := NewConfig()
+	config.fromJSON(`{"inputPath": ["static/ellie.png", "examples/*.jpg"], "outputPath": "{name}_{seed}.svg"}`)
+
+	if !config.equalTo(&baseConfig) {
+		t.Error("Parsing an array of input paths failed!")
+	}
+
+	if getBatchOutputPath(config.outputPath, "static/ellie.png", 0, config.randomSeed) != "ellie_1701.svg" {
+		t.Error("Replacing the placeholders of the output path failed!")
+	}
+}
+
 func TestAllConfigJson(t *testing.T) {
 	baseConfig := NewConfig()
 	baseConfig.inputPath = "/some/path/picture.png"
@@ -84,6 +111,30 @@ func TestAllConfigJson(t *testing.T) {
 
 	baseConfig.shapeAngleDeviationRange = 16
 	baseConfig.shapeAngleDeviationStep = 17.5
+	baseConfig.scoringFunction = ScoringBlur
+	baseConfig.overlapPunishmentValue = 19.5
+	baseConfig.toneModel = ToneModelCoverage
+	baseConfig.toneModelBlur = 20.5
+	baseConfig.annealing = true
+	baseConfig.annealingIterations = 21
+	baseConfig.annealingTimeout = 22
+	baseConfig.annealingStartTemperature = 23.5
+	baseConfig.annealingEndTemperature = 0.5
+	baseConfig.preview = true
+	baseConfig.previewComparison = true
+	baseConfig.metrics = true
+	baseConfig.metricsFile = true
+	baseConfig.penDownSpeed = 24.5
+	baseConfig.penUpSpeed = 25.5
+	baseConfig.acceleration = 26.5
+	baseConfig.penLiftTime = 0.25
+
+	region := NewRegion(&baseConfig)
+	region.polygon = []Point{{0, 0}, {1, 0}, {1, 2}}
+	region.darknessThreshold = 18
+	region.strokeColor = "blue"
+	region.shapes = append(region.shapes, *NewLine(NewPoint(0, 0), NewPoint(0, 1)))
+	baseConfig.regions = append(baseConfig.regions, *region)
 
 	allConfigFile, err := StaticAssets.Open("static/configs/proved/all.json")
 	if err != nil {
@@ -295,3 +346,267 @@ func TestGroupConfigJson(t *testing.T) {
 		t.Error("Parsing group.json to config failed!")
 	}
 }
+
+func TestConfigSchema(t *testing.T) {
+	configPaths := []string{"static/configs/ellie.json", "static/configs/proved/all.json", "static/configs/proved/circles.json",
+		"static/configs/proved/group.json", "static/configs/proved/lines.json", "static/configs/proved/polygons.json",
+		"static/configs/proved/polylines.json", "static/configs/proved/rectangles.json", "static/configs/proved/triangles.json"}
+
+	for _, configPath := range configPaths {
+		content, err := StaticAssets.ReadFile(configPath)
+		if err != nil {
+			t.Fatal("Reading " + configPath + " from static assets failed!")
+		}
+
+		var jsonData any
+		err = json.Unmarshal(content, &jsonData)
+		if err != nil {
+			t.Fatal("Parsing " + configPath + " failed!")
+		}
+
+		for _, configError := range validateConfigSchema(jsonData) {
+			t.Error(configPath + ": " + configError.String())
+		}
+	}
+
+	var jsonData any
+	json.Unmarshal([]byte(`{"quadrantWidth": 0, "shapes": [{"type": "line", "p1": [0, 0], "p2": "x"}, {"type": "group", "shapes": [{"type": "star"}]}]}`), &jsonData)
+	configErrors := validateConfigSchema(jsonData)
+
+	var paths []string
+	for _, configError := range configErrors {
+		paths = append(paths, configError.path)
+	}
+
+	if !slices.Equal(paths, []string{"quadrantWidth", "shapes[0].p2", "shapes[1].shapes[0].type"}) {
+		t.Errorf("Unexpected schema validation errors %v", configErrors)
+	}
+}
+
+func TestConfigConstraints(t *testing.T) {
+	resetStaticVariables()
+	config := NewConfig()
+	if !config.validateConstraints() {
+		t.Errorf("The standard config violates the constraints: %v", errors)
+	}
+
+	// The schema and the validation are built from the same constraints
+	properties := getConfigSchema()["properties"].(map[string]any)
+	for key, constraints := range configConstraints {
+		for constraint, constraintValue := range constraints {
+			property, _ := properties[key].(map[string]any)
+			if !reflect.DeepEqual(property[constraint], constraintValue) {
+				t.Errorf("The schema of '%s' does not contain the constraint '%s'", key, constraint)
+			}
+		}
+	}
+
+	// Lengths with a unit are only checked after parsing. Errors of the schema validation are not reported twice.
+	errors = nil
+	config = NewConfig()
+	if config.fromJSON(`{"quadrantWidth": "0mm", "darknessThreshold": -1, "orientation": "x", "strokeColor": 5}`) {
+		t.Fatal("The config violates the constraints")
+	}
+	var paths []string
+	for _, configError := range getStructuredErrors() {
+		paths = append(paths, configError.path)
+	}
+	if !slices.Equal(paths, []string{"darknessThreshold", "orientation", "strokeColor", "quadrantWidth"}) {
+		t.Errorf("Unexpected errors %v", errors)
+	}
+	resetStaticVariables()
+}
+
+func TestConfigFormats(t *testing.T) {
+	jsonConfig := NewConfig()
+	jsonConfig.fromJSON(`{"outputPath": "art.svg", "darknessThreshold": 12, "strokeColor": "#ff0000", "shapes": [
+		{"type": "line", "p1": [0, 0], "p2": [1, 1]},
+		{"type": "group", "shapes": [{"type": "circle", "center": [0, 0], "radius": 0.5}]}]}`)
+
+	formats := map[string]string{
+		"config.yaml": `
+# A comment
+outputPath: art.svg
+darknessThreshold: 12
+strokeColor: "#ff0000"
+shapes:
+- type: line
+  p1: [0, 0]
+  p2: [1, 1]
+- type: group
+  shapes:
+    - {type: circle, center: [0, 0], radius: 0.5}
+`,
+		"config.toml": `
+# A comment
+outputPath = "art.svg"
+darknessThreshold = 12
+strokeColor = '#ff0000'
+
+[[shapes]]
+type = "line"
+p1 = [0, 0]
+p2 = [
+	1,
+	1,
+]
+
+[[shapes]]
+type = "group"
+
+[[shapes.shapes]]
+type = "circle"
+center = [0, 0]
+radius = 0.5
+`,
+	}
+
+	for configPath, content := range formats {
+		jsonContent, _, err := resolveConfigContent(content, configPath, "")
+		if err != nil {
+			t.Fatalf("Resolving %s failed: %s", configPath, err)
+		}
+
+		config := NewConfig()
+		config.fromJSON(jsonContent)
+		if !config.equalTo(&jsonConfig) {
+			t.Errorf("Parsing %s failed!", configPath)
+		}
+	}
+}
+
+func TestConfigExtendsAndIncludes(t *testing.T) {
+	directory := t.TempDir()
+	os.WriteFile(filepath.Join(directory, "base.toml"), []byte("darknessThreshold = 12\nstrokeColor = 'red'\n"), 0644)
+	os.WriteFile(filepath.Join(directory, "library.yaml"), []byte("shapes:\n  - {type: line, p1: [0, 0], p2: [1, 1]}\n"), 0644)
+
+	configPath := filepath.Join(directory, "config.json")
+	content, files, err := resolveConfigContent(`{"extends": ["ellie", "base.toml"], "include": "library.yaml", "strokeColor": "blue",
+		"shapes": [{"type": "line", "p1": [0, 0], "p2": [2, 2]}]}`, configPath, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 3 {
+		t.Errorf("Expected the config, the base config and the library as files but got %v", files)
+	}
+
+	var jsonData map[string]any
+	json.Unmarshal([]byte(content), &jsonData)
+
+	if jsonData["darknessThreshold"] != 12.0 || jsonData["strokeColor"] != "blue" || jsonData["outputPath"] != "ellie.svg" {
+		t.Errorf("Merging the base configs failed: %v", jsonData)
+	}
+
+	shapes, _ := jsonData["shapes"].([]any)
+	if len(shapes) != 2 || shapes[0].(map[string]any)["p2"].([]any)[0] != 1.0 {
+		t.Errorf("Including the shapes of the library failed: %v", shapes)
+	}
+
+	if _, ok := jsonData[extendsKey]; ok {
+		t.Error("The effective config must not contain 'extends'")
+	}
+
+	_, _, err = resolveConfigContent(`{"extends": "config.json"}`, configPath, "")
+	if err == nil {
+		t.Error("Cyclic references have to be detected")
+	}
+
+	// With a root directory only presets and the files inside of it can be referenced
+	if _, _, err = resolveConfigContent(`{"extends": ["ellie", "base.toml"]}`, configPath, directory); err != nil {
+		t.Errorf("Unexpected error %s", err)
+	}
+	for _, reference := range []string{filepath.Join(directory, "base.toml"), "../base.toml", "lib/../../base.toml"} {
+		if _, _, err = resolveConfigContent(`{"include": "`+reference+`"}`, configPath, directory); err == nil || !strings.Contains(err.Error(), "not allowed") {
+			t.Errorf("The reference '%s' has to be rejected, got %v", reference, err)
+		}
+	}
+}
+
+func TestConfigLengthUnits(t *testing.T) {
+	resetStaticVariables()
+
+	baseConfig := NewConfig()
+	baseConfig.artworkWidth = 210
+	baseConfig.artworkHeight = 50.8
+	baseConfig.processingDpi = 50.8
+	baseConfig.quadrantWidth = 4
+	baseConfig.quadrantHeight = 3
+	baseConfig.strokeWidth = 36
+	baseConfig.combineShapesTolerance = 25.4 / 72
+	baseConfig.shapes = []Shape{*NewLine(NewPoint(0, 0), NewPoint(10, 25.4))}
+
+	config := NewConfig()
+	ok := config.fromJSON(`{"artworkWidth": "21cm", "artworkHeight": "2in", "processingDpi": 50.8, "quadrantWidth": "2mm",
+		"quadrantHeight": 3, "strokeWidth": "0.5 in", "combineShapesTolerance": "1pt",
+		"shapes": [{"type": "line", "p1": [0, 0], "p2": ["1cm", "72px"]}]}`)
+
+	if !ok || !config.equalTo(&baseConfig) {
+		t.Errorf("Parsing lengths with units failed! %v", errors)
+	}
+
+	resetStaticVariables()
+	config = NewConfig()
+	if config.fromJSON(`{"artworkWidth": "12 furlongs"}`) {
+		t.Error("Lengths with unknown units have to be rejected")
+	}
+
+	// Pixel lengths of shapes, regions and the canvas are converted with the outputDpi of their own config
+	jsonData := map[string]any{"shapes": []any{map[string]any{"type": "line", "p1": []any{0.0, 0.0}, "p2": []any{"100px", "50px"}}}}
+	var shapes []Shape
+	getShapes(jsonData, "shapes", &shapes, 254)
+	if p2 := shapes[0].Lines[0].points[1]; math.Abs(p2.X-10) > 1e-9 || math.Abs(p2.Y-5) > 1e-9 {
+		t.Errorf("Expected the point (10, 5), got %v", p2)
+	}
+	var canvas CanvasShape
+	getCanvas(map[string]any{"canvas": map[string]any{"type": "rectangle", "cornerRadius": "254px"}}, "canvas", &canvas, 127)
+	if canvas.cornerRadius != 50.8 {
+		t.Errorf("Expected a cornerRadius of 50.8mm, got %f", canvas.cornerRadius)
+	}
+	resetStaticVariables()
+}
+
+func TestPaperConfig(t *testing.T) {
+	resetStaticVariables()
+
+	config := NewConfig()
+	ok := config.fromJSON(`{"paper": "A4", "orientation": "landscape", "margin": [10, "1cm", 5, 0], "fit": "stretch"}`)
+	if !ok || config.margins != [4]float64{10, 10, 5, 0} || config.fit != FitStretch {
+		t.Errorf("Parsing the paper settings failed! %v", errors)
+	}
+
+	width, height, ok := config.getPaperSize()
+	if !ok || width != 297 || height != 210 {
+		t.Errorf("Expected a landscape A4 sheet but got %vmm x %vmm", width, height)
+	}
+
+	// The orientation only applies to presets, custom sizes are used as given
+	config.paper = "300mm x 40cm"
+	width, height, ok = config.getPaperSize()
+	if !ok || width != 300 || height != 400 {
+		t.Errorf("Expected a portrait custom sheet but got %vmm x %vmm", width, height)
+	}
+	config.orientation = OrientationPortrait
+	config.paper = "400mm x 300mm"
+	width, height, ok = config.getPaperSize()
+	if !ok || width != 400 || height != 300 {
+		t.Errorf("Expected a landscape custom sheet but got %vmm x %vmm", width, height)
+	}
+
+	resetStaticVariables()
+	config = NewConfig()
+	if config.fromJSON(`{"paper": "A11"}`) || config.fromJSON(`{"paper": "A6", "margin": 60}`) {
+		t.Error("Unknown papers and margins without printable area have to be rejected")
+	}
+
+	resetStaticVariables()
+	Config.fromJSON(`{"paper": "100mm x 100mm", "margin": [20, 10], "processingDpi": 25.4, "quadrantWidth": 1, "quadrantHeight": 1}`)
+	img := fitImageToPage(image.NewGray(image.Rect(0, 0, 200, 100)))
+	if img.Bounds().Dx() != 80 || img.Bounds().Dy() != 40 || pageLayout.offsetX != 10 || pageLayout.offsetY != 30 {
+		t.Errorf("Fitting the image into the printable area failed! %v %+v", img.Bounds(), pageLayout)
+	}
+	if svg := generateSVG(80, 40, nil); !strings.Contains(svg, "<g transform=\"translate(") || !strings.HasSuffix(svg, "</g>\n</svg>") {
+		t.Error("The artwork has to be wrapped into a group that moves it onto the sheet")
+	}
+	resetStaticVariables()
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Configs can be written in JSON, YAML or TOML. The format is determined by the file extension. A config can inherit
+// from other configs or built-in presets with 'extends' and add the shapes and regions of shape libraries with
+// 'include'. Both are resolved before the config is parsed so that the rest of Vecart only sees the effective JSON
+// config.
+
+const (
+	extendsKey = "extends"
+	includeKey = "include"
+)
+
+type ConfigResolver struct {
+	// The config files the effective config was built from
+	files []string
+	// The configs that are currently being resolved. Used for detecting cyclic references.
+	stack []string
+	// If set, only files inside of this directory and presets can be referenced
+	rootDirectory string
+}
+
+// Returned by loadConfigFile if the config file itself could not be read
+type ConfigReadError struct {
+	configPath string
+	err        error
+}
+
+func (readError *ConfigReadError) Error() string {
+	return fmt.Sprintf("could not read config from '%s': %s", readError.configPath, readError.err)
+}
+
+// Reads the config file and returns its effective JSON config together with every file it was built from. Returns a
+// *ConfigReadError if the file could not be read.
+func loadConfigFile(configPath string) (string, []string, error) {
+	content, err := getFileContentsFromFilePath(configPath)
+	if err != nil {
+		return "", []string{configPath}, &ConfigReadError{configPath, err}
+	}
+
+	return resolveConfigContent(content, configPath, "")
+}
+
+// Returns the effective JSON config of the config content read from configPath together with every file it was built
+// from. JSON configs without 'extends' and 'include' are returned unchanged. If rootDirectory is set, references to
+// files outside of it are rejected.
+func resolveConfigContent(content, configPath, rootDirectory string) (string, []string, error) {
+	format := getConfigFormat(configPath)
+	if format == "json" {
+		var jsonData map[string]any
+		err := json.Unmarshal([]byte(content), &jsonData)
+		// Invalid JSON is reported by the config parser
+		if err != nil || (jsonData[extendsKey] == nil && jsonData[includeKey] == nil) {
+			return content, []string{configPath}, nil
+		}
+	}
+
+	resolver := ConfigResolver{files: []string{configPath}, stack: []string{configPath}, rootDirectory: rootDirectory}
+	jsonData, err := resolver.resolve(content, format, filepath.Dir(configPath))
+	if err != nil {
+		return "", resolver.files, err
+	}
+
+	jsonBytes, err := json.MarshalIndent(jsonData, "", "    ")
+	if err != nil {
+		return "", resolver.files, err
+	}
+
+	return string(jsonBytes), resolver.files, nil
+}
+
+func getConfigFormat(configPath string) string {
+	switch strings.ToLower(filepath.Ext(configPath)) {
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".toml":
+		return "toml"
+	}
+
+	return "json"
+}
+
+func parseConfigContent(content, format string) (map[string]any, error) {
+	var data any
+	var err error
+	switch format {
+	case "yaml":
+		data, err = parseYAML(content)
+	case "toml":
+		data, err = parseTOML(content)
+	default:
+		err = json.Unmarshal([]byte(content), &data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	jsonData, ok := data.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("the config has to be an object")
+	}
+
+	return jsonData, nil
+}
+
+// Parses the content and merges it with the configs it extends and includes. References are resolved relative to
+// baseDirectory.
+func (resolver *ConfigResolver) resolve(content, format, baseDirectory string) (map[string]any, error) {
+	jsonData, err := parseConfigContent(content, format)
+	if err != nil {
+		return nil, err
+	}
+
+	extends, err := getConfigReferences(jsonData, extendsKey)
+	if err != nil {
+		return nil, err
+	}
+	includes, err := getConfigReferences(jsonData, includeKey)
+	if err != nil {
+		return nil, err
+	}
+	delete(jsonData, extendsKey)
+	delete(jsonData, includeKey)
+
+	// Later bases override earlier ones and the config itself overrides all of its bases
+	merged := map[string]any{}
+	for _, reference := range extends {
+		base, err := resolver.load(reference, baseDirectory)
+		if err != nil {
+			return nil, err
+		}
+		merged = mergeConfigs(merged, base)
+	}
+	merged = mergeConfigs(merged, jsonData)
+
+	var includedConfigs []map[string]any
+	for _, reference := range includes {
+		included, err := resolver.load(reference, baseDirectory)
+		if err != nil {
+			return nil, err
+		}
+		includedConfigs = append(includedConfigs, included)
+	}
+
+	// Only the shapes and regions of included configs are used. They are added in front of the config's own.
+	for _, key := range []string{"shapes", "regions"} {
+		var values []any
+		for _, included := range includedConfigs {
+			if includedValues, ok := included[key].([]any); ok {
+				values = append(values, includedValues...)
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+
+		if ownValues, ok := merged[key].([]any); ok {
+			values = append(values, ownValues...)
+		} else if merged[key] != nil {
+			return nil, fmt.Errorf("'%s' has to be an array", key)
+		}
+		merged[key] = values
+	}
+
+	return merged, nil
+}
+
+// Loads a referenced config file or preset. Files are searched relative to baseDirectory first.
+func (resolver *ConfigResolver) load(reference, baseDirectory string) (map[string]any, error) {
+	var content string
+	var configPath string
+
+	candidates := []string{filepath.Join(baseDirectory, reference), reference}
+	if resolver.rootDirectory != "" {
+		candidates = nil
+		if relativePath, err := filepath.Rel(resolver.rootDirectory, filepath.Join(baseDirectory, reference)); err == nil && filepath.IsLocal(reference) && filepath.IsLocal(relativePath) {
+			candidates = []string{filepath.Join(baseDirectory, reference)}
+		} else if _, ok := getPresetPaths()[reference]; !ok {
+			return nil, fmt.Errorf("the path '%s' is not allowed. Paths must be relative and must not leave the directory '%s'", reference, resolver.rootDirectory)
+		}
+	}
+
+	for _, candidate := range candidates {
+		if filepath.IsAbs(reference) && candidate != reference {
+			continue
+		}
+		info, err := os.Stat(candidate)
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		bytes, err := os.ReadFile(candidate)
+		if err != nil {
+			return nil, fmt.Errorf("could not read '%s': %s", candidate, err)
+		}
+		content = string(bytes)
+		configPath = candidate
+		resolver.files = append(resolver.files, candidate)
+		break
+	}
+
+	if configPath == "" {
+		presetPath, ok := getPresetPaths()[reference]
+		if !ok {
+			return nil, fmt.Errorf("could not find config or preset '%s'", reference)
+		}
+
+		bytes, err := getPresetFS(presetPath).ReadFile(presetPath)
+		if err != nil {
+			return nil, fmt.Errorf("could not read preset '%s': %s", reference, err)
+		}
+		content = string(bytes)
+		configPath = presetPath
+	}
+
+	if slices.Contains(resolver.stack, configPath) {
+		return nil, fmt.Errorf("cyclic reference: %s -> %s", strings.Join(resolver.stack, " -> "), configPath)
+	}
+
+	resolver.stack = append(resolver.stack, configPath)
+	defer func() { resolver.stack = resolver.stack[:len(resolver.stack)-1] }()
+
+	jsonData, err := resolver.resolve(content, getConfigFormat(configPath), filepath.Dir(configPath))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", reference, err)
+	}
+
+	return jsonData, nil
+}
+
+// 'extends' and 'include' can either be a single reference or a list of references
+func getConfigReferences(jsonData map[string]any, key string) ([]string, error) {
+	switch value := jsonData[key].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{value}, nil
+	case []any:
+		var references []string
+		for _, item := range value {
+			reference, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("'%s' has to be a string or an array of strings", key)
+			}
+			references = append(references, reference)
+		}
+		return references, nil
+	}
+
+	return nil, fmt.Errorf("'%s' has to be a string or an array of strings", key)
+}
+
+// Returns a copy of base with the values of override. Objects are merged recursively, all other values (including
+// arrays) are replaced.
+func mergeConfigs(base, override map[string]any) map[string]any {
+	merged := make(map[string]any, len(base)+len(override))
+	for key, value := range base {
+		merged[key] = value
+	}
+
+	for key, value := range override {
+		baseObject, baseIsObject := merged[key].(map[string]any)
+		overrideObject, overrideIsObject := value.(map[string]any)
+		if baseIsObject && overrideIsObject {
+			merged[key] = mergeConfigs(baseObject, overrideObject)
+		} else {
+			merged[key] = value
+		}
+	}
+
+	return merged
+}
+
+// Returns the built-in presets by name. The presets are the example config of Vecart ('ellie') and the configs in
+// examples/ (e.g. 'ellie_circles' or 'earth_rise').
+func getPresetPaths() map[string]string {
+	presets := map[string]string{"ellie": "static/configs/ellie.json"}
+
+	fs.WalkDir(ExampleConfigs, "examples", func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() {
+			presets[strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))] = path
+		}
+		return nil
+	})
+
+	return presets
+}
+
+func getPresetFS(presetPath string) fs.ReadFileFS {
+	if strings.HasPrefix(presetPath, "static/") {
+		return StaticAssets
+	}
+
+	return ExampleConfigs
+}
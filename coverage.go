@@ -0,0 +1,202 @@
+package main
+
+import (
+	"image"
+	"math"
+)
+
+const (
+	ToneModelIntersections = "intersections"
+	ToneModelCoverage      = "coverage"
+)
+
+// A float buffer for a rectangular part of the processed image. Values outside the buffer are 0.
+type CoverageMap struct {
+	minX, minY    int
+	width, height int
+	values        []float64
+}
+
+func NewCoverageMap(minX, minY, maxX, maxY int) *CoverageMap {
+	width := max(0, maxX-minX+1)
+	height := max(0, maxY-minY+1)
+
+	return &CoverageMap{minX, minY, width, height, make([]float64, width*height)}
+}
+
+func (coverageMap *CoverageMap) at(x, y int) float64 {
+	x -= coverageMap.minX
+	y -= coverageMap.minY
+	if x < 0 || y < 0 || x >= coverageMap.width || y >= coverageMap.height {
+		return 0
+	}
+
+	return coverageMap.values[y*coverageMap.width+x]
+}
+
+func (coverageMap *CoverageMap) add(x, y int, value float64) {
+	x -= coverageMap.minX
+	y -= coverageMap.minY
+	if x < 0 || y < 0 || x >= coverageMap.width || y >= coverageMap.height {
+		return
+	}
+
+	coverageMap.values[y*coverageMap.width+x] += value
+}
+
+// Blurs the buffer with a separable gaussian kernel. The returned buffer is enlarged by the radius of the kernel.
+func (coverageMap *CoverageMap) blur(sigma float64) *CoverageMap {
+	kernel := getGaussianKernel(sigma)
+	radius := len(kernel) / 2
+	if radius == 0 {
+		return coverageMap
+	}
+
+	horizontal := NewCoverageMap(coverageMap.minX-radius, coverageMap.minY,
+		coverageMap.minX+coverageMap.width-1+radius, coverageMap.minY+coverageMap.height-1)
+	for y := horizontal.minY; y < horizontal.minY+horizontal.height; y++ {
+		for x := horizontal.minX; x < horizontal.minX+horizontal.width; x++ {
+			sum := 0.0
+			for offset := -radius; offset <= radius; offset++ {
+				sum += coverageMap.at(x+offset, y) * kernel[offset+radius]
+			}
+			horizontal.add(x, y, sum)
+		}
+	}
+
+	blurred := NewCoverageMap(horizontal.minX, horizontal.minY-radius,
+		horizontal.minX+horizontal.width-1, horizontal.minY+horizontal.height-1+radius)
+	for y := blurred.minY; y < blurred.minY+blurred.height; y++ {
+		for x := blurred.minX; x < blurred.minX+blurred.width; x++ {
+			sum := 0.0
+			for offset := -radius; offset <= radius; offset++ {
+				sum += horizontal.at(x, y+offset) * kernel[offset+radius]
+			}
+			blurred.add(x, y, sum)
+		}
+	}
+
+	return blurred
+}
+
+// Returns a normalized one dimensional gaussian kernel that covers three standard deviations.
+func getGaussianKernel(sigma float64) []float64 {
+	if sigma <= 0 {
+		return []float64{1}
+	}
+
+	radius := int(math.Ceil(3 * sigma))
+	kernel := make([]float64, 2*radius+1)
+	sum := 0.0
+	for index := range kernel {
+		distance := float64(index - radius)
+		kernel[index] = math.Exp(-(distance * distance) / (2 * sigma * sigma))
+		sum += kernel[index]
+	}
+
+	for index := range kernel {
+		kernel[index] /= sum
+	}
+
+	return kernel
+}
+
+// Calculates how much of every pixel is covered with ink by the strokes of the shape. The coverage of a pixel is the
+// length of the stroke inside the pixel multiplied by the stroke width (both in pixels), which preserves the total
+// amount of ink independent of the angle and position of the stroke.
+func rasterizeShape(shape *Shape, strokeWidth float64) *CoverageMap {
+	minX, maxX, minY, maxY := shape.getMaxAndMinCoordinates()
+	coverageMap := NewCoverageMap(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Floor(maxX)), int(math.Floor(maxY)))
+
+	for lineIndex := range shape.Lines {
+		points := shape.Lines[lineIndex].points
+		for pointIndex := 1; pointIndex < len(points); pointIndex++ {
+			p1, p2 := &points[pointIndex-1], &points[pointIndex]
+			for y := int(math.Floor(math.Min(p1.Y, p2.Y))); y <= int(math.Floor(math.Max(p1.Y, p2.Y))); y++ {
+				for x := int(math.Floor(math.Min(p1.X, p2.X))); x <= int(math.Floor(math.Max(p1.X, p2.X))); x++ {
+					length := getClippedSegmentLength(p1, p2, float64(x), float64(y), float64(x+1), float64(y+1))
+					coverageMap.add(x, y, length*strokeWidth)
+				}
+			}
+		}
+	}
+
+	return coverageMap
+}
+
+// Returns the length of the part of the line segment p1---p2 that lies inside the rectangle (Liang-Barsky clipping).
+func getClippedSegmentLength(p1, p2 *Point, x1, y1, x2, y2 float64) float64 {
+	dx := p2.X - p1.X
+	dy := p2.Y - p1.Y
+	tMin, tMax := 0.0, 1.0
+
+	for _, boundary := range [4][2]float64{{-dx, p1.X - x1}, {dx, x2 - p1.X}, {-dy, p1.Y - y1}, {dy, y2 - p1.Y}} {
+		p, q := boundary[0], boundary[1]
+		if p == 0 {
+			if q < 0 {
+				return 0
+			}
+			continue
+		}
+
+		t := q / p
+		if p < 0 {
+			tMin = math.Max(tMin, t)
+		} else {
+			tMax = math.Min(tMax, t)
+		}
+	}
+
+	if tMin >= tMax {
+		return 0
+	}
+
+	return (tMax - tMin) * math.Sqrt(dx*dx+dy*dy)
+}
+
+// Returns the blurred ink coverage of the shape. The coverage is calculated once per shape copy since shapes are
+// not transformed after they have been scored.
+func (shape *Shape) getCoverage() *CoverageMap {
+	if shape.coverage == nil {
+		strokeWidth := Config.strokeWidth
+		if shape.region != nil {
+			strokeWidth = shape.region.strokeWidth
+		}
+		// The stroke width is given in pixels of the output dpi
+		strokeWidth *= Config.processingDpi / Config.outputDpi
+		shape.coverage = rasterizeShape(shape, strokeWidth).blur(Config.toneModelBlur)
+	}
+
+	return shape.coverage
+}
+
+// Blurs the darkness of the input image the same way the ink coverage of the shapes is blurred, so both can be compared.
+func calculateTargetDarkness(img *image.Gray) {
+	darkness := NewCoverageMap(0, 0, img.Bounds().Max.X-1, img.Bounds().Max.Y-1)
+	for y := 0; y < darkness.height; y++ {
+		for x := 0; x < darkness.width; x++ {
+			darkness.add(x, y, float64(255-img.GrayAt(x, y).Y))
+		}
+	}
+
+	kernel := getGaussianKernel(Config.toneModelBlur)
+	radius := len(kernel) / 2
+	blurred := darkness.blur(Config.toneModelBlur)
+
+	for index := range quadrants {
+		for _, pixel := range quadrants[index].FlattenPixels {
+			// The kernel is normalized over the pixels inside the image so the borders of the image do not become lighter
+			weight := 0.0
+			for dy := -radius; dy <= radius; dy++ {
+				for dx := -radius; dx <= radius; dx++ {
+					x, y := pixel.X1+dx, pixel.Y1+dy
+					if x >= 0 && y >= 0 && x < darkness.width && y < darkness.height {
+						weight += kernel[dx+radius] * kernel[dy+radius]
+					}
+				}
+			}
+			pixel.TargetDarkness = blurred.at(pixel.X1, pixel.Y1) / weight
+			pixel.AdjustedDarkness = math.Max(0, pixel.getResidualDarkness(quadrants[index].region.shapeDarknessFactor))
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestClippedSegmentLength(t *testing.T) {
+	testCases := []struct {
+		p1, p2   Point
+		expected float64
+	}{
+		{Point{-1, 0.5}, Point{2, 0.5}, 1},
+		{Point{0.25, 0.5}, Point{0.75, 0.5}, 0.5},
+		{Point{0, 0}, Point{1, 1}, math.Sqrt2},
+		{Point{-1, -1}, Point{0.5, 0.5}, math.Sqrt2 / 2},
+		{Point{0.5, 2}, Point{0.5, -2}, 1},
+		{Point{2, 0}, Point{3, 1}, 0},
+		{Point{-1, 0.5}, Point{0.5, -1}, 0},
+		{Point{0.5, 0.5}, Point{0.5, 0.5}, 0},
+	}
+
+	for _, testCase := range testCases {
+		length := getClippedSegmentLength(&testCase.p1, &testCase.p2, 0, 0, 1, 1)
+		if math.Abs(length-testCase.expected) > 1e-9 {
+			t.Errorf("%v---%v: expected the length %v inside the unit square, got %v", testCase.p1, testCase.p2, testCase.expected, length)
+		}
+	}
+}
+
+func TestGaussianKernel(t *testing.T) {
+	if kernel := getGaussianKernel(0); len(kernel) != 1 || kernel[0] != 1 {
+		t.Errorf("A sigma of 0 must not blur, got the kernel %v", kernel)
+	}
+
+	kernel := getGaussianKernel(1)
+	if len(kernel) != 7 {
+		t.Fatalf("The kernel has to cover three standard deviations, got %d values", len(kernel))
+	}
+	sum := 0.0
+	for index := range kernel {
+		sum += kernel[index]
+		if math.Abs(kernel[index]-kernel[len(kernel)-1-index]) > 1e-12 {
+			t.Errorf("The kernel %v is not symmetric", kernel)
+		}
+		if index > 0 && index <= 3 && kernel[index] <= kernel[index-1] {
+			t.Errorf("The kernel %v does not peak in the middle", kernel)
+		}
+	}
+	if math.Abs(sum-1) > 1e-12 {
+		t.Errorf("The kernel is not normalized, its sum is %v", sum)
+	}
+	if math.Abs(kernel[4]/kernel[3]-math.Exp(-0.5)) > 1e-12 {
+		t.Errorf("The kernel %v is not gaussian", kernel)
+	}
+}
+
+func TestCoverageBlur(t *testing.T) {
+	coverageMap := NewCoverageMap(5, 5, 5, 5)
+	coverageMap.add(5, 5, 1)
+	// Values outside the map are ignored
+	coverageMap.add(6, 5, 1)
+
+	if coverageMap.blur(0) != coverageMap {
+		t.Error("A sigma of 0 has to return the map itself")
+	}
+
+	blurred := coverageMap.blur(1)
+	if blurred.minX != 2 || blurred.minY != 2 || blurred.width != 7 || blurred.height != 7 {
+		t.Errorf("The blurred map has to be enlarged by the radius of the kernel, got %+v", *blurred)
+	}
+
+	sum := 0.0
+	for _, value := range blurred.values {
+		sum += value
+	}
+	kernel := getGaussianKernel(1)
+	if math.Abs(sum-1) > 1e-12 || math.Abs(blurred.at(5, 5)-kernel[3]*kernel[3]) > 1e-12 ||
+		math.Abs(blurred.at(3, 6)-kernel[1]*kernel[4]) > 1e-12 || blurred.at(0, 0) != 0 {
+		t.Errorf("Unexpected blurred values %v", blurred.values)
+	}
+}
+
+func TestRasterizeShape(t *testing.T) {
+	shape := NewShape([]Polyline{{[]Point{{0.5, 0.5}, {3.5, 0.5}, {3.5, 2.5}}, nil}})
+	coverageMap := rasterizeShape(shape, 2)
+
+	expected := map[[2]int]float64{{0, 0}: 1, {1, 0}: 2, {2, 0}: 2, {3, 0}: 2, {3, 1}: 2, {3, 2}: 1}
+	total := 0.0
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 4; x++ {
+			total += coverageMap.at(x, y)
+			if math.Abs(coverageMap.at(x, y)-expected[[2]int{x, y}]) > 1e-9 {
+				t.Errorf("Expected the coverage %v at %d,%d, got %v", expected[[2]int{x, y}], x, y, coverageMap.at(x, y))
+			}
+		}
+	}
+	// The total amount of ink is the length of the line multiplied by the stroke width
+	if math.Abs(total-5*2) > 1e-9 {
+		t.Errorf("Expected the total coverage 10, got %v", total)
+	}
+}
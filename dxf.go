@@ -0,0 +1,332 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	DXFLayersPen  = "pen"
+	DXFLayersType = "type"
+)
+
+// The basic colors of the AutoCAD Color Index. The color 7 is shown black or white depending on the background.
+var dxfColors = []struct {
+	index int
+	rgb   [3]float64
+}{{1, [3]float64{255, 0, 0}}, {2, [3]float64{255, 255, 0}}, {3, [3]float64{0, 255, 0}}, {4, [3]float64{0, 255, 255}},
+	{5, [3]float64{0, 0, 255}}, {6, [3]float64{255, 0, 255}}, {7, [3]float64{0, 0, 0}}, {7, [3]float64{255, 255, 255}}}
+
+type DXFLayer struct {
+	name  string
+	color int
+}
+
+// Writes the group codes and values of a DXF file. Every object gets a unique handle.
+type DXFWriter struct {
+	lines   []string
+	handles int
+}
+
+func (writer *DXFWriter) add(code int, value string) {
+	writer.lines = append(writer.lines, fmt.Sprintf("%3d", code), value)
+}
+
+func (writer *DXFWriter) addFloat(code int, value float64) {
+	writer.add(code, strconv.FormatFloat(value, 'f', 4, 64))
+}
+
+func (writer *DXFWriter) addPoint(code int, x, y float64) {
+	writer.addFloat(code, x)
+	writer.addFloat(code+10, y)
+	writer.addFloat(code+20, 0)
+}
+
+// Returns a new handle. Handles are hexadecimal numbers starting at 1.
+func (writer *DXFWriter) newHandle() string {
+	writer.handles++
+	return strings.ToUpper(strconv.FormatInt(int64(writer.handles), 16))
+}
+
+// Starts a symbol table with the given number of entries and returns the handle of the table
+func (writer *DXFWriter) beginTable(name string, entries int) string {
+	handle := writer.newHandle()
+	writer.add(0, "TABLE")
+	writer.add(2, name)
+	writer.add(5, handle)
+	writer.add(330, "0")
+	writer.add(100, "AcDbSymbolTable")
+	writer.add(70, strconv.Itoa(entries))
+
+	return handle
+}
+
+// Starts an entry of a symbol table. The subclass is the record type of the table (e.g. AcDbLayerTableRecord).
+func (writer *DXFWriter) beginTableEntry(name, tableHandle, subclass, entryName string) string {
+	handle := writer.newHandle()
+	writer.add(0, name)
+	writer.add(5, handle)
+	writer.add(330, tableHandle)
+	writer.add(100, "AcDbSymbolTableRecord")
+	writer.add(100, subclass)
+	writer.add(2, entryName)
+	writer.add(70, "0")
+
+	return handle
+}
+
+// Starts an entity that is owned by the block record
+func (writer *DXFWriter) beginEntity(name, owner, layer, subclass string) {
+	writer.add(0, name)
+	writer.add(5, writer.newHandle())
+	writer.add(330, owner)
+	writer.add(100, "AcDbEntity")
+	writer.add(8, layer)
+	writer.add(100, subclass)
+}
+
+// Generates an ASCII DXF file (R2000) with the shapes (in pixels of the outputDpi). The coordinates are in millimetres
+// with the origin in the bottom left corner of the sheet. Circles are written as CIRCLE, lines with two points as LINE
+// and all other lines as (closed) LWPOLYLINE entities.
+func generateDXF(artworkWidth, artworkHeight int, shapes []*Shape) string {
+	width, height, offsetX, offsetY := getSheet(artworkWidth, artworkHeight)
+	toDXF := func(point *Point) (float64, float64) {
+		return PixelToMM(point.X, Config.outputDpi) + offsetX, height - PixelToMM(point.Y, Config.outputDpi) - offsetY
+	}
+
+	// The layers have to be known before the entities are written
+	layers := []DXFLayer{{"0", 7}}
+	for _, shape := range shapes {
+		for lineIndex := range shape.Lines {
+			getDXFLayer(shape, &shape.Lines[lineIndex], &layers)
+		}
+	}
+
+	var dxf DXFWriter
+	dxf.add(0, "SECTION")
+	dxf.add(2, "CLASSES")
+	dxf.add(0, "ENDSEC")
+
+	dxf.add(0, "SECTION")
+	dxf.add(2, "TABLES")
+	dxf.beginTable("VPORT", 0)
+	dxf.add(0, "ENDTAB")
+
+	tableHandle := dxf.beginTable("LTYPE", 3)
+	for _, lineType := range []string{"ByBlock", "ByLayer", "Continuous"} {
+		dxf.beginTableEntry("LTYPE", tableHandle, "AcDbLinetypeTableRecord", lineType)
+		if lineType == "Continuous" {
+			dxf.add(3, "Solid line")
+		} else {
+			dxf.add(3, "")
+		}
+		dxf.add(72, "65")
+		dxf.add(73, "0")
+		dxf.addFloat(40, 0)
+	}
+	dxf.add(0, "ENDTAB")
+
+	tableHandle = dxf.beginTable("LAYER", len(layers))
+	for _, layer := range layers {
+		dxf.beginTableEntry("LAYER", tableHandle, "AcDbLayerTableRecord", layer.name)
+		dxf.add(62, strconv.Itoa(layer.color))
+		dxf.add(6, "Continuous")
+	}
+	dxf.add(0, "ENDTAB")
+
+	tableHandle = dxf.beginTable("STYLE", 1)
+	dxf.beginTableEntry("STYLE", tableHandle, "AcDbTextStyleTableRecord", "Standard")
+	dxf.addFloat(40, 0)
+	dxf.addFloat(41, 1)
+	dxf.addFloat(50, 0)
+	dxf.add(71, "0")
+	dxf.addFloat(42, 2.5)
+	dxf.add(3, "txt")
+	dxf.add(4, "")
+	dxf.add(0, "ENDTAB")
+
+	dxf.beginTable("VIEW", 0)
+	dxf.add(0, "ENDTAB")
+	dxf.beginTable("UCS", 0)
+	dxf.add(0, "ENDTAB")
+
+	tableHandle = dxf.beginTable("APPID", 1)
+	dxf.beginTableEntry("APPID", tableHandle, "AcDbRegAppTableRecord", "ACAD")
+	dxf.add(0, "ENDTAB")
+
+	dxf.beginTable("DIMSTYLE", 0)
+	dxf.add(100, "AcDbDimStyleTable")
+	dxf.add(0, "ENDTAB")
+
+	tableHandle = dxf.beginTable("BLOCK_RECORD", 2)
+	modelSpace := dxf.beginTableEntry("BLOCK_RECORD", tableHandle, "AcDbBlockTableRecord", "*Model_Space")
+	paperSpace := dxf.beginTableEntry("BLOCK_RECORD", tableHandle, "AcDbBlockTableRecord", "*Paper_Space")
+	dxf.add(0, "ENDTAB")
+	dxf.add(0, "ENDSEC")
+
+	dxf.add(0, "SECTION")
+	dxf.add(2, "BLOCKS")
+	for _, blockRecord := range []string{modelSpace, paperSpace} {
+		name := "*Model_Space"
+		if blockRecord == paperSpace {
+			name = "*Paper_Space"
+		}
+		dxf.beginEntity("BLOCK", blockRecord, "0", "AcDbBlockBegin")
+		dxf.add(2, name)
+		dxf.add(70, "0")
+		dxf.addPoint(10, 0, 0)
+		dxf.add(3, name)
+		dxf.add(1, "")
+		dxf.beginEntity("ENDBLK", blockRecord, "0", "AcDbBlockEnd")
+	}
+	dxf.add(0, "ENDSEC")
+
+	dxf.add(0, "SECTION")
+	dxf.add(2, "ENTITIES")
+	for _, shape := range shapes {
+		for lineIndex := range shape.Lines {
+			line := &shape.Lines[lineIndex]
+			layer := getDXFLayer(shape, line, &layers)
+
+			if circle, ok := line.originalShape.(*Circle); ok {
+				dxf.beginEntity("CIRCLE", modelSpace, layer, "AcDbCircle")
+				x, y := toDXF(&circle.center)
+				dxf.addPoint(10, x, y)
+				dxf.addFloat(40, PixelToMM(circle.radius, Config.outputDpi))
+				continue
+			}
+
+			points := line.points
+			if polygon, ok := line.originalShape.(*Polygon); ok {
+				points = polygon.points
+			}
+
+			if len(points) == 2 && line.originalShape == nil {
+				dxf.beginEntity("LINE", modelSpace, layer, "AcDbLine")
+				x, y := toDXF(&points[0])
+				dxf.addPoint(10, x, y)
+				x, y = toDXF(&points[1])
+				dxf.addPoint(11, x, y)
+				continue
+			}
+
+			closed := line.isClosed()
+			if closed && line.originalShape == nil {
+				points = points[:len(points)-1]
+			}
+
+			dxf.beginEntity("LWPOLYLINE", modelSpace, layer, "AcDbPolyline")
+			dxf.add(90, strconv.Itoa(len(points)))
+			if closed {
+				dxf.add(70, "1")
+			} else {
+				dxf.add(70, "0")
+			}
+			for index := range points {
+				x, y := toDXF(&points[index])
+				dxf.addFloat(10, x)
+				dxf.addFloat(20, y)
+			}
+		}
+	}
+	dxf.add(0, "ENDSEC")
+
+	dxf.add(0, "SECTION")
+	dxf.add(2, "OBJECTS")
+	rootDictionary, groupDictionary := dxf.newHandle(), dxf.newHandle()
+	dxf.add(0, "DICTIONARY")
+	dxf.add(5, rootDictionary)
+	dxf.add(330, "0")
+	dxf.add(100, "AcDbDictionary")
+	dxf.add(281, "1")
+	dxf.add(3, "ACAD_GROUP")
+	dxf.add(350, groupDictionary)
+	dxf.add(0, "DICTIONARY")
+	dxf.add(5, groupDictionary)
+	dxf.add(330, rootDictionary)
+	dxf.add(100, "AcDbDictionary")
+	dxf.add(281, "1")
+	dxf.add(0, "ENDSEC")
+	dxf.add(0, "EOF")
+
+	// The header is written last since $HANDSEED has to be larger than all handles
+	var header DXFWriter
+	header.add(999, "Generated by Vecart v. "+Version)
+	header.add(999, "https://github.com/DavidJilg/Vecart")
+	if Config.configInOutput {
+		for _, configLine := range strings.Split(UserConfig, "\n") {
+			header.add(999, strings.TrimRight(configLine, "\r"))
+		}
+	}
+
+	header.add(0, "SECTION")
+	header.add(2, "HEADER")
+	header.add(9, "$ACADVER")
+	header.add(1, "AC1015")
+	header.add(9, "$HANDSEED")
+	header.add(5, dxf.newHandle())
+	header.add(9, "$INSUNITS")
+	header.add(70, "4")
+	header.add(9, "$MEASUREMENT")
+	header.add(70, "1")
+	header.add(9, "$EXTMIN")
+	header.addPoint(10, 0, 0)
+	header.add(9, "$EXTMAX")
+	header.addPoint(10, width, height)
+	header.add(0, "ENDSEC")
+
+	return strings.Join(append(header.lines, dxf.lines...), "\n") + "\n"
+}
+
+// Returns the name of the layer of the line and adds the layer if it is new. With the 'pen' mode every combination of
+// stroke color and width gets its own layer (e.g. PEN_BLACK_0_75), with the 'type' mode the layer is the type of the
+// line.
+func getDXFLayer(shape *Shape, line *Polyline, layers *[]DXFLayer) string {
+	strokeColor, strokeWidth := shape.getStroke()
+	color := getDXFColor(strokeColor)
+
+	var name string
+	if Config.dxfLayers == DXFLayersType {
+		switch line.originalShape.(type) {
+		case *Circle:
+			name = "CIRCLES"
+		case *Polygon:
+			name = "POLYGONS"
+		default:
+			name = "POLYLINES"
+			if len(line.points) == 2 {
+				name = "LINES"
+			}
+		}
+		color = 7
+	} else {
+		name = "PEN_" + strings.ToUpper(strings.NewReplacer("#", "", "(", "_", ")", "", ",", "_", " ", "").Replace(strokeColor)) +
+			"_" + strings.ReplaceAll(strconv.FormatFloat(strokeWidth, 'f', 2, 64), ".", "_")
+	}
+
+	for _, layer := range *layers {
+		if layer.name == name {
+			return name
+		}
+	}
+	*layers = append(*layers, DXFLayer{name, color})
+
+	return name
+}
+
+// Returns the AutoCAD Color Index of the basic color that is closest to the stroke color
+func getDXFColor(strokeColor string) int {
+	rgba := parseColor(strokeColor)
+
+	closestColor, closestDistance := 7, math.Inf(1)
+	for _, dxfColor := range dxfColors {
+		distance := math.Pow(float64(rgba.R)-dxfColor.rgb[0], 2) + math.Pow(float64(rgba.G)-dxfColor.rgb[1], 2) + math.Pow(float64(rgba.B)-dxfColor.rgb[2], 2)
+		if distance < closestDistance {
+			closestColor, closestDistance = dxfColor.index, distance
+		}
+	}
+
+	return closestColor
+}
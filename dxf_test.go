@@ -0,0 +1,82 @@
+package main
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDXFExport(t *testing.T) {
+	resetStaticVariables()
+	Config.fromJSON(`{"outputPath": "out.DXF", "processingDpi": 25.4, "outputDpi": 25.4, "configInOutput": false, "strokeColor": "#f00"}`)
+	if getOutputFormat() != OutputFormatDXF {
+		t.Error("The output format has to be determined by the extension of the outputPath")
+	}
+
+	shapes := []*Shape{
+		NewCircle(Point{5, 5}, 2).toShape(),
+		NewPolygon(&[]Point{{0, 0}, {4, 0}, {4, 4}}).toShape(),
+		NewShape([]Polyline{{[]Point{{1, 2}, {3, 4}}, nil}}),
+		NewShape([]Polyline{{[]Point{{0, 0}, {1, 1}, {2, 0}}, nil}}),
+	}
+	dxf := strings.Split(generateDXF(10, 20, shapes), "\n")
+	entities := strings.Join(dxf[slices.Index(dxf, "ENTITIES")+1:], "\n")
+
+	expectedEntities := []string{
+		"  8\nPEN_F00_0_75\n100\nAcDbCircle\n 10\n5.0000\n 20\n15.0000\n 30\n0.0000\n 40\n2.0000",
+		"  8\nPEN_F00_0_75\n100\nAcDbPolyline\n 90\n3\n 70\n1\n 10\n0.0000\n 20\n20.0000\n 10\n4.0000\n 20\n20.0000\n 10\n4.0000\n 20\n16.0000\n",
+		"  8\nPEN_F00_0_75\n100\nAcDbLine\n 10\n1.0000\n 20\n18.0000\n 30\n0.0000\n 11\n3.0000\n 21\n16.0000",
+		"  8\nPEN_F00_0_75\n100\nAcDbPolyline\n 90\n3\n 70\n0\n 10\n0.0000\n 20\n20.0000\n 10\n1.0000\n 20\n19.0000\n 10\n2.0000\n 20\n20.0000\n",
+	}
+	for _, expected := range expectedEntities {
+		if !strings.Contains(entities, expected) {
+			t.Errorf("Missing entity in DXF output:\n%s", expected)
+		}
+	}
+	if strings.Count(entities, "LWPOLYLINE") != 2 || dxf[len(dxf)-2] != "EOF" {
+		t.Error("The polylines have to be written as LWPOLYLINE entities")
+	}
+	if !strings.Contains(strings.Join(dxf, "\n"), "AcDbLayerTableRecord\n  2\nPEN_F00_0_75\n 70\n0\n 62\n1\n") {
+		t.Error("The pen layer has to be red")
+	}
+
+	// R2000 files need unique handles and a $HANDSEED that is larger than all of them
+	handles := make(map[int64]bool)
+	var handleSeed int64
+	for index := 0; index+1 < len(dxf); index += 2 {
+		if dxf[index] != "  5" {
+			continue
+		}
+		handle, err := strconv.ParseInt(dxf[index+1], 16, 64)
+		if err != nil {
+			t.Fatalf("Invalid handle '%s'", dxf[index+1])
+		}
+		if dxf[index-1] == "$HANDSEED" {
+			handleSeed = handle
+			continue
+		}
+		if handles[handle] {
+			t.Errorf("The handle %X is used twice", handle)
+		}
+		handles[handle] = true
+	}
+	for handle := range handles {
+		if handle >= handleSeed {
+			t.Errorf("The $HANDSEED %X has to be larger than the handle %X", handleSeed, handle)
+		}
+	}
+	header := strings.Join(dxf[:slices.Index(dxf, "ENDSEC")], "\n")
+	if !strings.Contains(header, "$ACADVER\n  1\nAC1015") || !strings.Contains(header, "$INSUNITS\n 70\n4") {
+		t.Error("The header has to declare an R2000 file in millimetres")
+	}
+
+	Config.dxfLayers = DXFLayersType
+	dxf = strings.Split(generateDXF(10, 20, shapes), "\n")
+	for _, layer := range []string{"CIRCLES", "POLYGONS", "LINES", "POLYLINES"} {
+		if !slices.Contains(dxf, layer) {
+			t.Errorf("Missing layer %s", layer)
+		}
+	}
+	resetStaticVariables()
+}
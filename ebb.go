@@ -0,0 +1,150 @@
+package main
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// The resolution of an AxiDraw with 16x microstepping (EM,1,1)
+const ebbStepsPerMM = 80
+
+// The highest step rate of the EBB in steps per second
+const ebbMaxStepRate = 25000
+
+// Moves are split into parts of this duration (in seconds) to approximate the acceleration
+const ebbTimeSlice = 0.025
+
+// The servo positions (in units of 1/12 µs) of the pen positions 0 and 100
+const (
+	ebbServoMin = 9855
+	ebbServoMax = 27831
+)
+
+// Writes the commands for an EiBotBoard and keeps track of the position of the pen
+type EBBWriter struct {
+	commands []string
+	position Point
+	stepsX   int
+	stepsY   int
+	// The duration of moves that were too short for a single step. It is added to the next move.
+	pendingMilliseconds int
+}
+
+func (writer *EBBWriter) add(command string, values ...int) {
+	for _, value := range values {
+		command += "," + strconv.Itoa(value)
+	}
+	writer.commands = append(writer.commands, command)
+}
+
+// Moves the pen along the points (in mm) with the given maximum speed, starting and ending at rest. Every segment is
+// split into short moves with a constant speed that follow the acceleration of the plotter.
+func (writer *EBBWriter) moveAlong(points []Point, maxSpeed float64) {
+	lengths, speeds := getSpeedProfile(points, maxSpeed)
+
+	segment := 0
+	for index := 1; index < len(points); index++ {
+		start, end := &points[index-1], &points[index]
+		if start.distanceTo(end) == 0 {
+			continue
+		}
+
+		length := lengths[segment]
+		duration := getSegmentTime(length, speeds[segment], speeds[segment+1], maxSpeed, Config.acceleration)
+		sliceCount := max(int(math.Ceil(duration/ebbTimeSlice)), 1)
+		for slice := 1; slice <= sliceCount; slice++ {
+			distance := length
+			if slice < sliceCount {
+				distance = getSegmentDistance(length, speeds[segment], speeds[segment+1], maxSpeed, Config.acceleration, duration*float64(slice)/float64(sliceCount))
+			}
+			milliseconds := math.Round(duration*float64(slice)/float64(sliceCount)*1000) - math.Round(duration*float64(slice-1)/float64(sliceCount)*1000)
+			writer.moveTo(Point{start.X + (end.X-start.X)*distance/length, start.Y + (end.Y-start.Y)*distance/length}, int(milliseconds))
+		}
+		segment++
+	}
+
+	// The pen waits at the end for the time of the last moves that were too short for a step
+	if writer.pendingMilliseconds > 0 {
+		writer.add("SM", writer.pendingMilliseconds, 0, 0)
+		writer.pendingMilliseconds = 0
+	}
+}
+
+// Moves the pen in a straight line to the point (in mm). The AxiDraw has a CoreXY mechanism, so both motors turn for
+// moves along the x or y axis. Moves that are shorter than a step are merged with the next move to keep the timing.
+func (writer *EBBWriter) moveTo(point Point, milliseconds int) {
+	stepsX, stepsY := int(math.Round(point.X*ebbStepsPerMM)), int(math.Round(point.Y*ebbStepsPerMM))
+	deltaX, deltaY := stepsX-writer.stepsX, stepsY-writer.stepsY
+	writer.position = point
+	milliseconds += writer.pendingMilliseconds
+	if deltaX == 0 && deltaY == 0 {
+		writer.pendingMilliseconds = milliseconds
+		return
+	}
+	writer.pendingMilliseconds = 0
+
+	motor1, motor2 := deltaX+deltaY, deltaX-deltaY
+	milliseconds = max(milliseconds, int(math.Ceil(float64(max(motor1, -motor1, motor2, -motor2))*1000/ebbMaxStepRate)), 1)
+	writer.add("SM", milliseconds, motor1, motor2)
+	writer.stepsX, writer.stepsY = stepsX, stepsY
+}
+
+func (writer *EBBWriter) penUp() {
+	writer.add("SP", 1, int(math.Round(Config.penLiftTime*1000)))
+}
+
+func (writer *EBBWriter) penDown() {
+	writer.add("SP", 0, int(math.Round(Config.penLiftTime*1000)))
+}
+
+// Generates the commands for an AxiDraw or another plotter with an EiBotBoard that draw the polylines (in mm). The
+// plotter has to be at its home position in the top left corner of the sheet. Every line of the file is one command,
+// which the sender has to terminate with a carriage return before waiting for the 'OK' of the EBB.
+func generateEBB(artworkWidth, artworkHeight int, polylines [][]Point) string {
+	_, _, offsetX, offsetY := getSheet(artworkWidth, artworkHeight)
+
+	var writer EBBWriter
+	writer.add("EM", 1, 1)
+	writer.add("SC", 4, getServoPosition(Config.ebbPenUpPosition))
+	writer.add("SC", 5, getServoPosition(Config.ebbPenDownPosition))
+	writer.penUp()
+
+	for _, polyline := range polylines {
+		if len(polyline) == 0 {
+			continue
+		}
+
+		points := make([]Point, len(polyline))
+		for index := range polyline {
+			points[index] = Point{polyline[index].X + offsetX, polyline[index].Y + offsetY}
+		}
+
+		writer.moveAlong([]Point{writer.position, points[0]}, Config.penUpSpeed)
+		writer.penDown()
+		writer.moveAlong(points, Config.penDownSpeed)
+		writer.penUp()
+	}
+	writer.moveAlong([]Point{writer.position, {0, 0}}, Config.penUpSpeed)
+
+	return strings.Join(writer.commands, "\n") + "\n"
+}
+
+// Returns the points of all lines of the shapes as they are drawn (e.g. circles as polygons). The points are copies in
+// the unit of the shapes.
+func getRenderPolylines(shapes []*Shape) [][]Point {
+	var polylines [][]Point
+	for _, shape := range shapes {
+		for lineIndex := range shape.Lines {
+			polylines = append(polylines, slices.Clone(getRenderPoints(&shape.Lines[lineIndex])))
+		}
+	}
+
+	return polylines
+}
+
+// Returns the servo position of the pen position in percent
+func getServoPosition(position float64) int {
+	return int(math.Round(ebbServoMin + (ebbServoMax-ebbServoMin)*position/100))
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestEBBExport(t *testing.T) {
+	resetStaticVariables()
+	Config.fromJSON(`{"processingDpi": 25.4, "outputDpi": 25.4, "penDownSpeed": 20, "acceleration": 100, "penLiftTime": 0.2}`)
+
+	if distance := getSegmentDistance(10, 0, 0, 20, 100, getSegmentTime(10, 0, 0, 20, 100)/2); math.Abs(distance-5) > 1e-9 {
+		t.Errorf("A symmetric move has to be half done after half of the time, not after %vmm", distance)
+	}
+
+	Config.outputPath = "out.ebb"
+	commands := strings.Split(strings.TrimSpace(generateOutput(20, 20, nil, [][]Point{{{5, 5}, {15, 5}}})), "\n")
+	if !slices.Equal(commands[:4], []string{"EM,1,1", "SC,4,20641", "SC,5,15248", "SP,1,200"}) {
+		t.Errorf("Unexpected setup commands %v", commands[:4])
+	}
+
+	var steps [3][2]int
+	var penDownTime int
+	part := 0
+	for _, command := range commands[4:] {
+		values := strings.Split(command, ",")
+		if values[0] == "SP" {
+			part++
+			continue
+		}
+		milliseconds, _ := strconv.Atoi(values[1])
+		motor1, _ := strconv.Atoi(values[2])
+		motor2, _ := strconv.Atoi(values[3])
+		steps[part][0] += motor1
+		steps[part][1] += motor2
+		if part == 1 {
+			penDownTime += milliseconds
+		}
+	}
+	// The pen travels to (5, 5), draws 10mm to the right and returns to the origin
+	if steps != [3][2]int{{800, 0}, {800, 800}, {-1600, -800}} {
+		t.Errorf("Unexpected motor steps %v", steps)
+	}
+	if math.Abs(float64(penDownTime)/1000-getMoveTime([]Point{{5, 5}, {15, 5}}, 20)) > 0.002 {
+		t.Errorf("The line takes %dms instead of the estimated plot time", penDownTime)
+	}
+
+	// Moves that are too short for a step are merged with the next move, so no time is lost
+	var writer EBBWriter
+	writer.moveTo(Point{0.001, 0}, 10)
+	writer.moveTo(Point{1, 0}, 10)
+	writer.moveTo(Point{1.001, 0}, 10)
+	if !slices.Equal(writer.commands, []string{"SM,20,80,80"}) || writer.pendingMilliseconds != 10 {
+		t.Errorf("Unexpected commands %v with %dms left", writer.commands, writer.pendingMilliseconds)
+	}
+
+	// A slow move of 4 steps is split into many slices without a step
+	writer = EBBWriter{}
+	writer.moveAlong([]Point{{0, 0}, {0.05, 0}}, 0.1)
+	totalTime, totalSteps := 0, 0
+	for _, command := range writer.commands {
+		values := strings.Split(command, ",")
+		milliseconds, _ := strconv.Atoi(values[1])
+		motor1, _ := strconv.Atoi(values[2])
+		totalTime += milliseconds
+		totalSteps += motor1
+	}
+	if expected := math.Round(getSegmentTime(0.05, 0, 0, 0.1, Config.acceleration) * 1000); float64(totalTime) != expected || totalSteps != 4 {
+		t.Errorf("Expected 4 steps in %vms but got %d steps in %dms", expected, totalSteps, totalTime)
+	}
+	resetStaticVariables()
+}
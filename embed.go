@@ -9,3 +9,8 @@ var StaticAssets embed.FS
 
 //go:embed LICENSE
 var License embed.FS
+
+// The example configs can be extended by name (e.g. '"extends": "ellie_circles"')
+//
+//go:embed examples/*/*.json
+var ExampleConfigs embed.FS
@@ -2,6 +2,8 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -13,6 +15,32 @@ type Font struct {
 	characterSpacing float64
 }
 
+// The SVG font files that were loaded while parsing the config. The watch mode regenerates the artwork when they change.
+var fontFiles []string
+
+// Loads a font from an SVG file that uses the same format as the fonts of the static Vecart ressources
+func loadFontFile(path string) (*Font, error) {
+	// Files that can not be loaded are recorded as well, so that fixing them triggers a regeneration
+	if !slices.Contains(fontFiles, path) {
+		fontFiles = append(fontFiles, path)
+	}
+
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	font := Font{name: path}
+	if err = font.fromXML(string(bytes)); err != nil {
+		return nil, err
+	}
+	if len(font.characters) == 0 {
+		return nil, fmt.Errorf("the font has no characters")
+	}
+
+	return &font, nil
+}
+
 func (font *Font) fromXML(xmlString string) error {
 	lineheight := 1.0
 	font.spaceWidth = 0.5
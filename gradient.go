@@ -0,0 +1,125 @@
+package main
+
+import (
+	"image"
+	"math"
+)
+
+const (
+	GradientOrientationNone          = "none"
+	GradientOrientationEdge          = "edge"
+	GradientOrientationPerpendicular = "perpendicular"
+
+	GradientOrientationModeBias     = "bias"
+	GradientOrientationModeRestrict = "restrict"
+)
+
+// Calculates the structure tensor (sum of the outer products of the sobel gradients) for every quadrant
+// and derives the dominant edge direction of each quadrant from the tensors of the quadrant and its neighbors.
+func calculateEdgeOrientations(img *image.Gray) {
+	width := img.Bounds().Max.X
+	height := img.Bounds().Max.Y
+
+	darknessAt := func(x, y int) float64 {
+		x = max(0, min(width-1, x))
+		y = max(0, min(height-1, y))
+		return float64(255 - img.GrayAt(x, y).Y)
+	}
+
+	for index := range quadrants {
+		currentQuadrant := quadrants[index]
+		currentQuadrant.structureTensor = [3]float64{0, 0, 0}
+
+		for _, pixel := range currentQuadrant.FlattenPixels {
+			x, y := pixel.X1, pixel.Y1
+
+			gx := (darknessAt(x+1, y-1) + 2*darknessAt(x+1, y) + darknessAt(x+1, y+1)) -
+				(darknessAt(x-1, y-1) + 2*darknessAt(x-1, y) + darknessAt(x-1, y+1))
+			gy := (darknessAt(x-1, y+1) + 2*darknessAt(x, y+1) + darknessAt(x+1, y+1)) -
+				(darknessAt(x-1, y-1) + 2*darknessAt(x, y-1) + darknessAt(x+1, y-1))
+
+			currentQuadrant.structureTensor[0] += gx * gx
+			currentQuadrant.structureTensor[1] += gx * gy
+			currentQuadrant.structureTensor[2] += gy * gy
+		}
+	}
+
+	for index := range quadrants {
+		currentQuadrant := quadrants[index]
+		jxx, jxy, jyy := currentQuadrant.structureTensor[0], currentQuadrant.structureTensor[1], currentQuadrant.structureTensor[2]
+
+		for neighborIndex := range currentQuadrant.Neighbors {
+			jxx += currentQuadrant.Neighbors[neighborIndex].structureTensor[0]
+			jxy += currentQuadrant.Neighbors[neighborIndex].structureTensor[1]
+			jyy += currentQuadrant.Neighbors[neighborIndex].structureTensor[2]
+		}
+
+		currentQuadrant.gradientAngle, currentQuadrant.gradientCoherence = getTensorOrientation(jxx, jxy, jyy)
+	}
+}
+
+// Returns the angle (in radians) of the dominant eigenvector of the symmetric 2x2 tensor [[xx, xy], [xy, yy]]
+// and the coherence (0 = isotropic, 1 = perfectly oriented) of the tensor.
+func getTensorOrientation(xx, xy, yy float64) (float64, float64) {
+	trace := xx + yy
+	if trace <= 0 {
+		return 0, 0
+	}
+
+	angle := 0.5 * math.Atan2(2*xy, xx-yy)
+	coherence := math.Sqrt((xx-yy)*(xx-yy)+4*xy*xy) / trace
+
+	return angle, math.Min(1, coherence)
+}
+
+// Returns how badly the shape is aligned to the preferred direction of the quadrant. 0 means perfectly aligned, 1 means
+// perpendicular to the preferred direction. The value is weighted by how oriented the image and the shape are.
+func getOrientationMisalignment(quadrant *Quadrant, shape *Shape) float64 {
+	targetAngle := quadrant.gradientAngle
+	if Config.gradientOrientation == GradientOrientationEdge {
+		targetAngle += math.Pi / 2
+	}
+
+	return math.Abs(math.Sin(shape.orientation-targetAngle)) * quadrant.gradientCoherence * shape.anisotropy
+}
+
+// Adjusts the scores of shape variants placed in the quadrant based on the configured gradient orientation mode.
+// variants[index] is the shape variant used to create the shape that received shapeScores[index].
+func applyOrientationBias(quadrant *Quadrant, variants []*Shape, shapeScores []float64) {
+	if Config.gradientOrientation == GradientOrientationNone {
+		return
+	}
+
+	if Config.gradientOrientationMode == GradientOrientationModeRestrict {
+		var restricted []int
+		for index := range variants {
+			if !isOrientationAllowed(quadrant, variants[index]) {
+				restricted = append(restricted, index)
+			}
+		}
+
+		// If no variant is aligned well enough all of them are kept so the quadrant can still be finished
+		if len(restricted) == len(variants) {
+			return
+		}
+
+		for _, index := range restricted {
+			shapeScores[index] = math.MaxFloat64 * -1
+		}
+		return
+	}
+
+	for index := range variants {
+		penalty := Config.gradientOrientationWeight * getOrientationMisalignment(quadrant, variants[index])
+		shapeScores[index] -= math.Abs(shapeScores[index]) * penalty
+	}
+}
+
+// Returns false if the restrict mode of the gradient orientation does not allow the shape in the quadrant
+func isOrientationAllowed(quadrant *Quadrant, shape *Shape) bool {
+	if Config.gradientOrientation == GradientOrientationNone || Config.gradientOrientationMode != GradientOrientationModeRestrict {
+		return true
+	}
+
+	return getOrientationMisalignment(quadrant, shape) <= math.Sin(Config.gradientOrientationTolerance*math.Pi/180)
+}
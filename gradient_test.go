@@ -0,0 +1,94 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestShapeOrientation(t *testing.T) {
+	horizontal := NewShape([]Polyline{{[]Point{{0, 0}, {4, 0}}, nil}})
+	horizontal.calculateOrientation()
+	vertical := NewShape([]Polyline{{[]Point{{0, 0}, {0, 4}}, nil}})
+	vertical.calculateOrientation()
+	circle := NewCircle(Point{0, 0}, 2).toShape()
+	circle.calculateOrientation()
+
+	if math.Abs(horizontal.orientation) > 1e-9 || math.Abs(horizontal.anisotropy-1) > 1e-9 {
+		t.Errorf("A horizontal line has the orientation 0 and the anisotropy 1, not %v and %v", horizontal.orientation, horizontal.anisotropy)
+	}
+	if math.Abs(math.Abs(vertical.orientation)-math.Pi/2) > 1e-9 || math.Abs(vertical.anisotropy-1) > 1e-9 {
+		t.Errorf("A vertical line has the orientation pi/2 and the anisotropy 1, not %v and %v", vertical.orientation, vertical.anisotropy)
+	}
+	if circle.anisotropy > 1e-9 {
+		t.Errorf("A circle has no preferred direction, but its anisotropy is %v", circle.anisotropy)
+	}
+}
+
+func TestEdgeOrientation(t *testing.T) {
+	resetStaticVariables()
+	// The image gets darker from left to right, so the gradient points along the x axis
+	img := image.NewGray(image.Rect(0, 0, 20, 20))
+	for y := range 20 {
+		for x := range 20 {
+			img.SetGray(x, y, color.Gray{uint8(255 - x*10)})
+		}
+	}
+	quadrants = []*Quadrant{{}}
+	for y := range 20 {
+		for x := range 20 {
+			quadrants[0].FlattenPixels = append(quadrants[0].FlattenPixels, &Pixel{X1: x, Y1: y})
+		}
+	}
+
+	calculateEdgeOrientations(img)
+	if math.Abs(quadrants[0].gradientAngle) > 1e-9 || quadrants[0].gradientCoherence < 0.99 {
+		t.Errorf("Expected the gradient angle 0 with a coherence of 1, got %v and %v", quadrants[0].gradientAngle, quadrants[0].gradientCoherence)
+	}
+	resetStaticVariables()
+}
+
+func TestOrientationBias(t *testing.T) {
+	resetStaticVariables()
+	horizontal := NewShape([]Polyline{{[]Point{{0, 0}, {4, 0}}, nil}})
+	horizontal.calculateOrientation()
+	diagonal := NewShape([]Polyline{{[]Point{{0, 0}, {4, 4}}, nil}})
+	diagonal.calculateOrientation()
+	vertical := NewShape([]Polyline{{[]Point{{0, 0}, {0, 4}}, nil}})
+	vertical.calculateOrientation()
+	variants := []*Shape{horizontal, diagonal, vertical}
+	quadrant := &Quadrant{gradientAngle: 0, gradientCoherence: 1}
+
+	testCases := []struct {
+		orientation string
+		mode        string
+		scores      []float64
+		expected    []float64
+	}{
+		{GradientOrientationNone, GradientOrientationModeBias, []float64{10, 10, 10}, []float64{10, 10, 10}},
+		{GradientOrientationPerpendicular, GradientOrientationModeBias, []float64{10, 10, -10}, []float64{10, 10 - 10*math.Sqrt(0.5), -20}},
+		{GradientOrientationEdge, GradientOrientationModeBias, []float64{10, 10, 10}, []float64{0, 10 - 10*math.Sqrt(0.5), 10}},
+		{GradientOrientationPerpendicular, GradientOrientationModeRestrict, []float64{10, 10, 10}, []float64{10, -math.MaxFloat64, -math.MaxFloat64}},
+		{GradientOrientationEdge, GradientOrientationModeRestrict, []float64{10, 10, 10}, []float64{-math.MaxFloat64, -math.MaxFloat64, 10}},
+	}
+
+	for _, testCase := range testCases {
+		Config.gradientOrientation = testCase.orientation
+		Config.gradientOrientationMode = testCase.mode
+		applyOrientationBias(quadrant, variants, testCase.scores)
+		if !slices.EqualFunc(testCase.scores, testCase.expected, func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }) {
+			t.Errorf("%s/%s: expected the scores %v, got %v", testCase.orientation, testCase.mode, testCase.expected, testCase.scores)
+		}
+	}
+
+	// If no variant is aligned well enough none of them is restricted
+	Config.gradientOrientation = GradientOrientationPerpendicular
+	scores := []float64{10, 10}
+	applyOrientationBias(quadrant, []*Shape{diagonal, vertical}, scores)
+	if !slices.Equal(scores, []float64{10, 10}) {
+		t.Errorf("The quadrant could not be finished with the scores %v", scores)
+	}
+	resetStaticVariables()
+}
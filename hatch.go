@@ -0,0 +1,70 @@
+package main
+
+import (
+	"math"
+	"slices"
+)
+
+// Fills the closed lines of all shapes (in mm) with hatch lines so that plotters can draw filled shapes
+func hatchShapes() {
+	for quadrantIndex := range quadrants {
+		for shapeIndex := range quadrants[quadrantIndex].Shapes {
+			shape := &quadrants[quadrantIndex].Shapes[shapeIndex]
+			shape.Lines = append(shape.Lines, getHatchLines(shape.Lines, Config.hatchSpacing, Config.hatchAngle)...)
+		}
+	}
+}
+
+// Returns parallel lines with the given spacing and angle (in degrees) that fill the area enclosed by the closed lines.
+// Areas that are enclosed by an even number of lines (e.g. the holes of letters) are not filled. The hatch lines lie
+// on a grid that is the same for all shapes and alternate their direction to avoid long travel moves. Areas that are
+// too small for the grid are filled with a single line through their middle.
+func getHatchLines(lines []Polyline, spacing, angle float64) []Polyline {
+	var rings [][]Point
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for index := range lines {
+		if !lines[index].isClosed() {
+			continue
+		}
+
+		ring := slices.Clone(getRenderPoints(&lines[index]))
+		for pointIndex := range ring {
+			ring[pointIndex].rotate(-angle, Point{0, 0})
+			minY = math.Min(minY, ring[pointIndex].Y)
+			maxY = math.Max(maxY, ring[pointIndex].Y)
+		}
+		rings = append(rings, ring)
+	}
+
+	if len(rings) == 0 {
+		return nil
+	}
+
+	var scanlines []float64
+	for y := (math.Floor(minY/spacing) + 0.5) * spacing; y < maxY; y += spacing {
+		if y > minY {
+			scanlines = append(scanlines, y)
+		}
+	}
+	if len(scanlines) == 0 {
+		scanlines = append(scanlines, (minY+maxY)/2)
+	}
+
+	var hatchLines []Polyline
+	for scanlineIndex, y := range scanlines {
+		intersections := getScanlineIntersections(y, rings)
+		if scanlineIndex%2 == 1 {
+			slices.Reverse(intersections)
+		}
+
+		for index := 1; index < len(intersections); index += 2 {
+			start := Point{intersections[index-1], y}
+			end := Point{intersections[index], y}
+			start.rotate(angle, Point{0, 0})
+			end.rotate(angle, Point{0, 0})
+			hatchLines = append(hatchLines, Polyline{[]Point{start, end}, nil})
+		}
+	}
+
+	return hatchLines
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHatchFill(t *testing.T) {
+	resetStaticVariables()
+
+	config := NewConfig()
+	if !config.fromJSON(`{"hatchFill": true, "hatchSpacing": "1mm", "hatchAngle": 0}`) || config.hatchSpacing != 1 || config.hatchAngle != 0 {
+		t.Fatalf("Parsing the hatch parameters failed! %v", errors)
+	}
+	if config.fromJSON(`{"hatchSpacing": 0}`) {
+		t.Error("A hatchSpacing of 0 has to be rejected")
+	}
+
+	// A square with a square hole, the open line is not filled
+	lines := []Polyline{
+		*NewPolygon(&[]Point{{0, 0}, {10, 0}, {10, 10}, {0, 10}}).toPolyline(),
+		*NewPolygon(&[]Point{{3, 3}, {7, 3}, {7, 7}, {3, 7}}).toPolyline(),
+		{[]Point{{20, 0}, {20, 10}}, nil},
+	}
+	hatchLines := getHatchLines(lines, 1, 0)
+
+	length := 0.0
+	for _, line := range hatchLines {
+		length += line.points[0].distanceTo(&line.points[1])
+		midpoint := NewPoint((line.points[0].X+line.points[1].X)/2, (line.points[0].Y+line.points[1].Y)/2)
+		if midpoint.X > 3 && midpoint.X < 7 && midpoint.Y > 3 && midpoint.Y < 7 {
+			t.Errorf("The hole must not be filled! %v", line.points)
+		}
+	}
+	if len(hatchLines) != 14 || math.Abs(length-84) > 1e-9 {
+		t.Errorf("Expected 14 hatch lines with a length of 84mm but got %d with %vmm", len(hatchLines), length)
+	}
+	if hatchLines[0].points[0].X != 0 || hatchLines[1].points[0].X != 10 {
+		t.Error("The direction of the hatch lines has to alternate")
+	}
+
+	// Areas smaller than the spacing still get a line
+	if len(getHatchLines([]Polyline{*NewPolygon(&[]Point{{0.1, 0.1}, {0.3, 0.1}, {0.3, 0.3}}).toPolyline()}, 1, 45)) != 1 {
+		t.Error("Small areas have to be filled with one line")
+	}
+	resetStaticVariables()
+}
@@ -0,0 +1,81 @@
+package main
+
+// The colors of the first layers of LightBurn. LightBurn assigns imported lines to the layer with the matching color.
+const (
+	LaserFillColor = "#000000"
+	LaserLineColor = "#0000FF"
+	LaserCutColor  = "#FF0000"
+)
+
+// A group of lines that the laser processes with the same operation
+type LaserLayer struct {
+	id    string
+	label string
+	lines []string
+}
+
+func (layer *LaserLayer) toSVG() []string {
+	if len(layer.lines) == 0 {
+		return nil
+	}
+
+	svgLines := []string{"<g id=\"" + layer.id + "\" inkscape:groupmode=\"layer\" inkscape:label=\"" + layer.label + "\">"}
+	svgLines = append(svgLines, layer.lines...)
+
+	return append(svgLines, "</g>")
+}
+
+// Sorts the lines (in pixels of the outputDpi) into layers for laser engravers. Closed lines are engraved as filled
+// areas if fillClosedShapes is enabled, all other lines are engraved as lines. The outline of the canvas is added as
+// cut layer if laserCutOutline is enabled. The size of the artwork is given in processing pixels.
+func generateLaserLayers(artworkWidth, artworkHeight int, shapes []*Shape) []string {
+	fillLayer := LaserLayer{id: "engrave-fill", label: "Engrave Fill"}
+	lineLayer := LaserLayer{id: "engrave-line", label: "Engrave Line"}
+	cutLayer := LaserLayer{id: "cut", label: "Cut"}
+
+	for _, shape := range shapes {
+		_, strokeWidth := shape.getDrawnStroke()
+		for lineIndex := range shape.Lines {
+			line := &shape.Lines[lineIndex]
+			if Config.fillClosedShapes && line.isClosed() {
+				fillLayer.lines = append(fillLayer.lines, line.toSVG(getStyle(LaserFillColor, strokeWidth, true)))
+			} else {
+				lineLayer.lines = append(lineLayer.lines, line.toSVG(getStyle(LaserLineColor, strokeWidth, false)))
+			}
+		}
+	}
+
+	if Config.laserCutOutline {
+		for _, outline := range getCanvasOutline(float64(artworkWidth), float64(artworkHeight)) {
+			cutLayer.lines = append(cutLayer.lines, outline.toSVG(getStyle(LaserCutColor, Config.strokeWidth, false)))
+		}
+	}
+
+	var svgLines []string
+	for _, layer := range []LaserLayer{fillLayer, lineLayer, cutLayer} {
+		svgLines = append(svgLines, layer.toSVG()...)
+	}
+
+	return svgLines
+}
+
+// Returns the outline of the canvas in pixels of the outputDpi. The size of the artwork is given in processing pixels.
+func getCanvasOutline(artworkWidth, artworkHeight float64) []*Polygon {
+	var rings [][]Point
+	boundary := Config.canvas.getBoundary(artworkWidth, artworkHeight)
+	if boundary != nil {
+		rings = boundary.rings
+	} else {
+		rings = [][]Point{{{0, 0}, {artworkWidth, 0}, {artworkWidth, artworkHeight}, {0, artworkHeight}}}
+	}
+
+	var outlines []*Polygon
+	for _, ring := range rings {
+		polygon := NewPolygon(&ring).copy()
+		polygon.pixelToMM(Config.processingDpi)
+		polygon.mmToPixel(Config.outputDpi)
+		outlines = append(outlines, polygon)
+	}
+
+	return outlines
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLaserLayers(t *testing.T) {
+	resetStaticVariables()
+	Config.fromJSON(`{"fillClosedShapes": true, "processingDpi": 72, "outputDpi": 72, "configInOutput": false}`)
+
+	square := NewPolygon(&[]Point{{0, 0}, {2, 0}, {2, 2}, {0, 2}}).toShape()
+	line := NewShape([]Polyline{{[]Point{{0, 0}, {0, 4}}, nil}})
+	shapes := []*Shape{square, line}
+
+	svg := generateSVG(10, 10, shapes)
+	if !strings.Contains(svg, "<polygon  points=\"0.000000 0.000000 2.000000 0.000000 2.000000 2.000000 0.000000 2.000000\" style=\"stroke:black; fill:black;") ||
+		!strings.Contains(svg, "<polyline points=\"0.000000 0.000000 0.000000 4.000000\" style=\"stroke:black; fill:none;") {
+		t.Errorf("Only closed shapes have to be filled! %s", svg)
+	}
+
+	Config.laserLayers = true
+	Config.laserCutOutline = true
+	svg = generateSVG(10, 10, shapes)
+	expectedLines := []string{
+		"<g id=\"engrave-fill\" inkscape:groupmode=\"layer\" inkscape:label=\"Engrave Fill\">",
+		"<polygon  points=\"0.000000 0.000000 2.000000 0.000000 2.000000 2.000000 0.000000 2.000000\" style=\"stroke:#000000; fill:#000000; stroke-width: 0.75px\" />",
+		"</g>",
+		"<g id=\"engrave-line\" inkscape:groupmode=\"layer\" inkscape:label=\"Engrave Line\">",
+		"<polyline points=\"0.000000 0.000000 0.000000 4.000000\" style=\"stroke:#0000FF; fill:none; stroke-width: 0.75px\" />",
+		"</g>",
+		"<g id=\"cut\" inkscape:groupmode=\"layer\" inkscape:label=\"Cut\">",
+		"<polygon  points=\"0.000000 0.000000 10.000000 0.000000 10.000000 10.000000 0.000000 10.000000\" style=\"stroke:#FF0000; fill:none; stroke-width: 0.75px\" />",
+		"</g>",
+	}
+	if !strings.Contains(svg, strings.Join(expectedLines, "\n")) {
+		t.Errorf("The laser layers do not match! %s", svg)
+	}
+	resetStaticVariables()
+}
@@ -1,5 +1,7 @@
 package main
 
+import "slices"
+
 // Determines if the line segments p1---p2 & p3---p4 intersect
 func intersectingLineSegments(p1, p2, p3, p4 *Point) bool {
 	if pointOnLine(p1, p2, p3) || pointOnLine(p1, p2, p4) {
@@ -60,6 +62,36 @@ func countLineIntersections(line1, line2 *Polyline) int {
 	return intersections
 }
 
+// Determines if the point is inside the polygon (even-odd rule). The polygon is implicitly closed.
+func pointInPolygon(point *Point, polygon []Point) bool {
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		pi, pj := &polygon[i], &polygon[j]
+		if (pi.Y > point.Y) != (pj.Y > point.Y) &&
+			point.X < (pj.X-pi.X)*(point.Y-pi.Y)/(pj.Y-pi.Y)+pi.X {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// Returns the sorted x coordinates where the horizontal line at y crosses the rings. Like in pointInPolygon an edge only
+// counts if its end points are on different sides of the line, so that vertices on the line are not counted twice.
+func getScanlineIntersections(y float64, rings [][]Point) []float64 {
+	var intersections []float64
+	for _, ring := range rings {
+		for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+			pi, pj := &ring[i], &ring[j]
+			if (pi.Y > y) != (pj.Y > y) {
+				intersections = append(intersections, (pj.X-pi.X)*(y-pi.Y)/(pj.Y-pi.Y)+pi.X)
+			}
+		}
+	}
+	slices.Sort(intersections)
+
+	return intersections
+}
+
 // Determines if the point p3 is on the line p1---p2
 func pointOnLine(p1, p2, p3 *Point) bool {
 	return p1.distanceTo(p3)+p2.distanceTo(p3) == p1.distanceTo(p2)
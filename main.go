@@ -40,56 +40,107 @@ func main() {
 		case "updateprovedsvg":
 			updateProvedSVG()
 			return
+		case "sweep":
+			if len(argsWithoutProg) < 2 {
+				printUsage()
+				os.Exit(ExitUsage)
+			}
+			if !runSweep(argsWithoutProg[1]) {
+				os.Exit(1)
+			}
+			return
+		case "serve":
+			if !runServer(argsWithoutProg[1:]) {
+				os.Exit(1)
+			}
+			return
+		case "ui":
+			if !runUI(argsWithoutProg[1:]) {
+				os.Exit(1)
+			}
+			return
+		case "watch":
+			if len(argsWithoutProg) < 2 {
+				printUsage()
+				os.Exit(ExitUsage)
+			}
+			if !runWatch(argsWithoutProg[1]) {
+				os.Exit(1)
+			}
+			return
+		case "validate":
+			os.Exit(runValidate(argsWithoutProg[1:]))
+		case "schema":
+			Fonts = loadFonts()
+			schema := getConfigSchemaJSON()
+			if len(argsWithoutProg) > 1 {
+				if writeStringToFile(schema+"\n", argsWithoutProg[1]) != nil {
+					os.Exit(ExitIOError)
+				}
+				return
+			}
+			fmt.Println(schema)
+			return
 		case "help", "-help", "--help", "h", "-h":
 			printUsage()
+			return
 		}
 	}
 
+	options, err := parseCommandLine(argsWithoutProg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		printUsage()
+		os.Exit(ExitUsage)
+	}
+
+	if options.quiet {
+		silenceStdout()
+	}
+
 	fmt.Printf("Vecart v%s - by David Jilg (david-jilg.com/vecart)\n\n", Version)
 
 	Fonts = loadFonts()
 
 	Config = NewConfig()
 
-	if len(argsWithoutProg) > 0 {
-		content, err := getFileContentsFromFilePath(argsWithoutProg[0])
-		if err != nil {
-			fmt.Println("Could not read config from '" + argsWithoutProg[0] + "'")
-			return
-		}
-		ok := Config.fromJSON(content)
-		if !ok {
-
-			fmt.Println("Errors occured while parsing Config. Start Vecart in debug mode for more details (' \"debug\": true ' in config).")
-			for {
-				option, ok := askForOption("\nContinue despite the errors?", []string{"yes", "no", "more info"})
-
-				if !ok || option == "no" {
-					return
-				}
-
-				if option == "more info" {
-					for _, errorString := range errors {
-						fmt.Println(errorString)
-					}
-					continue
-				}
-
-				break
-			}
-
+	var content string
+	if options.configPath != "" {
+		content, _, err = loadConfigFile(options.configPath)
+		if _, ok := err.(*ConfigReadError); ok {
+			fmt.Fprintln(os.Stderr, "Could not read config from '"+options.configPath+"'")
+			os.Exit(ExitIOError)
+		} else if err != nil {
+			fmt.Fprintf(os.Stderr, "Could not load config from '%s': %s\n", options.configPath, err)
+			os.Exit(ExitConfigError)
 		}
 		UserConfig = content
 	} else {
 		printUsage()
 		fmt.Println()
 		fmt.Println("No configuration provided. Continuing with example configuration!")
-		config, err := getConfigFromStaticAssets("static/configs/ellie.json")
+		bytes, err := StaticAssets.ReadFile("static/configs/ellie.json")
 		if err != nil {
 			fmt.Println(err)
 			panic("Could not get example config file from static assets!")
 		}
-		Config = config
+		content = string(bytes)
+	}
+
+	if options.hasOverrides() {
+		content, err = options.applyOverrides(content)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Could not apply command line options to the config: '%s'\n", err)
+			os.Exit(ExitConfigError)
+		}
+		UserConfig = content
+	}
+
+	ok := Config.fromJSON(content)
+	if !ok {
+		if !handleConfigErrors(options) {
+			os.Exit(ExitConfigError)
+		}
 	}
 
 	if Config.debug {
@@ -97,11 +148,44 @@ func main() {
 		fmt.Printf("%s\n\n", Config.toJson())
 	}
 
-	svg := startVecart()
-	if svg != "" {
-		writeStringToFile(svg, Config.outputPath)
-		duration := time.Since(start)
-		fmt.Printf("\n\nVecart finished in %s\n\n", duration.Round(time.Second))
+	if exitCode := runBatch(); exitCode != ExitSuccess {
+		os.Exit(exitCode)
+	}
+
+	duration := time.Since(start)
+	fmt.Printf("\n\nVecart finished in %s\n\n", duration.Round(time.Second))
+}
+
+// Decides if Vecart continues despite errors in the config. Only asks the user if neither '--yes' nor '--strict' is
+// given and stdin is a terminal.
+func handleConfigErrors(options *CommandLineOptions) bool {
+	if options.yes || options.strict || !stdinIsTerminal() {
+		fmt.Fprintln(os.Stderr, "Errors occured while parsing Config:")
+		for _, errorString := range errors {
+			fmt.Fprintln(os.Stderr, "   "+errorString)
+		}
+		if options.yes {
+			fmt.Fprintln(os.Stderr, "Continuing despite the errors (--yes).")
+		}
+		return options.yes
+	}
+
+	fmt.Println("Errors occured while parsing Config. Start Vecart in debug mode for more details (' \"debug\": true ' in config).")
+	for {
+		option, ok := askForOption("\nContinue despite the errors?", []string{"yes", "no", "more info"})
+
+		if !ok || option == "no" {
+			return false
+		}
+
+		if option == "more info" {
+			for _, errorString := range errors {
+				fmt.Println(errorString)
+			}
+			continue
+		}
+
+		return true
 	}
 }
 
@@ -240,7 +324,9 @@ func loadFonts() map[string]*Font {
 	return fonts
 }
 
-func startVecart() string {
+// Converts the input image of the config into the SVG. Returns an error if the image could not be read and an empty
+// SVG if the generation was cancelled.
+func startVecart() (string, error) {
 	RandSource = rand.New(rand.NewPCG(uint64(Config.randomSeed), uint64(Config.randomSeed)))
 
 	var img image.Image
@@ -248,38 +334,35 @@ func startVecart() string {
 	if Config.inputPath == "" {
 		ellieFile, err := StaticAssets.Open("static/ellie.png")
 		if err != nil {
-			fmt.Println("Can not open image 'ellie.png' from static Vecart ressources!")
-			fmt.Println(err)
-			return ""
+			return "", fmt.Errorf("can not open image 'ellie.png' from static Vecart ressources: %w", err)
 		}
 		defer ellieFile.Close()
 
 		img, _, err = image.Decode(ellieFile)
 		if err != nil {
-			fmt.Println("Can not decode image 'ellie.jpg' from static Vecart ressources!")
-			fmt.Println(err)
-			return ""
+			return "", fmt.Errorf("can not decode image 'ellie.png' from static Vecart ressources: %w", err)
 		}
 	} else {
 		img, err = getImageFromFilePath(Config.inputPath)
 		if err != nil {
-			fmt.Printf("Can not decode image '%s'!\n", Config.inputPath)
-			fmt.Println(err)
-			return ""
+			return "", fmt.Errorf("can not decode image '%s': %w", Config.inputPath, err)
 		}
 	}
 
-	if Config.artworkWidth != 0 && Config.artworkHeight != 0 {
-		imageWidthPixel := int(math.Round(mmToPixel(float64(Config.artworkWidth), Config.processingDpi)))
-		imageHeightPixel := int(math.Round(mmToPixel(float64(Config.artworkHeight), Config.processingDpi)))
+	pageLayout = nil
+	if Config.paper != "" {
+		img = fitImageToPage(img)
+	} else if Config.artworkWidth != 0 && Config.artworkHeight != 0 {
+		imageWidthPixel := int(math.Round(mmToPixel(Config.artworkWidth, Config.processingDpi)))
+		imageHeightPixel := int(math.Round(mmToPixel(Config.artworkHeight, Config.processingDpi)))
 
 		imageWidth := imageWidthPixel - (imageWidthPixel % Config.quadrantWidth)
 		imageHeight := imageHeightPixel - (imageHeightPixel % Config.quadrantHeight)
 
 		img = resizeImage(img, imageWidth, imageHeight)
 	} else if Config.artworkWidth != 0 || Config.artworkHeight != 0 {
-		imageWidthPixel := int(math.Round(mmToPixel(float64(Config.artworkWidth), Config.processingDpi)))
-		imageHeightPixel := int(math.Round(mmToPixel(float64(Config.artworkHeight), Config.processingDpi)))
+		imageWidthPixel := int(math.Round(mmToPixel(Config.artworkWidth, Config.processingDpi)))
+		imageHeightPixel := int(math.Round(mmToPixel(Config.artworkHeight, Config.processingDpi)))
 		img = resizeImage(img, imageWidthPixel, imageHeightPixel)
 
 		imageWidth := img.Bounds().Max.X - (img.Bounds().Max.X % Config.quadrantWidth)
@@ -291,9 +374,18 @@ func startVecart() string {
 	greyscaleImg := image.NewGray(img.Bounds())
 	draw.Draw(greyscaleImg, greyscaleImg.Bounds(), img, img.Bounds().Min, draw.Src)
 
-	initialize(greyscaleImg, calculateNeighborRange())
+	canvasBoundary = Config.canvas.getBoundary(float64(greyscaleImg.Bounds().Max.X), float64(greyscaleImg.Bounds().Max.Y))
+	if canvasBoundary != nil {
+		canvasBoundary.maskImage(greyscaleImg)
+	}
+
+	ProcessedImage = greyscaleImg
+	if !shapesInitialized {
+		shapeNeighborRange = calculateNeighborRange()
+	}
+	initialize(greyscaleImg, shapeNeighborRange)
 
-	return generateVectorArt(greyscaleImg.Bounds().Max.X, greyscaleImg.Bounds().Max.Y)
+	return generateVectorArt(greyscaleImg.Bounds().Max.X, greyscaleImg.Bounds().Max.Y), nil
 }
 
 func getAllShapeVariants(xOffset float64) []*Shape {
@@ -320,25 +412,41 @@ func resizeImage(img image.Image, width, height int) image.Image {
 	return dst
 }
 
-func writeStringToFile(content, path string) {
+func writeStringToFile(content, path string) error {
 	file, err := createFile(path)
 	if err != nil {
-		fmt.Printf("Could not create file '%s'\n", path)
-		fmt.Println(err)
-		return
+		fmt.Fprintf(os.Stderr, "Could not create file '%s'\n", path)
+		fmt.Fprintln(os.Stderr, err)
+		return err
 	}
 
 	defer file.Close()
 
 	writer := bufio.NewWriter(file)
-	defer writer.Flush()
+	_, err = writer.WriteString(content)
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not write file '%s'\n", path)
+		fmt.Fprintln(os.Stderr, err)
+	}
 
-	writer.WriteString(content)
+	return err
 }
 
 func printUsage() {
 	fmt.Println("Usage")
 	fmt.Println("  Vecart [pathToJSONConfig]")
+	fmt.Println("  Vecart [pathToJSONConfig] [pathToImage | pathToDirectory | glob]...")
+	fmt.Println("  Vecart [--config pathToJSONConfig] [--input path]... [--output path] [--seed n] [--set key=value]...")
+	fmt.Println("         [--yes | --strict] [--quiet]")
+	fmt.Println("  Vecart sweep [pathToJSONSweepDefinition]")
+	fmt.Println("  Vecart serve [--addr localhost:8080] [--directory pathToJobDirectory] [--retention 1h]")
+	fmt.Println("  Vecart ui [--addr localhost:8080] [--directory pathToJobDirectory] [--retention 1h]")
+	fmt.Println("  Vecart watch [pathToJSONConfig]")
+	fmt.Println("  Vecart validate [--json] [pathToJSONConfig]")
+	fmt.Println("  Vecart schema [pathToOutputFile]")
 	fmt.Println("  Vecart --help")
 	fmt.Println("  Vecart --license")
 }
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The number of unfinished quadrants when monitorQuadrants ended the shape placement because of the timeout
+var unfinishedQuadrantsAtTimeout int
+var placementTimedOut bool
+
+// The metrics of the last artwork. Only calculated by generateVectorArt if metrics or metricsFile is enabled.
+var generatedMetrics *Metrics
+
+// Objective measures of the quality and the plotting effort of an artwork
+type Metrics struct {
+	toneError                    float64
+	ssim                         float64
+	inkLength                    float64
+	nrOfPolylines                int
+	travelDistance               float64
+	shapeTypes                   map[string]int
+	timeoutReached               bool
+	unfinishedQuadrantsAtTimeout int
+	plotTime                     time.Duration
+}
+
+// Calculates the metrics of the finished artwork. Called at the end of generateVectorArt since the shapes need to be in
+// pixels of the outputDpi.
+func calculateMetrics() *Metrics {
+	metrics := Metrics{shapeTypes: make(map[string]int)}
+
+	preview := renderPreview()
+	width, height := preview.Bounds().Dx(), preview.Bounds().Dy()
+	input := image.NewGray(preview.Bounds())
+	draw.Draw(input, input.Bounds(), resizeImage(ProcessedImage, width, height), image.Point{}, draw.Src)
+	rendering := image.NewGray(preview.Bounds())
+	draw.Draw(rendering, rendering.Bounds(), preview, image.Point{}, draw.Src)
+
+	// Only the pixels inside of a non-rectangular canvas are compared
+	var mask *image.Gray
+	if canvasBoundary != nil {
+		mask = canvasBoundary.getMask(width, height, float64(ProcessedImage.Bounds().Dx())/float64(width))
+	}
+
+	metrics.toneError = getToneError(input, rendering, mask)
+	metrics.ssim = getSSIM(input, rendering, mask)
+
+	var lastPoint *Point
+	for _, shape := range collectShapes() {
+		metrics.shapeTypes[shape.getType()]++
+		for lineIndex := range shape.Lines {
+			points := getRenderPoints(&shape.Lines[lineIndex])
+			if len(points) == 0 {
+				continue
+			}
+
+			metrics.nrOfPolylines++
+			metrics.inkLength += PixelToMM((&Polyline{points, nil}).getLength(), Config.outputDpi)
+			if lastPoint != nil {
+				metrics.travelDistance += PixelToMM(lastPoint.distanceTo(&points[0]), Config.outputDpi)
+			}
+			lastPoint = &points[len(points)-1]
+		}
+	}
+
+	metrics.plotTime = estimatedPlotTime
+
+	metrics.timeoutReached = placementTimedOut
+	metrics.unfinishedQuadrantsAtTimeout = unfinishedQuadrantsAtTimeout
+
+	return &metrics
+}
+
+// Returns the root mean square difference between the darkness of both images. If a mask is given, only the white
+// pixels of the mask are compared.
+func getToneError(img, otherImg, mask *image.Gray) float64 {
+	sum := 0.0
+	comparedPixels := 0
+	for index := range img.Pix {
+		if mask != nil && mask.Pix[index] < 128 {
+			continue
+		}
+		difference := float64(img.Pix[index]) - float64(otherImg.Pix[index])
+		sum += difference * difference
+		comparedPixels++
+	}
+
+	if comparedPixels == 0 {
+		return 0
+	}
+
+	return math.Sqrt(sum / float64(comparedPixels))
+}
+
+// Returns the mean structural similarity of both images calculated in 8x8 windows with a stride of 4 pixels.
+// A value of 1 means the images are identical. If a mask is given, windows whose center is black in the mask are
+// skipped.
+func getSSIM(img, otherImg, mask *image.Gray) float64 {
+	const windowSize, stride = 8, 4
+	c1 := math.Pow(0.01*255, 2)
+	c2 := math.Pow(0.03*255, 2)
+
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	sum := 0.0
+	windows := 0
+	for y := 0; y+windowSize <= height; y += stride {
+		for x := 0; x+windowSize <= width; x += stride {
+			if mask != nil && mask.GrayAt(x+windowSize/2, y+windowSize/2).Y < 128 {
+				continue
+			}
+
+			var meanA, meanB float64
+			for dy := 0; dy < windowSize; dy++ {
+				for dx := 0; dx < windowSize; dx++ {
+					meanA += float64(img.GrayAt(x+dx, y+dy).Y)
+					meanB += float64(otherImg.GrayAt(x+dx, y+dy).Y)
+				}
+			}
+			meanA /= windowSize * windowSize
+			meanB /= windowSize * windowSize
+
+			var varianceA, varianceB, covariance float64
+			for dy := 0; dy < windowSize; dy++ {
+				for dx := 0; dx < windowSize; dx++ {
+					a := float64(img.GrayAt(x+dx, y+dy).Y) - meanA
+					b := float64(otherImg.GrayAt(x+dx, y+dy).Y) - meanB
+					varianceA += a * a
+					varianceB += b * b
+					covariance += a * b
+				}
+			}
+			varianceA /= windowSize*windowSize - 1
+			varianceB /= windowSize*windowSize - 1
+			covariance /= windowSize*windowSize - 1
+
+			sum += ((2*meanA*meanB + c1) * (2*covariance + c2)) /
+				((meanA*meanA + meanB*meanB + c1) * (varianceA + varianceB + c2))
+			windows++
+		}
+	}
+
+	if windows == 0 {
+		return 0
+	}
+
+	return sum / float64(windows)
+}
+
+// Returns the type of the shape derived from its lines. Shapes with multiple lines are groups (e.g. texts).
+func (shape *Shape) getType() string {
+	if len(shape.Lines) != 1 {
+		return "group"
+	}
+
+	switch shape.Lines[0].originalShape.(type) {
+	case *Circle:
+		return "circle"
+	case *Polygon:
+		return "polygon"
+	}
+
+	if len(shape.Lines[0].points) == 2 {
+		return "line"
+	}
+
+	return "polyline"
+}
+
+func (metrics *Metrics) toMap() map[string]any {
+	jsonData := make(map[string]any)
+
+	jsonData["toneError"] = metrics.toneError
+	jsonData["ssim"] = metrics.ssim
+	jsonData["inkLength"] = metrics.inkLength
+	jsonData["nrOfPolylines"] = metrics.nrOfPolylines
+	jsonData["travelDistance"] = metrics.travelDistance
+	jsonData["shapeTypes"] = metrics.shapeTypes
+	jsonData["timeoutReached"] = metrics.timeoutReached
+	jsonData["unfinishedQuadrantsAtTimeout"] = metrics.unfinishedQuadrantsAtTimeout
+	jsonData["plotTime"] = metrics.plotTime.Seconds()
+
+	return jsonData
+}
+
+func (metrics *Metrics) toJson() string {
+	jsonBytes, err := json.MarshalIndent(metrics.toMap(), "", "    ")
+	if err == nil {
+		return string(jsonBytes)
+	}
+
+	fmt.Printf("Error ocurred while parsing metrics to json %T\n", err)
+
+	return ""
+}
+
+func (metrics *Metrics) print() {
+	fmt.Println("\nMetrics:")
+	fmt.Printf("   Tone Error (RMS):          %.2f\n", metrics.toneError)
+	fmt.Printf("   SSIM:                      %.4f\n", metrics.ssim)
+	fmt.Printf("   Ink Length:                %.1f mm\n", metrics.inkLength)
+	fmt.Printf("   Polylines:                 %d\n", metrics.nrOfPolylines)
+	fmt.Printf("   Pen-Up Travel Distance:    %.1f mm\n", metrics.travelDistance)
+	fmt.Printf("   Estimated Plot Time:       %s\n", formatDuration(metrics.plotTime))
+
+	var shapeTypes []string
+	for shapeType := range metrics.shapeTypes {
+		shapeTypes = append(shapeTypes, shapeType)
+	}
+	sort.Strings(shapeTypes)
+	for _, shapeType := range shapeTypes {
+		fmt.Printf("   Shapes (%s):%s%d\n", shapeType, strings.Repeat(" ", max(1, 17-len(shapeType))), metrics.shapeTypes[shapeType])
+	}
+
+	if metrics.timeoutReached {
+		fmt.Printf("   Unfinished Quadrants at Timeout: %d\n", metrics.unfinishedQuadrantsAtTimeout)
+	}
+}
+
+// Prints the metrics of the finished artwork and writes them to a JSON file next to the output file, depending on
+// whether metrics and metricsFile are enabled
+func reportMetrics() {
+	if generatedMetrics == nil {
+		return
+	}
+
+	if Config.metrics {
+		generatedMetrics.print()
+	}
+
+	if Config.metricsFile {
+		writeStringToFile(generatedMetrics.toJson(), strings.TrimSuffix(Config.outputPath, filepath.Ext(Config.outputPath))+"_metrics.json")
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func newUniformGray(width, height int, value uint8) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for index := range img.Pix {
+		img.Pix[index] = value
+	}
+
+	return img
+}
+
+func TestToneError(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 2, 2))
+	otherImg := image.NewGray(image.Rect(0, 0, 2, 2))
+	copy(img.Pix, []uint8{0, 10, 20, 30})
+	copy(otherImg.Pix, []uint8{2, 8, 20, 30})
+
+	if toneError := getToneError(img, img, nil); toneError != 0 {
+		t.Errorf("Identical images have no tone error, got %v", toneError)
+	}
+	if toneError := getToneError(img, otherImg, nil); math.Abs(toneError-math.Sqrt2) > 1e-12 {
+		t.Errorf("Expected the tone error %v, got %v", math.Sqrt2, toneError)
+	}
+	if toneError := getToneError(newUniformGray(4, 4, 0), newUniformGray(4, 4, 255), nil); toneError != 255 {
+		t.Errorf("Expected the tone error 255 between black and white, got %v", toneError)
+	}
+	// Only the white pixels of the mask are compared
+	mask := image.NewGray(image.Rect(0, 0, 2, 2))
+	copy(mask.Pix, []uint8{255, 0, 0, 0})
+	if toneError := getToneError(img, otherImg, mask); toneError != 2 {
+		t.Errorf("Expected the tone error 2 with the mask, got %v", toneError)
+	}
+}
+
+func TestSSIM(t *testing.T) {
+	checkerboard := image.NewGray(image.Rect(0, 0, 8, 8))
+	inverted := image.NewGray(image.Rect(0, 0, 8, 8))
+	for y := range 8 {
+		for x := range 8 {
+			if (x+y)%2 == 0 {
+				checkerboard.Pix[y*8+x] = 255
+			} else {
+				inverted.Pix[y*8+x] = 255
+			}
+		}
+	}
+
+	c1 := math.Pow(0.01*255, 2)
+	c2 := math.Pow(0.03*255, 2)
+	variance := 127.5 * 127.5 * 64 / 63
+
+	testCases := []struct {
+		name          string
+		img, otherImg *image.Gray
+		mask          *image.Gray
+		expected      float64
+	}{
+		{"identical", checkerboard, checkerboard, nil, 1},
+		// Only the luminance differs
+		{"black and white", newUniformGray(8, 8, 0), newUniformGray(8, 8, 255), nil, c1 / (255*255 + c1)},
+		// Same luminance but the structure is inverted
+		{"inverted", checkerboard, inverted, nil, (c2 - 2*variance) / (c2 + 2*variance)},
+		// 12x12 pixels contain 4 windows with a stride of 4 pixels
+		{"windows", newUniformGray(12, 12, 100), newUniformGray(12, 12, 100), nil, 1},
+		{"too small", newUniformGray(7, 7, 0), newUniformGray(7, 7, 0), nil, 0},
+		// Windows whose center is outside of the mask are skipped
+		{"masked", checkerboard, inverted, newUniformGray(8, 8, 0), 0},
+	}
+	for _, testCase := range testCases {
+		if ssim := getSSIM(testCase.img, testCase.otherImg, testCase.mask); math.Abs(ssim-testCase.expected) > 1e-12 {
+			t.Errorf("%s: expected the SSIM %v, got %v", testCase.name, testCase.expected, ssim)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"math"
+	"slices"
+)
+
+const (
+	JoinRound = "round"
+	JoinMiter = "miter"
+	JoinBevel = "bevel"
+
+	CapRound  = "round"
+	CapSquare = "square"
+	CapButt   = "butt"
+)
+
+// Like in SVG miter joins are replaced by bevel joins if the miter is longer than 4 times the stroke width
+const offsetMiterLimit = 4
+
+// The number of line segments of a full circle for round joins and caps
+const offsetArcSegments = 36
+
+// Surrounds the lines of all shapes (in mm) with the outlines of their stroke. Runs before hatchShapes, so the hatch
+// lines are drawn with the pen only.
+func offsetShapes() {
+	for quadrantIndex := range quadrants {
+		for shapeIndex := range quadrants[quadrantIndex].Shapes {
+			shape := &quadrants[quadrantIndex].Shapes[shapeIndex]
+			_, strokeWidth := shape.getStroke()
+			shape.Lines = getOffsetLines(shape.Lines, PixelToMM(strokeWidth, Config.outputDpi), Config.penWidth)
+		}
+	}
+}
+
+// Simulates a stroke that is wider than the pen. Every line is kept and surrounded by outlines of the stroke with
+// increasing width, which are at most one pen width apart. The outermost outline touches the border of the stroke.
+func getOffsetLines(lines []Polyline, strokeWidth, penWidth float64) []Polyline {
+	maxDistance := (strokeWidth - penWidth) / 2
+	if maxDistance <= 0 {
+		return lines
+	}
+	passes := int(math.Ceil(maxDistance / penWidth))
+
+	var offsetLines []Polyline
+	for index := range lines {
+		offsetLines = append(offsetLines, lines[index])
+		for pass := 1; pass <= passes; pass++ {
+			offsetLines = append(offsetLines, getStrokeOutline(&lines[index], maxDistance*float64(pass)/float64(passes))...)
+		}
+	}
+
+	return offsetLines
+}
+
+// Returns the outline of the stroke of the line with the given distance to the line. Open lines get a single outline
+// with caps at both ends, closed lines get an outer and an inner outline. Inner outlines of shapes that are too small
+// for the distance are omitted.
+func getStrokeOutline(line *Polyline, distance float64) []Polyline {
+	if circle, ok := line.originalShape.(*Circle); ok {
+		outlines := []Polyline{*NewCircle(circle.center, circle.radius+distance).toPolyline(6)}
+		if circle.radius > distance {
+			outlines = append(outlines, *NewCircle(circle.center, circle.radius-distance).toPolyline(6))
+		}
+		return outlines
+	}
+
+	var points []Point
+	for _, point := range line.points {
+		if len(points) == 0 || !points[len(points)-1].equalTo(&point, 6) {
+			points = append(points, point)
+		}
+	}
+
+	if len(points) == 1 {
+		if Config.offsetCap != CapRound {
+			return nil
+		}
+		return []Polyline{*NewCircle(points[0], distance).toPolyline(6)}
+	}
+
+	if line.isClosed() {
+		if points[0].equalTo(&points[len(points)-1], 6) {
+			points = points[:len(points)-1]
+		}
+		if len(points) < 3 {
+			return nil
+		}
+
+		reversed := slices.Clone(points)
+		slices.Reverse(reversed)
+
+		var outlines []Polyline
+		for _, ring := range [][]Point{points, reversed} {
+			outline := getOffsetPoints(ring, distance, true)
+			// The inner outline is turned inside out if the shape is too small and then comes closer to the ring
+			if getRingDistance(outline, ring) > distance*(1-1e-6) {
+				outlines = append(outlines, Polyline{append(outline, outline[0]), nil})
+			}
+		}
+		return outlines
+	}
+
+	reversed := slices.Clone(points)
+	slices.Reverse(reversed)
+
+	outline := getOffsetPoints(points, distance, false)
+	outline = append(outline, getCapPoints(&points[len(points)-2], &points[len(points)-1], distance)...)
+	outline = append(outline, getOffsetPoints(reversed, distance, false)...)
+	outline = append(outline, getCapPoints(&reversed[len(reversed)-2], &reversed[len(reversed)-1], distance)...)
+	outline = append(outline, outline[0])
+
+	return []Polyline{{outline, nil}}
+}
+
+// Returns the points that are offset by the distance to the left of the line in the direction of the line. The
+// corners are connected with the configured join.
+func getOffsetPoints(points []Point, distance float64, closed bool) []Point {
+	segments := len(points) - 1
+	if closed {
+		segments = len(points)
+	}
+
+	normals := make([]Point, segments)
+	for index := range segments {
+		start, end := &points[index], &points[(index+1)%len(points)]
+		length := start.distanceTo(end)
+		normals[index] = Point{-(end.Y - start.Y) / length * distance, (end.X - start.X) / length * distance}
+	}
+
+	var offsetPoints []Point
+	if !closed {
+		offsetPoints = append(offsetPoints, Point{points[0].X + normals[0].X, points[0].Y + normals[0].Y})
+	}
+	for index := range points {
+		if !closed && (index == 0 || index == len(points)-1) {
+			continue
+		}
+
+		previous := &points[(index-1+len(points))%len(points)]
+		next := &points[(index+1)%len(points)]
+		offsetPoints = append(offsetPoints, getJoinPoints(previous, &points[index], next, normals[(index-1+segments)%segments], normals[index%segments])...)
+	}
+	if !closed {
+		last := &points[len(points)-1]
+		offsetPoints = append(offsetPoints, Point{last.X + normals[segments-1].X, last.Y + normals[segments-1].Y})
+	}
+
+	return offsetPoints
+}
+
+// Returns the points that connect the offset segments before and after the vertex. On the inner side of the corner
+// the segments are cut at their intersection, on the outer side the configured join is used.
+func getJoinPoints(previous, vertex, next *Point, previousNormal, nextNormal Point) []Point {
+	end := Point{vertex.X + previousNormal.X, vertex.Y + previousNormal.Y}
+	start := Point{vertex.X + nextNormal.X, vertex.Y + nextNormal.Y}
+
+	cross := (vertex.X-previous.X)*(next.Y-vertex.Y) - (vertex.Y-previous.Y)*(next.X-vertex.X)
+	dot := (vertex.X-previous.X)*(next.X-vertex.X) + (vertex.Y-previous.Y)*(next.Y-vertex.Y)
+	turn := math.Atan2(cross, dot)
+
+	if math.Abs(turn) < 1e-9 {
+		return []Point{end}
+	}
+
+	// The normals point to the left, so the offset is on the inner side of left turns. Reversals are outer corners.
+	if turn > 0 && turn < math.Pi-1e-9 {
+		previousStart := Point{previous.X + previousNormal.X, previous.Y + previousNormal.Y}
+		nextEnd := Point{next.X + nextNormal.X, next.Y + nextNormal.Y}
+		if intersection, ok := calculateLineIntersection(&previousStart, &end, &start, &nextEnd); ok {
+			return []Point{intersection}
+		}
+		return []Point{end, *vertex, start}
+	}
+	if turn > 0 {
+		turn = -math.Pi
+	}
+
+	switch Config.offsetJoin {
+	case JoinRound:
+		return getRoundPoints(*vertex, end, turn)
+	case JoinMiter:
+		if 1/math.Cos(turn/2) <= offsetMiterLimit {
+			bisector := Point{previousNormal.X + nextNormal.X, previousNormal.Y + nextNormal.Y}
+			scale := previousNormal.distanceTo(&Point{0, 0}) / math.Cos(turn/2) / bisector.distanceTo(&Point{0, 0})
+			return []Point{{vertex.X + bisector.X*scale, vertex.Y + bisector.Y*scale}}
+		}
+	}
+
+	return []Point{end, start}
+}
+
+// Returns the points of the cap at the end of the segment previous---end, without the points on the offset lines
+func getCapPoints(previous, end *Point, distance float64) []Point {
+	length := previous.distanceTo(end)
+	direction := Point{(end.X - previous.X) / length * distance, (end.Y - previous.Y) / length * distance}
+	normal := Point{-direction.Y, direction.X}
+
+	switch Config.offsetCap {
+	case CapRound:
+		points := getRoundPoints(*end, Point{end.X + normal.X, end.Y + normal.Y}, -math.Pi)
+		return points[1 : len(points)-1]
+	case CapSquare:
+		return []Point{
+			{end.X + normal.X + direction.X, end.Y + normal.Y + direction.Y},
+			{end.X - normal.X + direction.X, end.Y - normal.Y + direction.Y},
+		}
+	}
+
+	return nil
+}
+
+// Returns the points of the arc that starts at the point and turns around the center by the angle (in radians)
+func getRoundPoints(center, start Point, angle float64) []Point {
+	segments := max(int(math.Ceil(math.Abs(angle)/(2*math.Pi)*offsetArcSegments)), 1)
+
+	points := []Point{start}
+	for index := 1; index <= segments; index++ {
+		points = append(points, *start.rotateCopy(angle*float64(index)/float64(segments)*180/math.Pi, center))
+	}
+
+	return points
+}
+
+// Returns the smallest distance between the points and the implicitly closed ring
+func getRingDistance(points, ring []Point) float64 {
+	distance := math.Inf(1)
+	for index := range points {
+		for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+			distance = math.Min(distance, distanceToSegment(&points[index], &ring[j], &ring[i]))
+		}
+	}
+
+	return distance
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestOffsetStrokes(t *testing.T) {
+	resetStaticVariables()
+
+	config := NewConfig()
+	if config.fromJSON(`{"offsetJoin": "sharp"}`) || config.fromJSON(`{"penWidth": 0}`) {
+		t.Error("Invalid offset parameters have to be rejected")
+	}
+
+	// With round joins and caps every point of the outline has the same distance to the line, also at corners on
+	// both sides
+	line := Polyline{[]Point{{0, 0}, {10, 0}, {10, 10}, {2, 10}, {2, 20}}, nil}
+	offsetLines := getOffsetLines([]Polyline{line}, 2, 0.5)
+	if len(offsetLines) != 3 {
+		t.Fatalf("Expected the line and two outlines but got %d lines", len(offsetLines))
+	}
+	for _, outline := range offsetLines[1:] {
+		distance := math.Inf(1)
+		for _, point := range outline.points {
+			pointDistance := math.Inf(1)
+			for index := 1; index < len(line.points); index++ {
+				pointDistance = math.Min(pointDistance, distanceToSegment(&point, &line.points[index-1], &line.points[index]))
+			}
+			if distance != math.Inf(1) && math.Abs(pointDistance-distance) > 1e-9 {
+				t.Fatalf("The outline has to have a constant distance to the line! %v != %v", pointDistance, distance)
+			}
+			distance = pointDistance
+		}
+		if !outline.isClosed() {
+			t.Error("The outline of an open line has to be closed")
+		}
+	}
+
+	// Closed lines get an outer and an inner outline, miter joins keep the corners
+	Config.offsetJoin = JoinMiter
+	square := NewPolygon(&[]Point{{0, 0}, {10, 0}, {10, 10}, {0, 10}}).toPolyline()
+	outlines := getStrokeOutline(square, 1)
+	containsPoint := func(lines []Polyline, point Point) bool {
+		return slices.ContainsFunc(lines, func(line Polyline) bool {
+			return len(line.points) == 5 && slices.ContainsFunc(line.points, func(linePoint Point) bool { return linePoint.equalTo(&point, 6) })
+		})
+	}
+	if len(outlines) != 2 || !containsPoint(outlines, Point{-1, -1}) || !containsPoint(outlines, Point{1, 1}) {
+		t.Errorf("Expected a 12x12 and an 8x8 square but got %v", outlines)
+	}
+	if outlines := getStrokeOutline(square, 6); len(outlines) != 1 {
+		t.Error("Inner outlines that are turned inside out have to be omitted")
+	}
+
+	Config.offsetCap = CapSquare
+	outlines = getStrokeOutline(&Polyline{[]Point{{0, 0}, {10, 0}}, nil}, 1)
+	if len(outlines) != 1 || !slices.Equal(outlines[0].points, []Point{{0, 1}, {10, 1}, {11, 1}, {11, -1}, {10, -1}, {0, -1}, {-1, -1}, {-1, 1}, {0, 1}}) {
+		t.Errorf("Square caps have to extend the line by the distance! %v", outlines)
+	}
+
+	// The lines are drawn with the width of the pen, the stroke width of the region is kept for the offset and the DXF layers
+	Config.offsetStrokes = true
+	Config.penWidth = 0.5
+	shape := NewShape([]Polyline{line})
+	shape.region = &Region{strokeColor: "red", strokeWidth: 3}
+	if _, strokeWidth := shape.getStroke(); strokeWidth != 3 {
+		t.Errorf("The stroke width of the region has to be kept, got %v", strokeWidth)
+	}
+	if svg := generateSVG(10, 10, []*Shape{shape}); !strings.Contains(svg, "stroke-width: "+strconv.FormatFloat(mmToPixel(0.5, Config.outputDpi), 'f', 2, 64)+"px") {
+		t.Errorf("Offset strokes have to be drawn with the width of the pen! %s", svg)
+	}
+	if _, strokeWidth := shape.getDrawnStroke(); strokeWidth != mmToPixel(0.5, Config.outputDpi) {
+		t.Errorf("Offset strokes have to be drawn with the width of the pen, got %v", strokeWidth)
+	}
+	Config.laserLayers = true
+	if svg := generateSVG(10, 10, []*Shape{shape}); !strings.Contains(svg, "stroke-width: "+strconv.FormatFloat(mmToPixel(0.5, Config.outputDpi), 'f', 2, 64)+"px") {
+		t.Errorf("The laser layers have to use the width of the pen! %s", svg)
+	}
+	resetStaticVariables()
+}
+
+func TestOffsetShapes(t *testing.T) {
+	resetStaticVariables()
+	Config.penWidth = 0.5
+	Config.hatchSpacing = 1
+
+	// A stroke of 2mm needs two outlines on each side of the square with a pen of 0.5mm
+	square := NewPolygon(&[]Point{{0, 0}, {10, 0}, {10, 10}, {0, 10}}).toShape()
+	square.region = &Region{strokeWidth: mmToPixel(2, Config.outputDpi)}
+	quadrants = []*Quadrant{{Shapes: []Shape{*square}}}
+
+	offsetShapes()
+	if lines := len(quadrants[0].Shapes[0].Lines); lines != 5 {
+		t.Fatalf("Expected the square and four outlines but got %d lines", lines)
+	}
+
+	// The hatch lines are added after the offset and are not offset themselves
+	hatchShapes()
+	for _, line := range quadrants[0].Shapes[0].Lines[5:] {
+		if len(line.points) != 2 {
+			t.Fatalf("Expected only hatch lines after the outlines but got %v", line.points)
+		}
+	}
+	resetStaticVariables()
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/disintegration/gift"
+)
+
+const (
+	OrientationPortrait  = "portrait"
+	OrientationLandscape = "landscape"
+
+	FitContain = "contain"
+	FitCover   = "cover"
+	FitStretch = "stretch"
+)
+
+// The width and height in millimetres of the paper presets in portrait orientation
+var paperSizes = map[string][2]float64{
+	"a0":      {841, 1189},
+	"a1":      {594, 841},
+	"a2":      {420, 594},
+	"a3":      {297, 420},
+	"a4":      {210, 297},
+	"a5":      {148, 210},
+	"a6":      {105, 148},
+	"b3":      {353, 500},
+	"b4":      {250, 353},
+	"b5":      {176, 250},
+	"letter":  {215.9, 279.4},
+	"legal":   {215.9, 355.6},
+	"tabloid": {279.4, 431.8},
+}
+
+// The sheet the artwork is placed on. All values are in millimetres.
+type PageLayout struct {
+	width   float64
+	height  float64
+	offsetX float64
+	offsetY float64
+}
+
+// The layout of the current artwork. Nil if no paper is configured, in which case the canvas has the size of the
+// artwork.
+var pageLayout *PageLayout
+
+// Returns the size of the configured paper in millimetres. The paper is either the name of a preset (e.g. 'A3' or
+// 'letter'), which is turned into the configured orientation, or a custom size like '300mm x 400mm', which is used as
+// given.
+func (config *VecartConfig) getPaperSize() (float64, float64, bool) {
+	name := strings.ToLower(strings.TrimSpace(config.paper))
+	size, ok := paperSizes[name]
+	if !ok {
+		widthString, heightString, found := strings.Cut(name, "x")
+		if !found {
+			return 0, 0, false
+		}
+
+		width, err := convertLength(strings.TrimSpace(widthString), "mm", config.outputDpi)
+		if err != nil {
+			return 0, 0, false
+		}
+		height, err := convertLength(strings.TrimSpace(heightString), "mm", config.outputDpi)
+		if err != nil {
+			return 0, 0, false
+		}
+		return width, height, true
+	}
+
+	width, height := size[0], size[1]
+	if (config.orientation == OrientationLandscape && width < height) || (config.orientation == OrientationPortrait && width > height) {
+		width, height = height, width
+	}
+
+	return width, height, true
+}
+
+// Reads the margins. Like in CSS the margin is either a single length for all sides or an array with the lengths for
+// [vertical, horizontal] or [top, right, bottom, left].
+func getMargins(jsonData map[string]any, key string, margins *[4]float64, dpi float64) {
+	value, ok := jsonData[key]
+	if !ok {
+		return
+	}
+
+	values, isArray := value.([]any)
+	if !isArray {
+		values = []any{value}
+	}
+
+	var lengths []float64
+	for _, value := range values {
+		length, err := convertLength(value, "mm", dpi)
+		if err != nil {
+			errorsOcurred = true
+			errors = append(errors, "Unexpected value for '"+key+"': "+err.Error())
+			return
+		}
+		lengths = append(lengths, length)
+	}
+
+	switch len(lengths) {
+	case 1:
+		*margins = [4]float64{lengths[0], lengths[0], lengths[0], lengths[0]}
+	case 2:
+		*margins = [4]float64{lengths[0], lengths[1], lengths[0], lengths[1]}
+	case 4:
+		*margins = [4]float64{lengths[0], lengths[1], lengths[2], lengths[3]}
+	default:
+		errorsOcurred = true
+		errors = append(errors, "Invalid value for '"+key+"' | expected a length or an array of 2 or 4 lengths")
+	}
+}
+
+// Returns the margins as single value if they are equal on all sides
+func (config *VecartConfig) getMarginValue() any {
+	if config.margins[0] == config.margins[1] && config.margins[0] == config.margins[2] && config.margins[0] == config.margins[3] {
+		return config.margins[0]
+	}
+
+	return config.margins[:]
+}
+
+func (config *VecartConfig) validatePaper() bool {
+	valid := true
+
+	for _, margin := range config.margins {
+		if margin < 0 {
+			valid = false
+			errors = append(errors, "A margin below 0 is invalid!")
+			break
+		}
+	}
+
+	if config.paper == "" {
+		return valid
+	}
+
+	width, height, ok := config.getPaperSize()
+	if !ok {
+		valid = false
+		errors = append(errors, "Unknown paper '"+config.paper+"'! Use a preset like 'A4' or 'letter' or a size like '300mm x 400mm'.")
+		return valid
+	}
+
+	if width-config.margins[1]-config.margins[3] <= 0 || height-config.margins[0]-config.margins[2] <= 0 {
+		valid = false
+		errors = append(errors, "The margins leave no printable area on the paper!")
+	}
+
+	return valid
+}
+
+// Resizes the image so that it fits into the printable area of the paper and sets the page layout. With 'contain' the
+// whole image is visible and centered, with 'cover' the image fills the printable area and is cropped and with
+// 'stretch' the image is distorted to the size of the printable area. The size is rounded down to whole quadrants.
+func fitImageToPage(img image.Image) image.Image {
+	paperWidth, paperHeight, _ := Config.getPaperSize()
+	top, right, bottom, left := Config.margins[0], Config.margins[1], Config.margins[2], Config.margins[3]
+	areaWidth := paperWidth - left - right
+	areaHeight := paperHeight - top - bottom
+
+	imageWidth := float64(img.Bounds().Dx())
+	imageHeight := float64(img.Bounds().Dy())
+
+	width, height := areaWidth, areaHeight
+	if Config.fit == FitContain {
+		scale := math.Min(areaWidth/imageWidth, areaHeight/imageHeight)
+		width, height = imageWidth*scale, imageHeight*scale
+	}
+
+	widthPixel := int(mmToPixel(width, Config.processingDpi))
+	heightPixel := int(mmToPixel(height, Config.processingDpi))
+	widthPixel = max(widthPixel-widthPixel%Config.quadrantWidth, Config.quadrantWidth)
+	heightPixel = max(heightPixel-heightPixel%Config.quadrantHeight, Config.quadrantHeight)
+
+	if Config.fit == FitCover {
+		scale := math.Max(float64(widthPixel)/imageWidth, float64(heightPixel)/imageHeight)
+		img = resizeImage(img, int(math.Ceil(imageWidth*scale)), int(math.Ceil(imageHeight*scale)))
+		img = cropImage(img, widthPixel, heightPixel)
+	} else {
+		img = resizeImage(img, widthPixel, heightPixel)
+	}
+
+	artworkWidth := PixelToMM(float64(widthPixel), Config.processingDpi)
+	artworkHeight := PixelToMM(float64(heightPixel), Config.processingDpi)
+	pageLayout = &PageLayout{
+		width:   paperWidth,
+		height:  paperHeight,
+		offsetX: left + (areaWidth-artworkWidth)/2,
+		offsetY: top + (areaHeight-artworkHeight)/2,
+	}
+
+	return img
+}
+
+// Returns the size of the sheet and the position of the artwork on the sheet in millimetres. Without a paper the
+// sheet has the size of the artwork (in processing pixels).
+func getSheet(artworkWidth, artworkHeight int) (float64, float64, float64, float64) {
+	if pageLayout != nil {
+		return pageLayout.width, pageLayout.height, pageLayout.offsetX, pageLayout.offsetY
+	}
+
+	return PixelToMM(float64(artworkWidth), Config.processingDpi), PixelToMM(float64(artworkHeight), Config.processingDpi), 0, 0
+}
+
+func cropImage(img image.Image, width, height int) image.Image {
+	g := gift.New(gift.CropToSize(width, height, gift.CenterAnchor))
+	dst := image.NewNRGBA(g.Bounds(img.Bounds()))
+	g.Draw(dst, img)
+
+	return dst
+}
+
+// Returns the attributes of the svg element and the transformation that moves the artwork onto the sheet
+func (layout *PageLayout) getSVGAttributes() (string, string) {
+	width := strconv.FormatFloat(mmToPixel(layout.width, Config.outputDpi), 'f', 2, 64)
+	height := strconv.FormatFloat(mmToPixel(layout.height, Config.outputDpi), 'f', 2, 64)
+	offsetX := strconv.FormatFloat(mmToPixel(layout.offsetX, Config.outputDpi), 'f', 2, 64)
+	offsetY := strconv.FormatFloat(mmToPixel(layout.offsetY, Config.outputDpi), 'f', 2, 64)
+
+	attributes := fmt.Sprintf("width=\"%smm\" height=\"%smm\" viewBox=\"0 0 %s %s\"", strconv.FormatFloat(layout.width, 'f', -1, 64),
+		strconv.FormatFloat(layout.height, 'f', -1, 64), width, height)
+
+	return attributes, "translate(" + offsetX + " " + offsetY + ")"
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// PDF and PostScript use points (1/72 inch) as unit
+const pointsPerInch = 72
+
+// The distance of the control points of the bezier curves that approximate a quarter circle, relative to the radius
+const circleBezierFactor = 0.5522847498
+
+// A command of a path. The operator is 'M' (move), 'L' (line), 'C' (cubic bezier curve) or 'Z' (close path).
+type PathCommand struct {
+	operator byte
+	points   []Point
+}
+
+// A line of the artwork in points with the origin in the bottom left corner of the page
+type VectorPath struct {
+	strokeColor color.RGBA
+	strokeWidth float64
+	fill        bool
+	commands    []PathCommand
+}
+
+// Converts the shapes (in pixels of the outputDpi) into paths for PDF and PostScript files. Like in the SVG every line
+// uses the stroke of its shape and closed lines are filled if fillClosedShapes is enabled. Returns the size of the
+// page in points and the paths.
+func getVectorPaths(artworkWidth, artworkHeight int, shapes []*Shape) (float64, float64, []VectorPath) {
+	width, height, offsetX, offsetY := getSheet(artworkWidth, artworkHeight)
+	toPoints := func(point Point) Point {
+		return Point{
+			mmToPixel(PixelToMM(point.X, Config.outputDpi)+offsetX, pointsPerInch),
+			mmToPixel(height-PixelToMM(point.Y, Config.outputDpi)-offsetY, pointsPerInch),
+		}
+	}
+
+	var paths []VectorPath
+	for _, shape := range shapes {
+		strokeColor, strokeWidth := shape.getDrawnStroke()
+		for lineIndex := range shape.Lines {
+			line := &shape.Lines[lineIndex]
+			path := VectorPath{
+				strokeColor: parseColor(strokeColor),
+				strokeWidth: mmToPixel(PixelToMM(strokeWidth, Config.outputDpi), pointsPerInch),
+				fill:        Config.fillClosedShapes && line.isClosed(),
+			}
+
+			if circle, ok := line.originalShape.(*Circle); ok {
+				path.commands = getCirclePathCommands(toPoints(circle.center), mmToPixel(PixelToMM(circle.radius, Config.outputDpi), pointsPerInch))
+				paths = append(paths, path)
+				continue
+			}
+
+			points := line.points
+			closed := line.isClosed()
+			if polygon, ok := line.originalShape.(*Polygon); ok {
+				points = polygon.points
+			} else if closed {
+				points = points[:len(points)-1]
+			}
+
+			for index := range points {
+				operator := byte('L')
+				if index == 0 {
+					operator = 'M'
+				}
+				path.commands = append(path.commands, PathCommand{operator, []Point{toPoints(points[index])}})
+			}
+			if closed {
+				path.commands = append(path.commands, PathCommand{'Z', nil})
+			}
+			paths = append(paths, path)
+		}
+	}
+
+	return mmToPixel(width, pointsPerInch), mmToPixel(height, pointsPerInch), paths
+}
+
+// Approximates the circle with four cubic bezier curves
+func getCirclePathCommands(center Point, radius float64) []PathCommand {
+	control := radius * circleBezierFactor
+	commands := []PathCommand{{'M', []Point{{center.X + radius, center.Y}}}}
+	for quarter := range 4 {
+		angle := float64(quarter) * math.Pi / 2
+		cos, sin := math.Round(math.Cos(angle)), math.Round(math.Sin(angle))
+		nextCos, nextSin := -sin, cos
+		commands = append(commands, PathCommand{'C', []Point{
+			{center.X + radius*cos - control*sin, center.Y + radius*sin + control*cos},
+			{center.X + radius*nextCos + control*nextSin, center.Y + radius*nextSin - control*nextCos},
+			{center.X + radius*nextCos, center.Y + radius*nextSin},
+		}})
+	}
+
+	return append(commands, PathCommand{'Z', nil})
+}
+
+func formatPoints(value float64) string {
+	return strconv.FormatFloat(value, 'f', 3, 64)
+}
+
+func formatColor(rgba color.RGBA) string {
+	return formatPoints(float64(rgba.R)/255) + " " + formatPoints(float64(rgba.G)/255) + " " + formatPoints(float64(rgba.B)/255)
+}
+
+// Returns the drawing operators of the paths. The operators are the ones of PDF content streams, PostScript uses
+// procedures with the same names that are defined in the prolog.
+func getPathOperators(paths []VectorPath) []string {
+	operators := map[byte]string{'M': "m", 'L': "l", 'C': "c", 'Z': "h"}
+
+	var lines []string
+	var currentColor *color.RGBA
+	currentWidth := -1.0
+	for index := range paths {
+		path := &paths[index]
+		if currentColor == nil || *currentColor != path.strokeColor {
+			currentColor = &path.strokeColor
+			lines = append(lines, formatColor(path.strokeColor)+" RG "+formatColor(path.strokeColor)+" rg")
+		}
+		if currentWidth != path.strokeWidth {
+			currentWidth = path.strokeWidth
+			lines = append(lines, formatPoints(path.strokeWidth)+" w")
+		}
+
+		var parts []string
+		for _, command := range path.commands {
+			for _, point := range command.points {
+				parts = append(parts, formatPoints(point.X), formatPoints(point.Y))
+			}
+			parts = append(parts, operators[command.operator])
+		}
+		if path.fill {
+			parts = append(parts, "B")
+		} else {
+			parts = append(parts, "S")
+		}
+		lines = append(lines, strings.Join(parts, " "))
+	}
+
+	return lines
+}
+
+// Returns the lines of the comment with the Vecart version and the config, each starting with '%'
+func getPostScriptComment() []string {
+	lines := []string{"% Generated by Vecart v. " + Version, "% https://github.com/DavidJilg/Vecart"}
+	if Config.configInOutput {
+		for _, configLine := range strings.Split(UserConfig, "\n") {
+			lines = append(lines, "% "+strings.TrimRight(configLine, "\r"))
+		}
+	}
+
+	return lines
+}
+
+// Generates a single page PDF with the shapes (in pixels of the outputDpi) in their physical size. The page has the
+// size of the paper or of the artwork if no paper is configured.
+func generatePDF(artworkWidth, artworkHeight int, shapes []*Shape) string {
+	width, height, paths := getVectorPaths(artworkWidth, artworkHeight, shapes)
+
+	var content bytes.Buffer
+	writer := zlib.NewWriter(&content)
+	writer.Write([]byte(strings.Join(getPathOperators(paths), "\n")))
+	writer.Close()
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 " + formatPoints(width) + " " + formatPoints(height) + "] /Contents 4 0 R /Resources << >> >>",
+		"<< /Length " + strconv.Itoa(content.Len()) + " /Filter /FlateDecode >>\nstream\n" + content.String() + "\nendstream",
+		"<< /Producer (Vecart v. " + Version + ") >>",
+	}
+
+	var pdf strings.Builder
+	// The comment with bytes above 127 marks the file as binary, since the content stream is compressed
+	pdf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	pdf.WriteString(strings.Join(getPostScriptComment(), "\n") + "\n")
+
+	var offsets []int
+	for index, object := range objects {
+		offsets = append(offsets, pdf.Len())
+		pdf.WriteString(fmt.Sprintf("%d 0 obj\n%s\nendobj\n", index+1, object))
+	}
+
+	xrefOffset := pdf.Len()
+	pdf.WriteString(fmt.Sprintf("xref\n0 %d\n0000000000 65535 f \n", len(objects)+1))
+	for _, offset := range offsets {
+		pdf.WriteString(fmt.Sprintf("%010d 00000 n \n", offset))
+	}
+	pdf.WriteString(fmt.Sprintf("trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, len(objects), xrefOffset))
+
+	return pdf.String()
+}
+
+// Generates an Encapsulated PostScript file or, if page is true, a PostScript document with a single page of the size
+// of the paper
+func generatePostScript(artworkWidth, artworkHeight int, shapes []*Shape, page bool) string {
+	width, height, paths := getVectorPaths(artworkWidth, artworkHeight, shapes)
+
+	var lines []string
+	if page {
+		lines = append(lines, "%!PS-Adobe-3.0")
+	} else {
+		lines = append(lines, "%!PS-Adobe-3.0 EPSF-3.0")
+	}
+	lines = append(lines,
+		"%%BoundingBox: 0 0 "+strconv.Itoa(int(math.Ceil(width)))+" "+strconv.Itoa(int(math.Ceil(height))),
+		"%%HiResBoundingBox: 0 0 "+formatPoints(width)+" "+formatPoints(height),
+		"%%Creator: Vecart v. "+Version,
+		"%%Pages: 1",
+		"%%EndComments",
+	)
+	lines = append(lines, getPostScriptComment()...)
+
+	lines = append(lines,
+		"%%BeginProlog",
+		"/m { moveto } bind def",
+		"/l { lineto } bind def",
+		"/c { curveto } bind def",
+		"/h { closepath } bind def",
+		"/S { stroke } bind def",
+		"/B { gsave fill grestore stroke } bind def",
+		"/w { setlinewidth } bind def",
+		"/RG { setrgbcolor } bind def",
+		"/rg { pop pop pop } bind def",
+		"%%EndProlog",
+		"%%Page: 1 1",
+	)
+	if page {
+		lines = append(lines, "<< /PageSize ["+formatPoints(width)+" "+formatPoints(height)+"] >> setpagedevice")
+	}
+	lines = append(lines, getPathOperators(paths)...)
+	lines = append(lines, "showpage", "%%EOF")
+
+	return strings.Join(lines, "\n") + "\n"
+}
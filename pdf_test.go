@@ -0,0 +1,77 @@
+package main
+
+import (
+	"compress/zlib"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPDFAndPostScriptExport(t *testing.T) {
+	resetStaticVariables()
+	Config.fromJSON(`{"processingDpi": 25.4, "outputDpi": 25.4, "configInOutput": false, "strokeColor": "red", "strokeWidth": 0.5, "fillClosedShapes": true}`)
+
+	shapes := []*Shape{
+		NewCircle(Point{5, 5}, 2).toShape(),
+		NewPolygon(&[]Point{{0, 0}, {10, 0}, {10, 10}}).toShape(),
+		NewShape([]Polyline{{[]Point{{0, 0}, {2.54, 2.54}}, nil}}),
+	}
+
+	width, height, paths := getVectorPaths(10, 20, shapes)
+	if math.Abs(width-mmToPixel(10, 72)) > 1e-9 || math.Abs(height-mmToPixel(20, 72)) > 1e-9 || len(paths) != 3 {
+		t.Fatalf("Unexpected page size %vpt x %vpt or number of paths %d", width, height, len(paths))
+	}
+	// The bezier curves have to end on the circle and the y axis has to point upwards
+	for _, command := range paths[0].commands[1:5] {
+		end := command.points[2]
+		if math.Abs(end.distanceTo(&Point{mmToPixel(5, 72), mmToPixel(15, 72)})-mmToPixel(2, 72)) > 1e-9 {
+			t.Errorf("The circle is not approximated correctly! %v", paths[0].commands)
+		}
+	}
+	if !paths[1].fill || paths[2].fill || !paths[2].commands[1].points[0].equalTo(&Point{7.2, mmToPixel(20, 72) - 7.2}, 6) {
+		t.Errorf("Unexpected paths %v", paths[1:])
+	}
+
+	pdf := generatePDF(10, 20, shapes)
+	if !strings.HasPrefix(pdf, "%PDF-1.4\n") || !strings.HasSuffix(pdf, "%%EOF\n") {
+		t.Fatal("Invalid PDF header or trailer")
+	}
+	startxref, _ := strconv.Atoi(strings.Fields(pdf[strings.LastIndex(pdf, "startxref"):])[1])
+	xref := strings.Split(pdf[startxref:], "\n")
+	for object := 1; object <= 5; object++ {
+		offset, _ := strconv.Atoi(strings.Fields(xref[2+object])[0])
+		if !strings.HasPrefix(pdf[offset:], strconv.Itoa(object)+" 0 obj\n") {
+			t.Errorf("The offset of object %d in the xref table is wrong", object)
+		}
+	}
+	streamStart := strings.Index(pdf, "stream\n") + len("stream\n")
+	reader, err := zlib.NewReader(strings.NewReader(pdf[streamStart:strings.Index(pdf, "\nendstream")]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := io.ReadAll(reader)
+	if !strings.HasPrefix(string(content), "1.000 0.000 0.000 RG 1.000 0.000 0.000 rg\n1.417 w\n") ||
+		!strings.Contains(string(content), " h B\n") || !strings.HasSuffix(string(content), " l S") {
+		t.Errorf("Unexpected content stream:\n%s", content)
+	}
+
+	Config.outputPath = "out.eps"
+	eps := generateOutput(10, 20, shapes, nil)
+	if !strings.HasPrefix(eps, "%!PS-Adobe-3.0 EPSF-3.0\n%%BoundingBox: 0 0 29 57\n") || strings.Contains(eps, "setpagedevice") {
+		t.Errorf("Unexpected EPS header:\n%s", eps)
+	}
+	Config.outputPath = "out.ps"
+	if !strings.Contains(generateOutput(10, 20, shapes, nil), "<< /PageSize [28.346 56.693] >> setpagedevice") {
+		t.Error("PostScript documents have to set the page size")
+	}
+
+	// Offset strokes are drawn with the width of the pen
+	Config.offsetStrokes = true
+	Config.penWidth = 0.3
+	if _, _, paths = getVectorPaths(10, 20, shapes); math.Abs(paths[0].strokeWidth-mmToPixel(0.3, pointsPerInch)) > 1e-9 {
+		t.Errorf("Offset strokes have to be drawn with the width of the pen, got %vpt", paths[0].strokeWidth)
+	}
+	resetStaticVariables()
+}
@@ -8,6 +8,8 @@ type Pixel struct {
 	Lines            []Polyline
 	Border           Polyline
 	LineIntersects   int
+	TargetDarkness   float64
+	Coverage         float64
 }
 
 func NewPixel(X1, Y1, X2, Y2 int, Darkness int) *Pixel {
@@ -25,5 +27,15 @@ func NewPixel(X1, Y1, X2, Y2 int, Darkness int) *Pixel {
 		{float64(X2), float64(Y2)},
 		{float64(X1), float64(Y2)}}, nil}
 
-	return &Pixel{X1, Y1, X2, Y2, midpoint, Darkness, float64(Darkness), lines, border, 0}
+	return &Pixel{X1, Y1, X2, Y2, midpoint, Darkness, float64(Darkness), lines, border, 0, float64(Darkness), 0}
+}
+
+// Returns the darkness of the pixel that is not covered by shapes yet. The value is negative if the pixel is covered
+// by more shapes than necessary.
+func (pixel *Pixel) getResidualDarkness(shapeDarknessFactor float64) float64 {
+	if Config.toneModel == ToneModelCoverage {
+		return pixel.TargetDarkness - pixel.Coverage*255
+	}
+
+	return float64(pixel.Darkness) - (float64(pixel.LineIntersects) * shapeDarknessFactor)
 }
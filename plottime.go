@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// The estimated plot time of the last artwork. Calculated at the end of generateVectorArt.
+var estimatedPlotTime time.Duration
+
+// Returns the polylines of the shapes (in pixels of the outputDpi) in mm
+func getPolylinesMM(shapes []*Shape) [][]Point {
+	var polylinesMM [][]Point
+	for _, shape := range shapes {
+		for lineIndex := range shape.Lines {
+			points := getRenderPoints(&shape.Lines[lineIndex])
+			pointsMM := make([]Point, len(points))
+			for index := range points {
+				pointsMM[index] = points[index].copy()
+				pointsMM[index].pixelToMM(Config.outputDpi)
+			}
+			polylinesMM = append(polylinesMM, pointsMM)
+		}
+	}
+
+	return polylinesMM
+}
+
+func reportPlotTime() {
+	fmt.Printf("\nEstimated Plot Time: %s\n", formatDuration(estimatedPlotTime))
+}
+
+// Estimates how long a plotter needs to draw the polylines (in mm). The pen starts and ends at the origin, is lifted
+// for every travel move and accelerates and decelerates with the configured acceleration. Corners are taken with a
+// speed that depends on how sharp they are.
+func estimatePlotTime(polylines [][]Point) time.Duration {
+	seconds := 0.0
+	position := *NewPoint(0, 0)
+
+	for _, points := range polylines {
+		if len(points) == 0 {
+			continue
+		}
+
+		seconds += getMoveTime([]Point{position, points[0]}, Config.penUpSpeed)
+		seconds += 2 * Config.penLiftTime
+		seconds += getMoveTime(points, Config.penDownSpeed)
+		position = points[len(points)-1]
+	}
+	seconds += getMoveTime([]Point{position, *NewPoint(0, 0)}, Config.penUpSpeed)
+
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// Returns the time (in seconds) needed to move along the points starting and ending at rest
+func getMoveTime(points []Point, maxSpeed float64) float64 {
+	lengths, speeds := getSpeedProfile(points, maxSpeed)
+
+	seconds := 0.0
+	for index, length := range lengths {
+		seconds += getSegmentTime(length, speeds[index], speeds[index+1], maxSpeed, Config.acceleration)
+	}
+
+	return seconds
+}
+
+// Returns the lengths of the segments between the points, without segments of length 0, and the speeds at the start
+// of every segment and at the end of the last segment. The plotter starts and ends at rest and slows down for corners.
+func getSpeedProfile(points []Point, maxSpeed float64) ([]float64, []float64) {
+	var lengths []float64
+	var directions []Point
+	for index := 1; index < len(points); index++ {
+		length := points[index-1].distanceTo(&points[index])
+		if length == 0 {
+			continue
+		}
+		lengths = append(lengths, length)
+		directions = append(directions, *NewPoint((points[index].X-points[index-1].X)/length, (points[index].Y-points[index-1].Y)/length))
+	}
+
+	if len(lengths) == 0 {
+		return nil, nil
+	}
+
+	// speeds[index] is the speed at the start of segment index, speeds[len(lengths)] is the speed at the end
+	speeds := make([]float64, len(lengths)+1)
+	for index := 1; index < len(lengths); index++ {
+		cosine := directions[index-1].X*directions[index].X + directions[index-1].Y*directions[index].Y
+		speeds[index] = maxSpeed * math.Max(0, cosine)
+	}
+
+	acceleration := Config.acceleration
+	for index := 1; index < len(speeds); index++ {
+		speeds[index] = math.Min(speeds[index], math.Sqrt(speeds[index-1]*speeds[index-1]+2*acceleration*lengths[index-1]))
+	}
+	for index := len(speeds) - 2; index >= 0; index-- {
+		speeds[index] = math.Min(speeds[index], math.Sqrt(speeds[index+1]*speeds[index+1]+2*acceleration*lengths[index]))
+	}
+
+	return lengths, speeds
+}
+
+// Returns the time (in seconds) needed for a straight move with a trapezoidal speed profile
+func getSegmentTime(length, startSpeed, endSpeed, maxSpeed, acceleration float64) float64 {
+	peakSpeed := math.Sqrt((2*acceleration*length + startSpeed*startSpeed + endSpeed*endSpeed) / 2)
+	if peakSpeed <= maxSpeed {
+		return (peakSpeed-startSpeed)/acceleration + (peakSpeed-endSpeed)/acceleration
+	}
+
+	accelerationDistance := (maxSpeed*maxSpeed - startSpeed*startSpeed) / (2 * acceleration)
+	decelerationDistance := (maxSpeed*maxSpeed - endSpeed*endSpeed) / (2 * acceleration)
+	cruiseDistance := length - accelerationDistance - decelerationDistance
+
+	return (maxSpeed-startSpeed)/acceleration + cruiseDistance/maxSpeed + (maxSpeed-endSpeed)/acceleration
+}
+
+// Returns the distance travelled after the time (in seconds) of a straight move with a trapezoidal speed profile
+func getSegmentDistance(length, startSpeed, endSpeed, maxSpeed, acceleration, time float64) float64 {
+	peakSpeed := math.Min(math.Sqrt((2*acceleration*length+startSpeed*startSpeed+endSpeed*endSpeed)/2), maxSpeed)
+	accelerationTime := (peakSpeed - startSpeed) / acceleration
+	accelerationDistance := (peakSpeed*peakSpeed - startSpeed*startSpeed) / (2 * acceleration)
+	decelerationDistance := (peakSpeed*peakSpeed - endSpeed*endSpeed) / (2 * acceleration)
+	cruiseTime := math.Max(length-accelerationDistance-decelerationDistance, 0) / peakSpeed
+
+	if time < accelerationTime {
+		return startSpeed*time + acceleration*time*time/2
+	}
+	if time < accelerationTime+cruiseTime {
+		return accelerationDistance + peakSpeed*(time-accelerationTime)
+	}
+	time -= accelerationTime + cruiseTime
+
+	return math.Min(length-decelerationDistance+peakSpeed*time-acceleration*time*time/2, length)
+}
+
+func formatDuration(duration time.Duration) string {
+	duration = duration.Round(time.Second)
+	hours := int(duration.Hours())
+	minutes := int(duration.Minutes()) % 60
+	seconds := int(duration.Seconds()) % 60
+
+	return fmt.Sprintf("%dh %02dm %02ds", hours, minutes, seconds)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSegmentTime(t *testing.T) {
+	testCases := []struct {
+		name                                                 string
+		length, startSpeed, endSpeed, maxSpeed, acceleration float64
+		expected                                             float64
+	}{
+		// The maximum speed is not reached, so the profile is a triangle peaking at sqrt(2)
+		{"triangle", 2, 0, 0, 10, 1, 2 * math.Sqrt2},
+		// 2 mm to accelerate to 2 mm/s, 6 mm at full speed and 2 mm to decelerate
+		{"trapezoid", 10, 0, 0, 2, 1, 2 + 3 + 2},
+		{"full speed", 10, 2, 2, 2, 1, 5},
+		{"decelerate", 10, 2, 0, 2, 1, 4 + 2},
+	}
+
+	for _, testCase := range testCases {
+		segmentTime := getSegmentTime(testCase.length, testCase.startSpeed, testCase.endSpeed, testCase.maxSpeed, testCase.acceleration)
+		if math.Abs(segmentTime-testCase.expected) > 1e-9 {
+			t.Errorf("%s: expected %v s, got %v s", testCase.name, testCase.expected, segmentTime)
+		}
+
+		distance := getSegmentDistance(testCase.length, testCase.startSpeed, testCase.endSpeed, testCase.maxSpeed, testCase.acceleration, segmentTime)
+		if math.Abs(distance-testCase.length) > 1e-9 {
+			t.Errorf("%s: expected to travel %v mm until the end of the move, got %v mm", testCase.name, testCase.length, distance)
+		}
+	}
+
+	// Acceleration, cruise and deceleration phase of the trapezoid
+	for _, sample := range [][2]float64{{1, 0.5}, {2, 2}, {3.5, 5}, {6, 9.5}} {
+		if distance := getSegmentDistance(10, 0, 0, 2, 1, sample[0]); math.Abs(distance-sample[1]) > 1e-9 {
+			t.Errorf("Expected to travel %v mm after %v s, got %v mm", sample[1], sample[0], distance)
+		}
+	}
+}
+
+func TestSpeedProfile(t *testing.T) {
+	resetStaticVariables()
+	Config.acceleration = 1
+
+	// The plotter has to stop in the right angled corner but not at the straight joint
+	lengths, speeds := getSpeedProfile([]Point{{0, 0}, {8, 0}, {8, 8}, {8, 16}, {8, 16}}, 100)
+	if !equalFloats(lengths, []float64{8, 8, 8}) {
+		t.Errorf("Unexpected lengths %v", lengths)
+	}
+	if !equalFloats(speeds, []float64{0, 0, 4, 0}) {
+		t.Errorf("Unexpected speeds %v", speeds)
+	}
+
+	if lengths, speeds := getSpeedProfile([]Point{{1, 1}, {1, 1}}, 100); lengths != nil || speeds != nil {
+		t.Errorf("Points without distance need no move, got %v and %v", lengths, speeds)
+	}
+	resetStaticVariables()
+}
+
+func TestEstimatePlotTime(t *testing.T) {
+	resetStaticVariables()
+	Config.acceleration = 1
+	Config.penDownSpeed = 2
+	Config.penUpSpeed = 2
+	Config.penLiftTime = 0.5
+
+	// Lifting and lowering the pen, drawing the line and travelling back to the origin
+	plotTime := estimatePlotTime([][]Point{{{0, 0}, {10, 0}}, {}})
+	if expected := time.Duration(15 * float64(time.Second)); (plotTime - expected).Abs() > time.Microsecond {
+		t.Errorf("Expected the plot time %v, got %v", expected, plotTime)
+	}
+
+	if formatted := formatDuration(time.Hour + 2*time.Minute + 3400*time.Millisecond); formatted != "1h 02m 03s" {
+		t.Errorf("Unexpected formatted duration '%s'", formatted)
+	}
+	resetStaticVariables()
+}
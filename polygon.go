@@ -15,11 +15,7 @@ func (polygon *Polygon) equalTo(otherPolygon *Polygon, precision int) bool {
 }
 
 func (polygon *Polygon) toShape() *Shape {
-	line := polygon.toPolyline()
-	shape := Shape{[]Polyline{*line}, nil, Point{0, 0}}
-	shape.calculateCentroid()
-
-	return &shape
+	return NewSingleLineShape(*polygon.toPolyline())
 }
 
 func (polygon *Polygon) toPolyline() *Polyline {
@@ -237,6 +237,15 @@ func (line *Polyline) copy() Polyline {
 	return copiedLine
 }
 
+func (line *Polyline) getLength() float64 {
+	length := 0.0
+	for pointIndex := 1; pointIndex < len(line.points); pointIndex++ {
+		length += line.points[pointIndex-1].distanceTo(&line.points[pointIndex])
+	}
+
+	return length
+}
+
 func (line *Polyline) getLineSegments() []Polyline {
 	var lineSegments []Polyline
 	for pointIndex := 1; pointIndex < len(line.points); pointIndex++ {
@@ -275,6 +284,16 @@ func (line *Polyline) toSVG(style string) string {
 	return svg
 }
 
+// Determines if the line encloses an area. Circles and polygons are always closed, polylines are closed if their
+// first and last point are equal.
+func (line *Polyline) isClosed() bool {
+	if line.originalShape != nil {
+		return true
+	}
+
+	return len(line.points) > 2 && line.points[0].equalTo(&line.points[len(line.points)-1], 6)
+}
+
 func (line *Polyline) toJSON() any {
 	if line.originalShape != nil {
 		switch originalShapeType := line.originalShape.(type) {
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// The greyscale image the artwork was generated from (in pixels of the processingDpi)
+var ProcessedImage *image.Gray
+
+var namedColors = map[string]color.RGBA{
+	"black":   {0, 0, 0, 255},
+	"white":   {255, 255, 255, 255},
+	"gray":    {128, 128, 128, 255},
+	"grey":    {128, 128, 128, 255},
+	"red":     {255, 0, 0, 255},
+	"green":   {0, 128, 0, 255},
+	"blue":    {0, 0, 255, 255},
+	"yellow":  {255, 255, 0, 255},
+	"cyan":    {0, 255, 255, 255},
+	"magenta": {255, 0, 255, 255},
+	"orange":  {255, 165, 0, 255},
+	"purple":  {128, 0, 128, 255},
+	"brown":   {165, 42, 42, 255},
+	"pink":    {255, 192, 203, 255},
+}
+
+// Writes a PNG rendering of the artwork next to the output file and, if enabled, a comparison image that shows the
+// processed input image, the rendering and the difference between both side by side. Images that would overwrite one
+// of the input images are not written.
+func writePreviews(inputPaths []string) {
+	if !Config.preview || ProcessedImage == nil {
+		return
+	}
+
+	preview := renderPreview()
+	basePath := strings.TrimSuffix(Config.outputPath, filepath.Ext(Config.outputPath))
+	writePreviewPNG(preview, basePath+"_preview.png", inputPaths)
+
+	if Config.previewComparison {
+		writePreviewPNG(renderComparison(preview), basePath+"_comparison.png", inputPaths)
+	}
+}
+
+func writePreviewPNG(img image.Image, path string, inputPaths []string) {
+	if isInputPath(path, inputPaths) {
+		fmt.Printf("Not writing '%s' since it would overwrite an input image!\n", path)
+		return
+	}
+
+	writePNG(img, path)
+}
+
+// Returns true if the path points to the same file as one of the input paths
+func isInputPath(path string, inputPaths []string) bool {
+	absolutePath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+
+	for _, inputPath := range inputPaths {
+		absoluteInputPath, err := filepath.Abs(inputPath)
+		if err == nil && absoluteInputPath == absolutePath {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Rasterizes the final shapes (in pixels of the outputDpi) with their stroke width and color onto a white canvas.
+// Closed lines are filled with the stroke color if fillClosedShapes is enabled, like in the SVG.
+func renderPreview() *image.RGBA {
+	width := int(math.Ceil(mmToPixel(PixelToMM(float64(ProcessedImage.Bounds().Max.X), Config.processingDpi), Config.outputDpi)))
+	height := int(math.Ceil(mmToPixel(PixelToMM(float64(ProcessedImage.Bounds().Max.Y), Config.processingDpi), Config.outputDpi)))
+
+	preview := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(preview, preview.Bounds(), image.White, image.Point{}, draw.Src)
+
+	for _, shape := range collectShapes() {
+		strokeColor, strokeWidth := shape.getDrawnStroke()
+
+		rgba := parseColor(strokeColor)
+		for lineIndex := range shape.Lines {
+			points := getRenderPoints(&shape.Lines[lineIndex])
+			if Config.fillClosedShapes && shape.Lines[lineIndex].isClosed() {
+				fillPolygon(preview, points, rgba)
+			}
+			renderPolyline(preview, points, strokeWidth, rgba)
+		}
+	}
+
+	return preview
+}
+
+// Returns the points of the line. Circles are stored as hexagons and are therefore converted to a finer polyline.
+func getRenderPoints(line *Polyline) []Point {
+	if circle, ok := line.originalShape.(*Circle); ok {
+		return circle.toPolyline(120).points
+	}
+
+	return line.points
+}
+
+// Draws an anti-aliased polyline. The coverage of every pixel is the maximum coverage of all segments of the polyline
+// so that the joints between segments are not drawn twice.
+func renderPolyline(img *image.RGBA, points []Point, strokeWidth float64, strokeColor color.RGBA) {
+	halfWidth := math.Max(strokeWidth, 1) / 2
+	// Strokes thinner than a pixel are drawn one pixel wide but lighter
+	intensity := math.Min(strokeWidth, 1)
+	coverage := make(map[image.Point]float64)
+
+	for index := 1; index < len(points); index++ {
+		p1, p2 := &points[index-1], &points[index]
+		minX := int(math.Floor(math.Min(p1.X, p2.X) - halfWidth - 1))
+		maxX := int(math.Ceil(math.Max(p1.X, p2.X) + halfWidth + 1))
+		minY := int(math.Floor(math.Min(p1.Y, p2.Y) - halfWidth - 1))
+		maxY := int(math.Ceil(math.Max(p1.Y, p2.Y) + halfWidth + 1))
+
+		for y := max(minY, img.Bounds().Min.Y); y <= min(maxY, img.Bounds().Max.Y-1); y++ {
+			for x := max(minX, img.Bounds().Min.X); x <= min(maxX, img.Bounds().Max.X-1); x++ {
+				pixelCenter := NewPoint(float64(x)+0.5, float64(y)+0.5)
+				pixelCoverage := math.Max(0, math.Min(1, halfWidth+0.5-distanceToSegment(pixelCenter, p1, p2))) * intensity
+				pixel := image.Point{x, y}
+				coverage[pixel] = math.Max(coverage[pixel], pixelCoverage)
+			}
+		}
+	}
+
+	for pixel, alpha := range coverage {
+		if alpha == 0 {
+			continue
+		}
+		current := img.RGBAAt(pixel.X, pixel.Y)
+		img.SetRGBA(pixel.X, pixel.Y, color.RGBA{
+			blendChannel(current.R, strokeColor.R, alpha),
+			blendChannel(current.G, strokeColor.G, alpha),
+			blendChannel(current.B, strokeColor.B, alpha),
+			255,
+		})
+	}
+}
+
+// Fills the area enclosed by the points with the even-odd rule. A pixel is filled if its center is inside the area;
+// the edges are smoothed by the stroke that is drawn on top.
+func fillPolygon(img *image.RGBA, points []Point, fillColor color.RGBA) {
+	if len(points) < 3 {
+		return
+	}
+
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for _, point := range points {
+		minY, maxY = math.Min(minY, point.Y), math.Max(maxY, point.Y)
+	}
+
+	bounds := img.Bounds()
+	for y := max(int(math.Floor(minY)), bounds.Min.Y); y <= min(int(math.Ceil(maxY)), bounds.Max.Y-1); y++ {
+		scanline := float64(y) + 0.5
+
+		var crossings []float64
+		for i, j := 0, len(points)-1; i < len(points); j, i = i, i+1 {
+			p1, p2 := &points[j], &points[i]
+			if (p1.Y <= scanline) != (p2.Y <= scanline) {
+				crossings = append(crossings, p1.X+(scanline-p1.Y)/(p2.Y-p1.Y)*(p2.X-p1.X))
+			}
+		}
+		slices.Sort(crossings)
+
+		for index := 1; index < len(crossings); index += 2 {
+			startX := max(int(math.Ceil(crossings[index-1]-0.5)), bounds.Min.X)
+			endX := min(int(math.Floor(crossings[index]-0.5)), bounds.Max.X-1)
+			for x := startX; x <= endX; x++ {
+				img.SetRGBA(x, y, fillColor)
+			}
+		}
+	}
+}
+
+func blendChannel(background, foreground uint8, alpha float64) uint8 {
+	return uint8(math.Round(float64(background)*(1-alpha) + float64(foreground)*alpha))
+}
+
+// Returns the distance between the point and the line segment p1---p2
+func distanceToSegment(point, p1, p2 *Point) float64 {
+	dx, dy := p2.X-p1.X, p2.Y-p1.Y
+	lengthSquared := dx*dx + dy*dy
+	if lengthSquared == 0 {
+		return point.distanceTo(p1)
+	}
+
+	t := math.Max(0, math.Min(1, ((point.X-p1.X)*dx+(point.Y-p1.Y)*dy)/lengthSquared))
+	return point.distanceTo(NewPoint(p1.X+t*dx, p1.Y+t*dy))
+}
+
+// Places the processed input image (scaled to the size of the preview), the preview and the absolute difference
+// between both side by side. In the difference image black means large and white means no difference.
+func renderComparison(preview *image.RGBA) *image.RGBA {
+	width, height := preview.Bounds().Dx(), preview.Bounds().Dy()
+	input := image.NewGray(preview.Bounds())
+	draw.Draw(input, input.Bounds(), resizeImage(ProcessedImage, width, height), image.Point{}, draw.Src)
+
+	comparison := image.NewRGBA(image.Rect(0, 0, width*3, height))
+	draw.Draw(comparison, image.Rect(0, 0, width, height), input, image.Point{}, draw.Src)
+	draw.Draw(comparison, image.Rect(width, 0, width*2, height), preview, image.Point{}, draw.Src)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			previewGray := color.GrayModel.Convert(preview.RGBAAt(x, y)).(color.Gray).Y
+			difference := math.Abs(float64(input.GrayAt(x, y).Y) - float64(previewGray))
+			value := uint8(255 - difference)
+			comparison.SetRGBA(2*width+x, y, color.RGBA{value, value, value, 255})
+		}
+	}
+
+	return comparison
+}
+
+// Parses SVG color values (hex notation, rgb() notation and the most common color names). Unknown colors are
+// rendered black.
+func parseColor(value string) color.RGBA {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	if namedColor, ok := namedColors[value]; ok {
+		return namedColor
+	}
+
+	if strings.HasPrefix(value, "#") {
+		hex := strings.TrimPrefix(value, "#")
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		rgb, err := strconv.ParseUint(hex, 16, 32)
+		if err == nil && len(hex) == 6 {
+			return color.RGBA{uint8(rgb >> 16), uint8(rgb >> 8), uint8(rgb), 255}
+		}
+	}
+
+	if strings.HasPrefix(value, "rgb(") && strings.HasSuffix(value, ")") {
+		channels := strings.Split(strings.TrimSuffix(strings.TrimPrefix(value, "rgb("), ")"), ",")
+		if len(channels) == 3 {
+			var rgb [3]uint8
+			valid := true
+			for index, channel := range channels {
+				channelValue, err := strconv.Atoi(strings.TrimSpace(channel))
+				if err != nil || channelValue < 0 || channelValue > 255 {
+					valid = false
+					break
+				}
+				rgb[index] = uint8(channelValue)
+			}
+			if valid {
+				return color.RGBA{rgb[0], rgb[1], rgb[2], 255}
+			}
+		}
+	}
+
+	if Config.debug {
+		fmt.Printf("Unknown stroke color '%s'. The preview uses black instead.\n", value)
+	}
+	return namedColors["black"]
+}
+
+func writePNG(img image.Image, path string) {
+	file, err := createFile(path)
+	if err != nil {
+		fmt.Printf("Could not create file '%s'\n", path)
+		fmt.Println(err)
+		return
+	}
+	defer file.Close()
+
+	err = png.Encode(file, img)
+	if err != nil {
+		fmt.Printf("Could not write PNG '%s'\n", path)
+		fmt.Println(err)
+	}
+}
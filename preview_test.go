@@ -0,0 +1,71 @@
+package main
+
+import (
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWritePreviews(t *testing.T) {
+	resetStaticVariables()
+	directory := t.TempDir()
+	Config.preview = true
+	Config.previewComparison = true
+	ProcessedImage = image.NewGray(image.Rect(0, 0, 10, 10))
+
+	inputPath := filepath.Join(directory, "art.png")
+	if err := os.WriteFile(inputPath, []byte("input"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	Config.outputPath = filepath.Join(directory, "art.svg")
+	writePreviews([]string{inputPath})
+
+	if content, _ := os.ReadFile(inputPath); string(content) != "input" {
+		t.Error("The preview overwrote the input image")
+	}
+	for _, name := range []string{"art_preview.png", "art_comparison.png"} {
+		if _, err := os.Stat(filepath.Join(directory, name)); err != nil {
+			t.Errorf("The file '%s' was not written", name)
+		}
+	}
+
+	// An input image with the name of the preview is not overwritten either
+	previewInputPath := filepath.Join(directory, "other_preview.png")
+	if err := os.WriteFile(previewInputPath, []byte("input"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	Config.outputPath = filepath.Join(directory, "other.svg")
+	writePreviews([]string{inputPath, filepath.Join(directory, ".", "other_preview.png")})
+
+	if content, _ := os.ReadFile(previewInputPath); string(content) != "input" {
+		t.Error("The preview overwrote the input image")
+	}
+	if _, err := os.Stat(filepath.Join(directory, "other_comparison.png")); err != nil {
+		t.Error("The comparison has to be written even if the preview is not")
+	}
+	resetStaticVariables()
+}
+
+func TestPreviewFill(t *testing.T) {
+	resetStaticVariables()
+	Config.processingDpi = 25.4
+	Config.outputDpi = 25.4
+	Config.strokeColor = "black"
+	Config.strokeWidth = 1
+	ProcessedImage = image.NewGray(image.Rect(0, 0, 20, 20))
+
+	square := Polyline{[]Point{{4, 4}, {16, 4}, {16, 16}, {4, 16}, {4, 4}}, nil}
+	quadrants = []*Quadrant{{Shapes: []Shape{*NewShape([]Polyline{square})}}}
+
+	if renderPreview().RGBAAt(10, 10) != namedColors["white"] {
+		t.Error("Closed lines must not be filled if fillClosedShapes is disabled")
+	}
+
+	Config.fillClosedShapes = true
+	preview := renderPreview()
+	if preview.RGBAAt(10, 10) != namedColors["black"] || preview.RGBAAt(2, 10) != namedColors["white"] {
+		t.Error("Closed lines have to be filled with the stroke color if fillClosedShapes is enabled")
+	}
+	resetStaticVariables()
+}
@@ -31,6 +31,14 @@ type Quadrant struct {
 	Shapes          []Shape
 	accessMutex     sync.Mutex
 	processingMutex sync.Mutex
+
+	region *Region
+	// Set for quadrants outside of a non-rectangular canvas
+	outsideCanvas bool
+
+	structureTensor   [3]float64
+	gradientAngle     float64
+	gradientCoherence float64
 }
 
 func NewQuadrant(img *image.Gray, quadrantId uint, nrOfQuadrants uint, quadrantsPerRow uint, quadrantsPerColumn uint) *Quadrant {
@@ -71,6 +79,10 @@ func (quadrant *Quadrant) getIntersectedPixels(shape *Shape) []*Pixel {
 
 func (quadrant *Quadrant) updateLineIntersects(shape *Shape, shapeAdded bool) []*Pixel {
 	var intersectedPixels []*Pixel
+	var coverage *CoverageMap
+	if Config.toneModel == ToneModelCoverage {
+		coverage = shape.getCoverage()
+	}
 
 	for pixelIndex := range quadrant.FlattenPixels {
 		currentPixel := quadrant.FlattenPixels[pixelIndex]
@@ -87,8 +99,14 @@ func (quadrant *Quadrant) updateLineIntersects(shape *Shape, shapeAdded bool) []
 				intersectedPixels = append(intersectedPixels, quadrant.FlattenPixels[pixelIndex])
 			}
 		}
-		currentPixel.AdjustedDarkness = math.Max(0,
-			float64(currentPixel.Darkness)-(float64(currentPixel.LineIntersects)*Config.shapeDarknessFactor))
+		if coverage != nil {
+			if shapeAdded {
+				currentPixel.Coverage += coverage.at(currentPixel.X1, currentPixel.Y1)
+			} else {
+				currentPixel.Coverage -= coverage.at(currentPixel.X1, currentPixel.Y1)
+			}
+		}
+		currentPixel.AdjustedDarkness = math.Max(0, currentPixel.getResidualDarkness(quadrant.region.shapeDarknessFactor))
 	}
 	return intersectedPixels
 }
@@ -102,21 +120,47 @@ func (quadrant *Quadrant) addShapeWithoutNeighbors(shape *Shape) []*Pixel {
 
 func (quadrant *Quadrant) scoreShapes() []*ShapeScore {
 	var shapeScores []*ShapeScore
+	scorer := getShapeScorer()
 
+	neighborhood := quadrant.getNeighborhood()
 	for index := range quadrant.Shapes {
 		currentShape := quadrant.Shapes[index]
+		effect := ShapeEffect{shape: &currentShape, placed: true}
+
+		for neighborhoodIndex := range neighborhood {
+			currentQuadrant := neighborhood[neighborhoodIndex]
+
+			currentQuadrant.accessMutex.Lock()
+			effect.darknessWith += currentQuadrant.getAdjustedDarkness()
+			if scorer.usesResiduals() {
+				effect.pixels = append(effect.pixels, currentQuadrant.FlattenPixels...)
+				effect.residualsWith = currentQuadrant.appendResiduals(effect.residualsWith)
+			}
 
-		neighborhoodDarknessBefore := quadrant.getAdjustedNeighborhoodDarkness()
-		intersectedPixels := quadrant.getIntersectedPixels(&quadrant.Shapes[index])
-		for index := range quadrant.Neighbors {
-			intersectedPixels = append(intersectedPixels, quadrant.Neighbors[index].getIntersectedPixels(&currentShape)...)
+			intersectedPixels := currentQuadrant.getIntersectedPixels(&currentShape)
+			effect.intersectedPixels = append(effect.intersectedPixels, intersectedPixels...)
+			effect.overlappingPixels += countOverlappingPixels(intersectedPixels)
+			currentQuadrant.accessMutex.Unlock()
 		}
+
 		quadrant.removeShape(index)
-		neighborhoodDarknessAfter := quadrant.getAdjustedNeighborhoodDarkness()
+		for neighborhoodIndex := range neighborhood {
+			currentQuadrant := neighborhood[neighborhoodIndex]
+
+			currentQuadrant.accessMutex.Lock()
+			effect.darknessWithout += currentQuadrant.getAdjustedDarkness()
+			if scorer.usesResiduals() {
+				effect.residualsWithout = currentQuadrant.appendResiduals(effect.residualsWithout)
+			}
+			currentQuadrant.accessMutex.Unlock()
+		}
 		quadrant.addShapeAtIndex(&currentShape, index)
-		//Todo test different score types for example with white punishemnt
-		score := ((neighborhoodDarknessAfter - neighborhoodDarknessBefore) / float64(len(intersectedPixels))) //- (punishment / float64(len(intersectedPixels)))
-		shapeScores = append(shapeScores, &ShapeScore{int(quadrant.Id), index, score})
+
+		effect.darknessWith /= float64(len(quadrant.Neighbors))
+		effect.darknessWithout /= float64(len(quadrant.Neighbors))
+
+		score := scorer.score(&effect)
+		shapeScores = append(shapeScores, &ShapeScore{int(quadrant.Id), index, score, effect.getDarknessScore()})
 	}
 
 	return shapeScores
@@ -191,20 +235,6 @@ func (quadrant *Quadrant) getAdjustedDarkness() float64 {
 	return darkness / float64(len(quadrant.FlattenPixels))
 }
 
-func (quadrant *Quadrant) getAdjustedNeighborhoodDarkness() float64 {
-	neighborhoodDarkness := quadrant.getAdjustedDarkness()
-
-	for index := range quadrant.Neighbors {
-		currentNeighbor := quadrant.Neighbors[index]
-
-		currentNeighbor.accessMutex.Lock()
-		neighborhoodDarkness += currentNeighbor.getAdjustedDarkness()
-		currentNeighbor.accessMutex.Unlock()
-	}
-
-	return neighborhoodDarkness / float64(len(quadrant.Neighbors))
-}
-
 func (quadrant *Quadrant) getAdjustedDarkestPixel() *Pixel {
 	quadrant.accessMutex.Lock()
 	defer quadrant.accessMutex.Unlock()
@@ -223,9 +253,12 @@ func (quadrant *Quadrant) getAdjustedDarkestPixel() *Pixel {
 }
 
 func (quadrant *Quadrant) isDone() bool {
+	if quadrant.outsideCanvas {
+		return true
+	}
 	quadrant.accessMutex.Lock()
 	defer quadrant.accessMutex.Unlock()
-	return quadrant.getAdjustedDarkness() <= Config.darknessThreshold
+	return quadrant.getAdjustedDarkness() <= quadrant.region.darknessThreshold
 }
 
 func (quadrant *Quadrant) getTopLeftPixel() *Pixel {
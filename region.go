@@ -0,0 +1,280 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"slices"
+	"strconv"
+)
+
+// A region is a part of the artwork (defined by a mask image or a polygon in millimetre) that uses its own
+// shape set, darkness threshold and stroke settings.
+type Region struct {
+	maskPath            string
+	polygon             []Point
+	shapes              []Shape
+	darknessThreshold   float64
+	shapeDarknessFactor float64
+	strokeWidth         float64
+	strokeColor         string
+
+	mask *image.Gray
+}
+
+// All regions used during the artwork generation. The first region is always the default region derived from
+// the top level configuration and used for every quadrant not covered by a configured region.
+var regions []*Region
+
+func NewRegion(config *VecartConfig) *Region {
+	var region Region
+
+	region.darknessThreshold = config.darknessThreshold
+	region.shapeDarknessFactor = config.shapeDarknessFactor
+	region.strokeWidth = config.strokeWidth
+	region.strokeColor = config.strokeColor
+
+	return &region
+}
+
+// Returns the shapes of the region. Regions without their own shape set use the shapes of the top level configuration.
+func (region *Region) getShapes() []Shape {
+	if len(region.shapes) == 0 {
+		return Config.shapes
+	}
+
+	return region.shapes
+}
+
+func (region *Region) equalTo(otherRegion *Region) bool {
+	if region.maskPath != otherRegion.maskPath {
+		return false
+	}
+	if len(region.polygon) != len(otherRegion.polygon) {
+		return false
+	}
+	for index := range region.polygon {
+		if !region.polygon[index].equalTo(&otherRegion.polygon[index], 10) {
+			return false
+		}
+	}
+	if region.darknessThreshold != otherRegion.darknessThreshold {
+		return false
+	}
+	if region.shapeDarknessFactor != otherRegion.shapeDarknessFactor {
+		return false
+	}
+	if region.strokeWidth != otherRegion.strokeWidth {
+		return false
+	}
+	if region.strokeColor != otherRegion.strokeColor {
+		return false
+	}
+
+	return shapesEqual(&region.shapes, &otherRegion.shapes, 10, false)
+}
+
+func regionsEqual(regions, otherRegions []Region) bool {
+	if len(regions) != len(otherRegions) {
+		return false
+	}
+
+	for index := range regions {
+		if !regions[index].equalTo(&otherRegions[index]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (region *Region) toJSON() map[string]any {
+	jsonMap := make(map[string]any)
+
+	if region.maskPath != "" {
+		jsonMap["mask"] = region.maskPath
+	} else {
+		points := []any{}
+		for _, point := range region.polygon {
+			points = append(points, point.toJSON())
+		}
+		jsonMap["polygon"] = points
+	}
+
+	if len(region.shapes) != 0 {
+		var shapes []any
+		for _, shape := range region.shapes {
+			shapes = append(shapes, shape.toJSON())
+		}
+		jsonMap["shapes"] = shapes
+	}
+
+	jsonMap["darknessThreshold"] = region.darknessThreshold
+	jsonMap["shapeDarknessFactor"] = region.shapeDarknessFactor
+	jsonMap["strokeWidth"] = region.strokeWidth
+	jsonMap["strokeColor"] = region.strokeColor
+
+	return jsonMap
+}
+
+func (region *Region) validate(regionIndex int) bool {
+	valid := true
+	name := "Region " + strconv.Itoa(regionIndex)
+
+	if region.maskPath != "" && !pathValid(region.maskPath) {
+		valid = false
+		errors = append(errors, name+": Mask path '"+region.maskPath+"' is not a valid!")
+	}
+
+	if region.darknessThreshold < 0 {
+		valid = false
+		errors = append(errors, name+": A darknessThreshold below 0 is invalid!")
+	}
+
+	if region.shapeDarknessFactor <= 0 {
+		valid = false
+		errors = append(errors, name+": A shapeDarknessFactor below or equal to 0 is invalid!")
+	}
+
+	if region.strokeWidth < 0 {
+		valid = false
+		errors = append(errors, name+": strokeWidth must be greater or equal to 0!")
+	}
+
+	return valid
+}
+
+// Parses the region definitions. Must be called after all top level parameters have been parsed since
+// regions inherit the parameters they do not define from the top level configuration.
+func getRegions(jsonData map[string]any, key string, config *VecartConfig) {
+	regionArray, success := getArray(jsonData, key)
+	if !success {
+		return
+	}
+
+	var parsedRegions []Region
+	for regionIndex, regionAny := range regionArray {
+		regionParameters, ok := regionAny.(map[string]any)
+		if !ok {
+			errorsOcurred = true
+			errors = append(errors, "Unexpected type for region definition | expected object")
+			continue
+		}
+
+		region, ok := parseRegion(regionParameters, regionIndex, config)
+		if ok {
+			parsedRegions = append(parsedRegions, *region)
+		}
+	}
+
+	config.regions = parsedRegions
+}
+
+func parseRegion(regionParameters map[string]any, regionIndex int, config *VecartConfig) (*Region, bool) {
+	region := NewRegion(config)
+	name := "Region " + strconv.Itoa(regionIndex)
+
+	regionKeys := []string{"mask", "polygon", "shapes", "darknessThreshold", "shapeDarknessFactor", "strokeWidth", "strokeColor"}
+	for key := range regionParameters {
+		if !slices.Contains(regionKeys, key) {
+			errorsOcurred = true
+			errors = append(errors, name+": Unkown Key in region definition '"+key+"'")
+		}
+	}
+
+	getString(regionParameters, "mask", &region.maskPath)
+
+	pointsAny, ok := getArray(regionParameters, "polygon")
+	if ok {
+		for _, point := range pointsAny {
+			getPoint(point, &region.polygon, config.outputDpi)
+		}
+		if len(region.polygon) < 3 {
+			errorsOcurred = true
+			errors = append(errors, name+": Invalid point definition for region polygon!")
+			return nil, false
+		}
+	}
+
+	if (region.maskPath == "") == (len(region.polygon) == 0) {
+		errorsOcurred = true
+		errors = append(errors, name+": Each region needs either a 'mask' or a 'polygon' attribute!")
+		return nil, false
+	}
+
+	getShapes(regionParameters, "shapes", &region.shapes, config.outputDpi)
+	getFloat(regionParameters, "darknessThreshold", &region.darknessThreshold)
+	getFloat(regionParameters, "shapeDarknessFactor", &region.shapeDarknessFactor)
+	getLength(regionParameters, "strokeWidth", &region.strokeWidth, "px", config.outputDpi)
+	getString(regionParameters, "strokeColor", &region.strokeColor)
+
+	return region, true
+}
+
+// Creates the default region and the configured regions and assigns each quadrant to the first region
+// that covers the midpoint of the quadrant.
+func initializeRegions(img *image.Gray) {
+	regions = []*Region{NewRegion(&Config)}
+
+	for index := range Config.regions {
+		region := &Config.regions[index]
+		if region.maskPath != "" {
+			mask, err := loadRegionMask(region.maskPath, img.Bounds().Max.X, img.Bounds().Max.Y)
+			if err != nil {
+				fmt.Printf("Can not load region mask '%s'! The region will be ignored.\n", region.maskPath)
+				fmt.Println(err)
+				continue
+			}
+			region.mask = mask
+		}
+		regions = append(regions, region)
+	}
+
+	for index := range quadrants {
+		quadrants[index].region = regions[0]
+
+		midpoint := NewPoint(float64(quadrants[index].X1+quadrants[index].X2)/2, float64(quadrants[index].Y1+quadrants[index].Y2)/2)
+		for regionIndex := 1; regionIndex < len(regions); regionIndex++ {
+			if regions[regionIndex].contains(midpoint) {
+				quadrants[index].region = regions[regionIndex]
+				break
+			}
+		}
+	}
+}
+
+// Determines if the point (in processing pixels) is part of the region
+func (region *Region) contains(point *Point) bool {
+	if region.mask != nil {
+		x := int(math.Floor(point.X))
+		y := int(math.Floor(point.Y))
+		if !(image.Point{x, y}).In(region.mask.Bounds()) {
+			return false
+		}
+		return region.mask.GrayAt(x, y).Y >= 128
+	}
+
+	var polygon []Point
+	for _, point := range region.polygon {
+		pixelPoint := point.copy()
+		pixelPoint.mmToPixel(Config.processingDpi)
+		polygon = append(polygon, pixelPoint)
+	}
+
+	return pointInPolygon(point, polygon)
+}
+
+func loadRegionMask(path string, width, height int) (*image.Gray, error) {
+	img, err := getImageFromFilePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	img = resizeImage(img, width, height)
+
+	mask := image.NewGray(img.Bounds())
+	draw.Draw(mask, mask.Bounds(), img, img.Bounds().Min, draw.Src)
+
+	return mask, nil
+}
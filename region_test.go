@@ -0,0 +1,85 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestRegionContains(t *testing.T) {
+	resetStaticVariables()
+	Config.processingDpi = 25.4
+
+	polygonRegion := Region{polygon: []Point{{0, 0}, {10, 0}, {10, 10}, {0, 10}}}
+	mask := image.NewGray(image.Rect(0, 0, 10, 10))
+	mask.SetGray(2, 3, color.Gray{255})
+	maskRegion := Region{mask: mask}
+
+	testCases := []struct {
+		region   *Region
+		point    Point
+		expected bool
+	}{
+		{&polygonRegion, Point{5, 5}, true},
+		{&polygonRegion, Point{15, 5}, false},
+		{&maskRegion, Point{2.5, 3.9}, true},
+		{&maskRegion, Point{3.5, 3.5}, false},
+		{&maskRegion, Point{-1, 3}, false},
+		{&maskRegion, Point{20, 20}, false},
+	}
+	for _, testCase := range testCases {
+		if testCase.region.contains(&testCase.point) != testCase.expected {
+			t.Errorf("contains(%v) should be %v", testCase.point, testCase.expected)
+		}
+	}
+	resetStaticVariables()
+}
+
+func TestRegionAssignment(t *testing.T) {
+	resetStaticVariables()
+	Config.processingDpi = 25.4
+	Config.regions = []Region{
+		{polygon: []Point{{0, 0}, {10, 0}, {10, 10}, {0, 10}}, strokeColor: "red"},
+		{polygon: []Point{{0, 0}, {20, 0}, {20, 10}, {0, 10}}, strokeColor: "blue"},
+	}
+	quadrants = []*Quadrant{{X1: 0, Y1: 0, X2: 4, Y2: 4}, {X1: 12, Y1: 0, X2: 16, Y2: 4}, {X1: 12, Y1: 12, X2: 16, Y2: 16}}
+
+	initializeRegions(image.NewGray(image.Rect(0, 0, 20, 20)))
+	// Quadrants belong to the first region that covers their midpoint
+	if quadrants[0].region != &Config.regions[0] || quadrants[1].region != &Config.regions[1] || quadrants[2].region != regions[0] {
+		t.Errorf("Unexpected region assignment %p %p %p", quadrants[0].region, quadrants[1].region, quadrants[2].region)
+	}
+	if len(regions[0].getShapes()) != len(Config.shapes) {
+		t.Error("Regions without their own shapes have to use the shapes of the configuration")
+	}
+	resetStaticVariables()
+}
+
+func TestShapeCopy(t *testing.T) {
+	region := &Region{strokeColor: "red"}
+	shape := NewShape([]Polyline{{[]Point{{0, 0}, {4, 2}}, nil}})
+	shape.calculateOrientation()
+	shape.region = region
+	shape.coverage = NewCoverageMap(0, 0, 4, 2)
+
+	copiedShape := shape.copy()
+	if copiedShape.region != region || copiedShape.coverage != shape.coverage || copiedShape.centroid != shape.centroid ||
+		copiedShape.orientation != shape.orientation || copiedShape.anisotropy != shape.anisotropy {
+		t.Errorf("The copy %+v differs from the shape %+v", copiedShape, *shape)
+	}
+
+	copiedShape.Lines[0].points[0].X = 1
+	if shape.Lines[0].points[0].X != 0 {
+		t.Error("The lines of the copy have to be independent of the shape")
+	}
+
+	// The coverage depends on the position of the shape and can not be reused after moving it
+	moved := shape.transformCopy(1, 1)
+	if moved.region != region || moved.coverage != nil || moved.centroid != (Point{3, 2}) {
+		t.Errorf("Unexpected moved copy %+v", *moved)
+	}
+	rotated := shape.rotateCopy(90, Point{0, 0})
+	if rotated.coverage != nil || !rotated.centroid.equalTo(&Point{-1, 2}, 6) {
+		t.Errorf("Unexpected rotated copy %+v", *rotated)
+	}
+}
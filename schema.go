@@ -0,0 +1,532 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// A validation error with the JSON path of the invalid value (e.g. 'shapes[3].p2'). The path of errors that concern
+// the whole config is empty.
+type ConfigError struct {
+	path    string
+	message string
+}
+
+func (configError *ConfigError) String() string {
+	if configError.path == "" {
+		return configError.message
+	}
+
+	return configError.path + ": " + configError.message
+}
+
+func (configError *ConfigError) toMap() map[string]any {
+	return map[string]any{"path": configError.path, "message": configError.message}
+}
+
+// Constraints of the config parameters that go beyond their type. The JSON Schema is built from them and
+// VecartConfig.validate checks the parsed parameters against them.
+var configConstraints = map[string]map[string]any{
+	"artworkWidth":                 {"minimum": 0},
+	"artworkHeight":                {"minimum": 0},
+	"orientation":                  {"enum": schemaEnum(OrientationPortrait, OrientationLandscape)},
+	"fit":                          {"enum": schemaEnum(FitContain, FitCover, FitStretch)},
+	"quadrantWidth":                {"minimum": 1},
+	"quadrantHeight":               {"minimum": 1},
+	"darknessThreshold":            {"minimum": 0},
+	"shapeDarknessFactor":          {"exclusiveMinimum": 0},
+	"whitePunishmentBoundry":       {"minimum": 0, "maximum": 255},
+	"whitePunishmentValue":         {"minimum": 0},
+	"randomSeed":                   {"minimum": 0},
+	"parallelRoutines":             {"minimum": 1},
+	"shapeRefinementIterations":    {"minimum": 1},
+	"shapeRefinementPercentage":    {"exclusiveMinimum": 0},
+	"combineShapesTolerance":       {"exclusiveMinimum": 0},
+	"combineShapesIterations":      {"minimum": 1},
+	"strokeWidth":                  {"minimum": 0},
+	"hatchSpacing":                 {"exclusiveMinimum": 0},
+	"penWidth":                     {"exclusiveMinimum": 0},
+	"offsetJoin":                   {"enum": schemaEnum(JoinRound, JoinMiter, JoinBevel)},
+	"offsetCap":                    {"enum": schemaEnum(CapRound, CapSquare, CapButt)},
+	"dxfLayers":                    {"enum": schemaEnum(DXFLayersPen, DXFLayersType)},
+	"ebbPenUpPosition":             {"minimum": 0, "maximum": 100},
+	"ebbPenDownPosition":           {"minimum": 0, "maximum": 100},
+	"processingDpi":                {"exclusiveMinimum": 0},
+	"outputDpi":                    {"exclusiveMinimum": 0},
+	"timeout":                      {"minimum": 1},
+	"shapeAngleDeviationRange":     {"minimum": 0},
+	"shapeAngleDeviationStep":      {"exclusiveMinimum": 0},
+	"gradientOrientation":          {"enum": schemaEnum(GradientOrientationNone, GradientOrientationEdge, GradientOrientationPerpendicular)},
+	"gradientOrientationMode":      {"enum": schemaEnum(GradientOrientationModeBias, GradientOrientationModeRestrict)},
+	"gradientOrientationWeight":    {"minimum": 0},
+	"gradientOrientationTolerance": {"exclusiveMinimum": 0, "maximum": 90},
+	"scoringFunction":              {"enum": schemaEnum(ScoringDarkness, ScoringSquaredError, ScoringBlur, ScoringLengthNormalized, ScoringOverlap)},
+	"overlapPunishmentValue":       {"minimum": 0},
+	"toneModel":                    {"enum": schemaEnum(ToneModelIntersections, ToneModelCoverage)},
+	"toneModelBlur":                {"minimum": 0},
+	"annealingIterations":          {"minimum": 1},
+	"annealingTimeout":             {"minimum": 1},
+	"annealingStartTemperature":    {"exclusiveMinimum": 0},
+	"annealingEndTemperature":      {"exclusiveMinimum": 0},
+	"penDownSpeed":                 {"exclusiveMinimum": 0},
+	"penUpSpeed":                   {"exclusiveMinimum": 0},
+	"acceleration":                 {"exclusiveMinimum": 0},
+	"penLiftTime":                  {"minimum": 0},
+}
+
+// The parameters that are lengths and can be given with a unit (e.g. "210mm")
+var configSchemaLengths = []string{"artworkWidth", "artworkHeight", "quadrantWidth", "quadrantHeight", "combineShapesTolerance", "strokeWidth", "hatchSpacing", "penWidth"}
+
+func lengthSchema(constraints map[string]any) map[string]any {
+	schema := map[string]any{"type": []any{"number", "string"}, "pattern": lengthPattern}
+	for constraint, constraintValue := range constraints {
+		schema[constraint] = constraintValue
+	}
+	return schema
+}
+
+func schemaEnum(values ...string) []any {
+	var enum []any
+	for _, value := range values {
+		enum = append(enum, value)
+	}
+	return enum
+}
+
+// The accepted spellings of a shape type (e.g. 'line', 'Line' and 'LINE')
+func shapeTypeEnum(shapeType string) []any {
+	return schemaEnum(shapeType, strings.ToUpper(shapeType[:1])+shapeType[1:], strings.ToUpper(shapeType))
+}
+
+func shapeSchema(shapeType string, properties map[string]any, required ...string) map[string]any {
+	properties["type"] = map[string]any{"enum": shapeTypeEnum(shapeType)}
+
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             append([]any{"type"}, schemaEnum(required...)...),
+		"additionalProperties": false,
+	}
+}
+
+// Returns the JSON Schema of the config. The types and standard values of the parameters are derived from the
+// standard config so that new parameters are part of the schema automatically.
+func getConfigSchema() map[string]any {
+	point := map[string]any{"$ref": "#/$defs/point"}
+	number := map[string]any{"type": "number"}
+	length := lengthSchema(nil)
+	positiveLength := lengthSchema(map[string]any{"exclusiveMinimum": 0})
+	points := func(minItems int) map[string]any {
+		return map[string]any{"type": "array", "items": point, "minItems": minItems}
+	}
+
+	// A font is either one of the static fonts or the path to an SVG font file
+	fontPatterns := []string{`.*\.[sS][vV][gG]`}
+	for _, name := range slices.Sorted(maps.Keys(Fonts)) {
+		fontPatterns = append(fontPatterns, regexp.QuoteMeta(name))
+	}
+	font := map[string]any{"type": "string", "pattern": "^(" + strings.Join(fontPatterns, "|") + ")$"}
+
+	definitions := map[string]any{
+		"point": map[string]any{
+			"type":     "array",
+			"items":    length,
+			"minItems": 2,
+			"maxItems": 2,
+		},
+		"shape": map[string]any{
+			"oneOf": []any{
+				shapeSchema("line", map[string]any{"p1": point, "p2": point}, "p1", "p2"),
+				shapeSchema("rectangle", map[string]any{"topLeft": point, "width": positiveLength, "height": positiveLength}, "topLeft", "width", "height"),
+				shapeSchema("triangle", map[string]any{"p1": point, "p2": point, "p3": point}, "p1", "p2", "p3"),
+				shapeSchema("circle", map[string]any{"center": point, "radius": positiveLength}, "center", "radius"),
+				shapeSchema("polyline", map[string]any{"points": points(3)}, "points"),
+				shapeSchema("polygon", map[string]any{"points": points(3)}, "points"),
+				shapeSchema("text", map[string]any{
+					"text":       map[string]any{"type": "string"},
+					"lineHeight": positiveLength,
+					"center":     point,
+					"font":       font,
+				}, "text", "lineHeight", "center"),
+				shapeSchema("group", map[string]any{
+					"shapes": map[string]any{"type": "array", "items": map[string]any{"$ref": "#/$defs/shape"}, "minItems": 1},
+				}, "shapes"),
+			},
+		},
+		"region": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"mask":                map[string]any{"type": "string"},
+				"polygon":             points(3),
+				"shapes":              map[string]any{"type": "array", "items": map[string]any{"$ref": "#/$defs/shape"}},
+				"darknessThreshold":   number,
+				"shapeDarknessFactor": map[string]any{"type": "number", "exclusiveMinimum": 0},
+				"strokeWidth":         lengthSchema(map[string]any{"minimum": 0}),
+				"strokeColor":         map[string]any{"type": "string"},
+			},
+			"additionalProperties": false,
+		},
+	}
+
+	standardConfig := NewConfig()
+	properties := make(map[string]any)
+	for key, value := range standardConfig.toMap() {
+		property := map[string]any{"default": value}
+		switch value.(type) {
+		case bool:
+			property["type"] = "boolean"
+		case int:
+			property["type"] = "integer"
+		case float64:
+			property["type"] = "number"
+		case string:
+			property["type"] = "string"
+		}
+		for constraint, constraintValue := range configConstraints[key] {
+			property[constraint] = constraintValue
+		}
+		if slices.Contains(configSchemaLengths, key) {
+			property["type"] = []any{"number", "string"}
+			property["pattern"] = lengthPattern
+		}
+		properties[key] = property
+	}
+
+	properties["inputPath"] = map[string]any{"type": []any{"string", "array"}, "items": map[string]any{"type": "string"}, "default": ""}
+	// A single length for all sides or the lengths for [vertical, horizontal] or [top, right, bottom, left]
+	margin := lengthSchema(map[string]any{"minimum": 0, "default": 0, "items": lengthSchema(map[string]any{"minimum": 0}), "minItems": 2, "maxItems": 4})
+	margin["type"] = []any{"number", "string", "array"}
+	properties["margin"] = margin
+	properties["canvas"] = map[string]any{
+		"type": []any{"string", "object"},
+		"properties": map[string]any{
+			"type":         map[string]any{"enum": schemaEnum(CanvasRectangle, CanvasCircle, CanvasEllipse, CanvasRoundedRectangle, CanvasPolygon, CanvasPath)},
+			"cornerRadius": lengthSchema(map[string]any{"minimum": 0}),
+			"points":       points(3),
+			"d":            map[string]any{"type": "string"},
+		},
+		"required":             []any{"type"},
+		"additionalProperties": false,
+		"default":              CanvasRectangle,
+	}
+	properties["regions"] = map[string]any{"type": "array", "items": map[string]any{"$ref": "#/$defs/region"}, "default": []any{}}
+	properties["shapes"] = map[string]any{"type": "array", "items": map[string]any{"$ref": "#/$defs/shape"}}
+	// Allows configs to reference the schema for completion in editors
+	properties["$schema"] = map[string]any{"type": "string"}
+	references := map[string]any{"type": []any{"string", "array"}, "items": map[string]any{"type": "string"}}
+	properties[extendsKey] = references
+	properties[includeKey] = references
+
+	return map[string]any{
+		"$schema":              "https://json-schema.org/draft/2020-12/schema",
+		"title":                "Vecart Configuration",
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+		"$defs":                definitions,
+	}
+}
+
+func getConfigSchemaJSON() string {
+	jsonBytes, err := json.MarshalIndent(getConfigSchema(), "", "    ")
+	if err != nil {
+		fmt.Printf("Error ocurred while parsing schema to json %T\n", err)
+		return ""
+	}
+
+	return string(jsonBytes)
+}
+
+// Validates the parsed JSON config against the schema and returns all violations with their paths
+func validateConfigSchema(jsonData any) []ConfigError {
+	schema := getConfigSchema()
+	return validateAgainstSchema(jsonData, schema, "", schema["$defs"].(map[string]any))
+}
+
+// Validates the value against the subset of JSON Schema used by getConfigSchema. 'oneOf' is only used for shapes and
+// is resolved by the shape type so that the errors concern the matching shape definition.
+func validateAgainstSchema(value any, schema map[string]any, path string, definitions map[string]any) []ConfigError {
+	if ref, ok := schema["$ref"].(string); ok {
+		return validateAgainstSchema(value, definitions[strings.TrimPrefix(ref, "#/$defs/")].(map[string]any), path, definitions)
+	}
+
+	if options, ok := schema["oneOf"].([]any); ok {
+		return validateShapeSchema(value, options, path, definitions)
+	}
+
+	if expectedType, ok := schema["type"]; ok && !matchesSchemaType(value, expectedType) {
+		return []ConfigError{{path, "Unexpected type " + getJSONType(value) + " | expected " + formatSchemaType(expectedType)}}
+	}
+
+	var configErrors []ConfigError
+
+	if enum, ok := schema["enum"].([]any); ok && !slices.Contains(enum, value) {
+		var options []string
+		for _, option := range enum {
+			options = append(options, fmt.Sprintf("'%v'", option))
+		}
+		configErrors = append(configErrors, ConfigError{path, fmt.Sprintf("Invalid value '%v' | expected one of %s", value, strings.Join(options, ", "))})
+	}
+
+	if text, ok := value.(string); ok {
+		if pattern, ok := schema["pattern"].(string); ok && !regexp.MustCompile(pattern).MatchString(text) {
+			message := fmt.Sprintf("Invalid value '%s' | expected a value matching '%s'", text, pattern)
+			if pattern == lengthPattern {
+				message = fmt.Sprintf("Invalid length '%s' | expected a number with one of the units mm, cm, in, pt or px", text)
+			}
+			configErrors = append(configErrors, ConfigError{path, message})
+		}
+	}
+
+	if number, ok := value.(float64); ok {
+		if minimum, ok := getSchemaNumber(schema, "minimum"); ok && number < minimum {
+			configErrors = append(configErrors, ConfigError{path, "Value must be greater or equal to " + formatSchemaNumber(minimum)})
+		}
+		if minimum, ok := getSchemaNumber(schema, "exclusiveMinimum"); ok && number <= minimum {
+			configErrors = append(configErrors, ConfigError{path, "Value must be greater than " + formatSchemaNumber(minimum)})
+		}
+		if maximum, ok := getSchemaNumber(schema, "maximum"); ok && number > maximum {
+			configErrors = append(configErrors, ConfigError{path, "Value must be less or equal to " + formatSchemaNumber(maximum)})
+		}
+	}
+
+	if array, ok := value.([]any); ok {
+		if minItems, ok := getSchemaNumber(schema, "minItems"); ok && float64(len(array)) < minItems {
+			configErrors = append(configErrors, ConfigError{path, "Array must contain at least " + formatSchemaNumber(minItems) + " items"})
+		}
+		if maxItems, ok := getSchemaNumber(schema, "maxItems"); ok && float64(len(array)) > maxItems {
+			configErrors = append(configErrors, ConfigError{path, "Array must contain at most " + formatSchemaNumber(maxItems) + " items"})
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for index, item := range array {
+				configErrors = append(configErrors, validateAgainstSchema(item, items, path+"["+strconv.Itoa(index)+"]", definitions)...)
+			}
+		}
+	}
+
+	if object, ok := value.(map[string]any); ok {
+		properties, _ := schema["properties"].(map[string]any)
+
+		if required, ok := schema["required"].([]any); ok {
+			for _, key := range required {
+				if _, ok := object[key.(string)]; !ok {
+					configErrors = append(configErrors, ConfigError{joinSchemaPath(path, key.(string)), "Missing required attribute"})
+				}
+			}
+		}
+
+		for _, key := range slices.Sorted(maps.Keys(object)) {
+			property, ok := properties[key].(map[string]any)
+			if ok {
+				configErrors = append(configErrors, validateAgainstSchema(object[key], property, joinSchemaPath(path, key), definitions)...)
+				continue
+			}
+
+			if schema["additionalProperties"] == false {
+				configErrors = append(configErrors, ConfigError{joinSchemaPath(path, key), getUnknownKeyMessage(key, properties)})
+			}
+		}
+	}
+
+	return configErrors
+}
+
+func validateShapeSchema(value any, options []any, path string, definitions map[string]any) []ConfigError {
+	shape, ok := value.(map[string]any)
+	if !ok {
+		return []ConfigError{{path, "Unexpected type " + getJSONType(value) + " | expected object"}}
+	}
+
+	shapeType, ok := shape["type"]
+	if !ok {
+		return []ConfigError{{joinSchemaPath(path, "type"), "Missing required attribute"}}
+	}
+
+	var shapeTypes []string
+	for _, option := range options {
+		optionSchema := option.(map[string]any)
+		typeEnum := optionSchema["properties"].(map[string]any)["type"].(map[string]any)["enum"].([]any)
+		if slices.Contains(typeEnum, shapeType) {
+			return validateAgainstSchema(value, optionSchema, path, definitions)
+		}
+		shapeTypes = append(shapeTypes, fmt.Sprintf("'%v'", typeEnum[0]))
+	}
+
+	return []ConfigError{{joinSchemaPath(path, "type"), fmt.Sprintf("Invalid shape type '%v' | expected one of %s", shapeType, strings.Join(shapeTypes, ", "))}}
+}
+
+func getUnknownKeyMessage(key string, properties map[string]any) string {
+	bestDistance := math.MaxInt
+	bestCorrectKey := ""
+	for _, correctKey := range slices.Sorted(maps.Keys(properties)) {
+		distance := levenshteinDistance(key, correctKey)
+		if distance < bestDistance {
+			bestDistance = distance
+			bestCorrectKey = correctKey
+		}
+	}
+
+	if bestCorrectKey == "" {
+		return "Unkown Key '" + key + "'"
+	}
+
+	return "Unkown Key '" + key + "'. Did you mean '" + bestCorrectKey + "'?"
+}
+
+func matchesSchemaType(value any, expectedType any) bool {
+	if types, ok := expectedType.([]any); ok {
+		return slices.ContainsFunc(types, func(option any) bool { return matchesSchemaType(value, option) })
+	}
+
+	actualType := getJSONType(value)
+	if expectedType == "integer" {
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number)
+	}
+
+	return actualType == expectedType
+}
+
+func getJSONType(value any) string {
+	switch value.(type) {
+	case bool:
+		return "boolean"
+	case float64, int:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	case nil:
+		return "null"
+	}
+
+	return fmt.Sprintf("%T", value)
+}
+
+func formatSchemaType(expectedType any) string {
+	if types, ok := expectedType.([]any); ok {
+		var names []string
+		for _, name := range types {
+			names = append(names, fmt.Sprint(name))
+		}
+		return strings.Join(names, " or ")
+	}
+
+	return fmt.Sprint(expectedType)
+}
+
+func getSchemaNumber(schema map[string]any, key string) (float64, bool) {
+	switch value := schema[key].(type) {
+	case int:
+		return float64(value), true
+	case float64:
+		return value, true
+	}
+
+	return 0, false
+}
+
+func formatSchemaNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// Returns the errors of the last parsed config in the order they occured. Errors that do not concern a specific
+// parameter (e.g. a missing input image) have an empty path.
+func getStructuredErrors() []ConfigError {
+	var structuredErrors []ConfigError
+	for _, errorString := range errors {
+		index := slices.IndexFunc(configErrors, func(configError ConfigError) bool { return configError.String() == errorString })
+		if index >= 0 {
+			structuredErrors = append(structuredErrors, configErrors[index])
+		} else {
+			structuredErrors = append(structuredErrors, ConfigError{"", errorString})
+		}
+	}
+
+	return structuredErrors
+}
+
+func joinSchemaPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+// Validates the config file with the checks of VecartConfig.fromJSON, which include the schema validation. Returns the
+// exit code.
+func runValidate(args []string) int {
+	outputJSON := false
+	var configPath string
+	for _, arg := range args {
+		if arg == "--json" || arg == "-json" {
+			outputJSON = true
+		} else {
+			configPath = arg
+		}
+	}
+
+	if configPath == "" {
+		printUsage()
+		return ExitUsage
+	}
+
+	content, _, err := loadConfigFile(configPath)
+	if _, ok := err.(*ConfigReadError); ok {
+		fmt.Fprintln(os.Stderr, "Could not read config from '"+configPath+"'")
+		return ExitIOError
+	}
+
+	Fonts = loadFonts()
+
+	var validationErrors []ConfigError
+	var jsonData map[string]any
+	if err != nil {
+		validationErrors = append(validationErrors, ConfigError{"", err.Error()})
+	} else if err = json.Unmarshal([]byte(content), &jsonData); err != nil {
+		validationErrors = append(validationErrors, ConfigError{"", "Invalid JSON: " + err.Error()})
+	} else {
+		// fromJSON prints the errors itself in debug mode
+		jsonData["debug"] = false
+		jsonBytes, _ := json.Marshal(jsonData)
+
+		errors = nil
+		config := NewConfig()
+		if !config.fromJSON(string(jsonBytes)) {
+			validationErrors = getStructuredErrors()
+		}
+	}
+
+	if outputJSON {
+		errorMaps := []map[string]any{}
+		for index := range validationErrors {
+			errorMaps = append(errorMaps, validationErrors[index].toMap())
+		}
+		jsonBytes, _ := json.MarshalIndent(map[string]any{"valid": len(validationErrors) == 0, "errors": errorMaps}, "", "    ")
+		fmt.Println(string(jsonBytes))
+	} else if len(validationErrors) == 0 {
+		fmt.Printf("'%s' is valid\n", configPath)
+	} else {
+		fmt.Printf("'%s' is invalid:\n", configPath)
+		for index := range validationErrors {
+			fmt.Println("   " + validationErrors[index].String())
+		}
+	}
+
+	if len(validationErrors) != 0 {
+		return ExitConfigError
+	}
+
+	return ExitSuccess
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"math"
+)
+
+const (
+	ScoringDarkness         = "darkness"
+	ScoringSquaredError     = "squaredError"
+	ScoringBlur             = "blur"
+	ScoringLengthNormalized = "lengthNormalized"
+	ScoringOverlap          = "overlap"
+)
+
+// A scoring function rates how much a shape improves the artwork. Higher scores are better.
+type ShapeScorer interface {
+	score(effect *ShapeEffect) float64
+	// Reports if the scorer reads the pixels and residuals of the effect, which are only collected if needed
+	usesResiduals() bool
+}
+
+var shapeScorers = map[string]ShapeScorer{
+	ScoringDarkness:         DarknessScorer{},
+	ScoringSquaredError:     SquaredErrorScorer{},
+	ScoringBlur:             BlurScorer{},
+	ScoringLengthNormalized: LengthNormalizedScorer{},
+	ScoringOverlap:          OverlapScorer{},
+}
+
+func getShapeScorer() ShapeScorer {
+	return shapeScorers[Config.scoringFunction]
+}
+
+// Describes the effect a shape has on the neighborhood (the quadrant and its neighbors) it is placed in.
+// The same data is collected when placing new shapes and when rating already placed shapes during the refinement.
+type ShapeEffect struct {
+	shape             *Shape
+	intersectedPixels []*Pixel
+	overlappingPixels int
+	// True if the shape is already placed and rated during the refinement
+	placed bool
+
+	darknessWithout float64
+	darknessWith    float64
+
+	// The residual darkness of every pixel in the neighborhood without and with the shape. Both slices use the order of pixels.
+	pixels           []*Pixel
+	residualsWithout []float64
+	residualsWith    []float64
+}
+
+func (effect *ShapeEffect) getDarknessReduction() float64 {
+	return effect.darknessWithout - effect.darknessWith
+}
+
+// Returns the neighborhood darkness reduction per intersected pixel
+func (effect *ShapeEffect) getDarknessScore() float64 {
+	return effect.getDarknessReduction() / float64(len(effect.intersectedPixels))
+}
+
+func (effect *ShapeEffect) getWhitePunishment() float64 {
+	punishment := 0.0
+	for _, pixel := range effect.intersectedPixels {
+		if pixel.Darkness <= Config.whitePunishmentBoundry {
+			punishment += Config.whitePunishmentValue
+		}
+	}
+
+	return punishment
+}
+
+// The original heuristic. Neighborhood darkness reduction per intersected pixel minus the white punishment. Like
+// before the scoring functions were introduced, placed shapes are rated without the white punishment.
+type DarknessScorer struct{}
+
+func (DarknessScorer) usesResiduals() bool { return false }
+
+func (DarknessScorer) score(effect *ShapeEffect) float64 {
+	if effect.placed {
+		return effect.getDarknessScore()
+	}
+
+	intersections := float64(len(effect.intersectedPixels))
+	return (effect.getDarknessReduction() / intersections) - (effect.getWhitePunishment() / intersections)
+}
+
+// Reduction of the squared error between the target tone and the tone produced by the shapes. Unlike the
+// adjusted darkness the error also grows if pixels are drawn darker than they are in the input image.
+type SquaredErrorScorer struct{}
+
+func (SquaredErrorScorer) usesResiduals() bool { return true }
+
+func (SquaredErrorScorer) score(effect *ShapeEffect) float64 {
+	return (sumOfSquares(effect.residualsWithout) - sumOfSquares(effect.residualsWith)) / float64(len(effect.pixels))
+}
+
+// Reduction of the squared error between the blurred input image and the blurred shapes. This resembles how the
+// artwork is perceived from a distance and favors shapes that produce an even tone over shapes that hit single dark pixels.
+type BlurScorer struct{}
+
+func (BlurScorer) usesResiduals() bool { return true }
+
+func (BlurScorer) score(effect *ShapeEffect) float64 {
+	errorWithout := sumOfSquares(blurResiduals(effect.pixels, effect.residualsWithout))
+	errorWith := sumOfSquares(blurResiduals(effect.pixels, effect.residualsWith))
+
+	return (errorWithout - errorWith) / float64(len(effect.pixels))
+}
+
+// Neighborhood darkness reduction and white punishment per pixel of shape length, which prevents short shapes
+// that intersect many pixels (e.g. diagonal lines) from being preferred.
+type LengthNormalizedScorer struct{}
+
+func (LengthNormalizedScorer) usesResiduals() bool { return false }
+
+func (LengthNormalizedScorer) score(effect *ShapeEffect) float64 {
+	length := effect.shape.getLength()
+	if length == 0 {
+		return 0
+	}
+
+	return (effect.getDarknessReduction() - effect.getWhitePunishment()) / length
+}
+
+// The original heuristic with an additional punishment for every intersected pixel that is already covered by
+// other shapes, which spreads the shapes more evenly.
+type OverlapScorer struct{}
+
+func (OverlapScorer) usesResiduals() bool { return false }
+
+func (OverlapScorer) score(effect *ShapeEffect) float64 {
+	intersections := float64(len(effect.intersectedPixels))
+	punishment := effect.getWhitePunishment() + float64(effect.overlappingPixels)*Config.overlapPunishmentValue
+
+	return (effect.getDarknessReduction() / intersections) - (punishment / intersections)
+}
+
+func sumOfSquares(values []float64) float64 {
+	sum := 0.0
+	for _, value := range values {
+		sum += value * value
+	}
+
+	return sum
+}
+
+// Blurs the residuals with a 3x3 gaussian kernel. Pixels outside the neighborhood are ignored and the kernel is
+// normalized over the remaining pixels.
+func blurResiduals(pixels []*Pixel, residuals []float64) []float64 {
+	minX, minY := math.MaxInt, math.MaxInt
+	maxX, maxY := math.MinInt, math.MinInt
+	for _, pixel := range pixels {
+		minX, minY = min(minX, pixel.X1), min(minY, pixel.Y1)
+		maxX, maxY = max(maxX, pixel.X1), max(maxY, pixel.Y1)
+	}
+
+	width := maxX - minX + 1
+	grid := make([]float64, width*(maxY-minY+1))
+	present := make([]bool, len(grid))
+	for index, pixel := range pixels {
+		grid[(pixel.Y1-minY)*width+pixel.X1-minX] = residuals[index]
+		present[(pixel.Y1-minY)*width+pixel.X1-minX] = true
+	}
+
+	kernel := [3][3]float64{{1, 2, 1}, {2, 4, 2}, {1, 2, 1}}
+	blurred := make([]float64, len(pixels))
+	for index, pixel := range pixels {
+		sum, weight := 0.0, 0.0
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				x, y := pixel.X1-minX+dx, pixel.Y1-minY+dy
+				if x < 0 || y < 0 || x >= width || y > maxY-minY || !present[y*width+x] {
+					continue
+				}
+				sum += grid[y*width+x] * kernel[dy+1][dx+1]
+				weight += kernel[dy+1][dx+1]
+			}
+		}
+		blurred[index] = sum / weight
+	}
+
+	return blurred
+}
+
+// Returns the neighborhood of the quadrant. The quadrant itself is always the first element. Quadrants outside of the
+// canvas are left out since no shapes are drawn there.
+func (quadrant *Quadrant) getNeighborhood() []*Quadrant {
+	neighborhood := []*Quadrant{quadrant}
+	for _, neighbor := range quadrant.Neighbors {
+		if !neighbor.outsideCanvas {
+			neighborhood = append(neighborhood, neighbor)
+		}
+	}
+
+	return neighborhood
+}
+
+// Appends the residual darkness of every pixel. Unlike the adjusted darkness the residual darkness is negative if
+// the pixel is covered by more shapes than necessary. The caller must hold the accessMutex.
+func (quadrant *Quadrant) appendResiduals(residuals []float64) []float64 {
+	for _, pixel := range quadrant.FlattenPixels {
+		residuals = append(residuals, pixel.getResidualDarkness(quadrant.region.shapeDarknessFactor))
+	}
+
+	return residuals
+}
+
+// Counts the intersected pixels that are also intersected by other shapes. Must be called while the shape is added.
+func countOverlappingPixels(intersectedPixels []*Pixel) int {
+	ownIntersections := make(map[*Pixel]int)
+	for _, pixel := range intersectedPixels {
+		ownIntersections[pixel]++
+	}
+
+	overlappingPixels := 0
+	for pixel, intersections := range ownIntersections {
+		if pixel.LineIntersects > intersections {
+			overlappingPixels++
+		}
+	}
+
+	return overlappingPixels
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func equalFloats(values, otherValues []float64) bool {
+	return slices.EqualFunc(values, otherValues, func(a, b float64) bool { return math.Abs(a-b) < 1e-9 })
+}
+
+func TestShapeScorers(t *testing.T) {
+	resetStaticVariables()
+	Config.whitePunishmentBoundry = 5
+	Config.whitePunishmentValue = 2
+	Config.overlapPunishmentValue = 3
+
+	white, dark := &Pixel{Darkness: 5}, &Pixel{Darkness: 200}
+	effect := ShapeEffect{
+		shape:             NewShape([]Polyline{{[]Point{{0, 0}, {3, 4}}, nil}}),
+		intersectedPixels: []*Pixel{white, white, dark, dark},
+		overlappingPixels: 2,
+		darknessWithout:   10,
+		darknessWith:      6,
+		pixels:            []*Pixel{{X1: 0}, {X1: 1}},
+		residualsWithout:  []float64{3, 4},
+		residualsWith:     []float64{0, 1},
+	}
+
+	testCases := []struct {
+		scoringFunction string
+		expected        float64
+	}{
+		// Darkness reduction of 4 and white punishment of 4 for 4 intersected pixels
+		{ScoringDarkness, 4.0/4 - 4.0/4},
+		{ScoringSquaredError, (25.0 - 1.0) / 2},
+		// Both pixels are neighbors, so the blur averages them with the weights 4 and 2
+		{ScoringBlur, (math.Pow(22.0/6, 2) + math.Pow(20.0/6, 2) - math.Pow(2.0/6, 2) - math.Pow(4.0/6, 2)) / 2},
+		{ScoringLengthNormalized, (4.0 - 4.0) / 5},
+		{ScoringOverlap, 4.0/4 - (4.0+2*3)/4},
+	}
+	for _, testCase := range testCases {
+		Config.scoringFunction = testCase.scoringFunction
+		if score := getShapeScorer().score(&effect); math.Abs(score-testCase.expected) > 1e-9 {
+			t.Errorf("%s: expected the score %v, got %v", testCase.scoringFunction, testCase.expected, score)
+		}
+	}
+
+	// The pixels and residuals are only collected for the scorers that read them
+	for scoringFunction, scorer := range shapeScorers {
+		if usesResiduals := scoringFunction == ScoringSquaredError || scoringFunction == ScoringBlur; scorer.usesResiduals() != usesResiduals {
+			t.Errorf("%s: expected usesResiduals to be %v", scoringFunction, usesResiduals)
+		}
+	}
+
+	// The refinement rates placed shapes without the white punishment like before the scoring functions were introduced
+	Config.scoringFunction = ScoringDarkness
+	effect.placed = true
+	if score := getShapeScorer().score(&effect); score != 1 {
+		t.Errorf("Placed shapes have to be rated by their darkness reduction per intersected pixel, not %v", score)
+	}
+
+	Config.scoringFunction = ScoringLengthNormalized
+	effect.shape = NewShape([]Polyline{{[]Point{{1, 1}, {1, 1}}, nil}})
+	if score := getShapeScorer().score(&effect); score != 0 {
+		t.Errorf("Shapes without length have to be rated with 0, not %v", score)
+	}
+	resetStaticVariables()
+}
+
+func TestBlurResiduals(t *testing.T) {
+	row := []*Pixel{{X1: 0, Y1: 0}, {X1: 1, Y1: 0}, {X1: 2, Y1: 0}}
+	if blurred := blurResiduals(row, []float64{0, 4, 0}); !equalFloats(blurred, []float64{8.0 / 6, 2, 8.0 / 6}) {
+		t.Errorf("Unexpected blurred row %v", blurred)
+	}
+
+	// The center of a full 3x3 block is blurred with the whole kernel, pixels outside the neighborhood are ignored
+	var block []*Pixel
+	residuals := make([]float64, 9)
+	for y := range 3 {
+		for x := range 3 {
+			block = append(block, &Pixel{X1: x + 10, Y1: y + 20})
+		}
+	}
+	residuals[4] = 16
+	blurred := blurResiduals(block, residuals)
+	if !equalFloats(blurred, []float64{16.0 / 9, 32.0 / 12, 16.0 / 9, 32.0 / 12, 4, 32.0 / 12, 16.0 / 9, 32.0 / 12, 16.0 / 9}) {
+		t.Errorf("Unexpected blurred block %v", blurred)
+	}
+
+	if blurred := blurResiduals([]*Pixel{{X1: 3, Y1: 3}}, []float64{-5}); !equalFloats(blurred, []float64{-5}) {
+		t.Errorf("A single pixel has to keep its residual, not %v", blurred)
+	}
+}
+
+func TestCountOverlappingPixels(t *testing.T) {
+	// Intersected twice by the shape and by nothing else
+	own := &Pixel{LineIntersects: 2}
+	// Intersected once by the shape and twice by other shapes
+	shared := &Pixel{LineIntersects: 3}
+	other := &Pixel{LineIntersects: 1}
+
+	if overlapping := countOverlappingPixels([]*Pixel{own, shared, own}); overlapping != 1 {
+		t.Errorf("Expected 1 overlapping pixel, got %d", overlapping)
+	}
+	if overlapping := countOverlappingPixels([]*Pixel{other}); overlapping != 0 {
+		t.Errorf("Expected no overlapping pixel, got %d", overlapping)
+	}
+	if overlapping := countOverlappingPixels(nil); overlapping != 0 {
+		t.Errorf("Expected no overlapping pixel without intersections, got %d", overlapping)
+	}
+}
@@ -0,0 +1,529 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusFinished  = "finished"
+	JobStatusFailed    = "failed"
+	JobStatusCancelled = "cancelled"
+)
+
+const maxJobUploadSize = 64 << 20
+
+// The files a finished job can provide. The key is used in the download URL.
+var jobResults = map[string]string{
+	"svg":        "output.svg",
+	"png":        "output_preview.png",
+	"comparison": "output_comparison.png",
+	"metrics":    "output_metrics.json",
+}
+
+// A job generates one artwork. Since the generation relies on global state the server runs one job at a time.
+type Job struct {
+	id              string
+	status          string
+	directory       string
+	config          VecartConfig
+	userConfig      string
+	submitted       time.Time
+	ended           time.Time
+	cancelRequested bool
+
+	// The shapes placed so far (in pixels of the processingDpi) and the size of the canvas. Only recorded for the UI.
+	// The revision is increased whenever shapes were removed and the UI has to redraw all shapes.
+	liveShapes   [][][2]float64
+	liveRevision int
+	canvasWidth  int
+	canvasHeight int
+}
+
+type Server struct {
+	jobs      map[string]*Job
+	jobOrder  []string
+	nextJobId int
+	directory string
+	// How long the results of ended jobs are kept before the job and its directory are removed
+	retention time.Duration
+	queue     chan *Job
+	running   *Job
+	mutex     sync.Mutex
+}
+
+// Parsing the config uses the global errors and must not happen concurrently
+var configParsingMutex sync.Mutex
+
+func NewServer(directory string, retention time.Duration) *Server {
+	return &Server{jobs: make(map[string]*Job), directory: directory, retention: retention, queue: make(chan *Job, 1000)}
+}
+
+// Starts the HTTP server. Blocks until the server fails or is interrupted.
+func runServer(args []string) bool {
+	return startServer("serve", args, false)
+}
+
+// Starts the HTTP server and, if withUI is set, the web UI with the live view of placed shapes
+func startServer(command string, args []string, withUI bool) bool {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	addr := flags.String("addr", "localhost:8080", "The address the server listens on")
+	directory := flags.String("directory", "", "The directory the images and results of jobs are stored in (default: a temporary directory)")
+	retention := flags.Duration("retention", time.Hour, "How long the results of a job are kept after it ended")
+	if flags.Parse(args) != nil {
+		return false
+	}
+
+	if *directory == "" {
+		temporaryDirectory, err := os.MkdirTemp("", "vecart-server-")
+		if err != nil {
+			fmt.Println("Could not create a temporary directory for the jobs!")
+			fmt.Println(err)
+			return false
+		}
+		*directory = temporaryDirectory
+		defer os.RemoveAll(temporaryDirectory)
+	}
+
+	Fonts = loadFonts()
+
+	server := NewServer(*directory, *retention)
+	if withUI {
+		shapePlacedListener = server.addLiveShape
+		shapesChangedListener = server.resetLiveShapes
+	}
+	go server.runJobs()
+	go server.removeExpiredJobsPeriodically()
+
+	httpServer := &http.Server{Addr: *addr, Handler: server.getHandler(withUI)}
+	// Shuts the server down on Ctrl+C so that the temporary directory is removed
+	go func() {
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+		<-interrupt
+		generationCancelled.Store(true)
+		httpServer.Close()
+	}()
+
+	fmt.Printf("Vecart server listening on %s (jobs are stored in '%s')\n", *addr, *directory)
+	err := httpServer.ListenAndServe()
+	if err == http.ErrServerClosed {
+		return true
+	}
+	fmt.Println(err)
+
+	return false
+}
+
+func (server *Server) getHandler(withUI bool) http.Handler {
+	mux := http.NewServeMux()
+	if withUI {
+		mux.HandleFunc("GET /{$}", handleUI)
+		mux.HandleFunc("GET /jobs/{id}/shapes", server.handleGetLiveShapes)
+	}
+	mux.HandleFunc("POST /jobs", server.handleSubmitJob)
+	mux.HandleFunc("GET /jobs", server.handleListJobs)
+	mux.HandleFunc("GET /jobs/{id}", server.handleGetJob)
+	mux.HandleFunc("DELETE /jobs/{id}", server.handleCancelJob)
+	mux.HandleFunc("GET /jobs/{id}/{result}", server.handleGetResult)
+
+	return mux
+}
+
+// Expects a multipart form with the config JSON in the field 'config' and the image in the field 'image'. Both are
+// optional. Without an image the example image is used.
+func (server *Server) handleSubmitJob(writer http.ResponseWriter, request *http.Request) {
+	err := request.ParseMultipartForm(maxJobUploadSize)
+	if err != nil && err != http.ErrNotMultipart {
+		writeJSONError(writer, http.StatusBadRequest, "Could not parse form: "+err.Error())
+		return
+	}
+
+	server.mutex.Lock()
+	server.nextJobId++
+	job := &Job{id: strconv.Itoa(server.nextJobId), status: JobStatusQueued, submitted: time.Now()}
+	server.mutex.Unlock()
+
+	job.directory = filepath.Join(server.directory, job.id)
+	err = os.MkdirAll(job.directory, 0755)
+	if err != nil {
+		writeJSONError(writer, http.StatusInternalServerError, "Could not create job directory: "+err.Error())
+		return
+	}
+
+	imagePath := ""
+	file, header, err := request.FormFile("image")
+	if err == nil {
+		defer file.Close()
+		imagePath = filepath.Join(job.directory, "input"+filepath.Ext(header.Filename))
+		if !isSupportedImage(imagePath) {
+			os.RemoveAll(job.directory)
+			writeJSONError(writer, http.StatusBadRequest, "Unsupported image format '"+filepath.Ext(header.Filename)+"'")
+			return
+		}
+
+		err = saveUpload(file, imagePath)
+		if err != nil {
+			os.RemoveAll(job.directory)
+			writeJSONError(writer, http.StatusInternalServerError, "Could not save image: "+err.Error())
+			return
+		}
+	}
+
+	config, userConfig, configErrors := parseJobConfig(request.FormValue("config"), imagePath, filepath.Join(job.directory, jobResults["svg"]))
+	if len(configErrors) > 0 {
+		os.RemoveAll(job.directory)
+		writeJSON(writer, http.StatusBadRequest, map[string]any{"errors": configErrors})
+		return
+	}
+	job.config = config
+	job.userConfig = userConfig
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	select {
+	case server.queue <- job:
+	default:
+		os.RemoveAll(job.directory)
+		writeJSONError(writer, http.StatusServiceUnavailable, "Too many queued jobs")
+		return
+	}
+
+	server.jobs[job.id] = job
+	server.jobOrder = append(server.jobOrder, job.id)
+
+	writeJSON(writer, http.StatusAccepted, server.getJobStatus(job))
+}
+
+func (server *Server) handleListJobs(writer http.ResponseWriter, request *http.Request) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	jobs := []map[string]any{}
+	for _, id := range server.jobOrder {
+		jobs = append(jobs, server.getJobStatus(server.jobs[id]))
+	}
+
+	writeJSON(writer, http.StatusOK, map[string]any{"jobs": jobs})
+}
+
+func (server *Server) handleGetJob(writer http.ResponseWriter, request *http.Request) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	job, ok := server.jobs[request.PathValue("id")]
+	if !ok {
+		writeJSONError(writer, http.StatusNotFound, "Unknown job '"+request.PathValue("id")+"'")
+		return
+	}
+
+	writeJSON(writer, http.StatusOK, server.getJobStatus(job))
+}
+
+// Cancels a queued job immediately. A running job ends at the next point the generation checks for cancellation.
+func (server *Server) handleCancelJob(writer http.ResponseWriter, request *http.Request) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	job, ok := server.jobs[request.PathValue("id")]
+	if !ok {
+		writeJSONError(writer, http.StatusNotFound, "Unknown job '"+request.PathValue("id")+"'")
+		return
+	}
+
+	switch job.status {
+	case JobStatusQueued:
+		job.status = JobStatusCancelled
+		job.ended = time.Now()
+		os.RemoveAll(job.directory)
+	case JobStatusRunning:
+		job.cancelRequested = true
+		generationCancelled.Store(true)
+	default:
+		writeJSONError(writer, http.StatusConflict, "Job '"+job.id+"' is already "+job.status)
+		return
+	}
+
+	writeJSON(writer, http.StatusAccepted, server.getJobStatus(job))
+}
+
+func (server *Server) handleGetResult(writer http.ResponseWriter, request *http.Request) {
+	server.mutex.Lock()
+	job, ok := server.jobs[request.PathValue("id")]
+	status := ""
+	if ok {
+		status = job.status
+	}
+	server.mutex.Unlock()
+
+	if !ok {
+		writeJSONError(writer, http.StatusNotFound, "Unknown job '"+request.PathValue("id")+"'")
+		return
+	}
+
+	fileName, ok := jobResults[request.PathValue("result")]
+	if !ok {
+		writeJSONError(writer, http.StatusNotFound, "Unknown result '"+request.PathValue("result")+"'")
+		return
+	}
+
+	if status != JobStatusFinished {
+		writeJSONError(writer, http.StatusConflict, "Job '"+job.id+"' is "+status)
+		return
+	}
+
+	path := filepath.Join(job.directory, fileName)
+	if _, err := os.Stat(path); err != nil {
+		writeJSONError(writer, http.StatusNotFound, "Job '"+job.id+"' has no result '"+request.PathValue("result")+"'")
+		return
+	}
+
+	http.ServeFile(writer, request, path)
+}
+
+// Runs the queued jobs one after another
+func (server *Server) runJobs() {
+	for job := range server.queue {
+		server.mutex.Lock()
+		if job.status == JobStatusCancelled {
+			server.mutex.Unlock()
+			continue
+		}
+		job.status = JobStatusRunning
+		server.running = job
+		generationCancelled.Store(false)
+		setGenerationProgress("", 0)
+		server.mutex.Unlock()
+
+		fmt.Printf("\nStarting job %s\n", job.id)
+
+		configParsingMutex.Lock()
+		resetStaticVariables()
+		configParsingMutex.Unlock()
+		Config = job.config
+		UserConfig = job.userConfig
+
+		finished := runBatch() == ExitSuccess
+
+		server.mutex.Lock()
+		switch {
+		case job.cancelRequested:
+			job.status = JobStatusCancelled
+		case finished:
+			job.status = JobStatusFinished
+		default:
+			job.status = JobStatusFailed
+		}
+		// Only finished jobs have results that can be downloaded
+		if job.status != JobStatusFinished {
+			os.RemoveAll(job.directory)
+		}
+		job.ended = time.Now()
+		server.running = nil
+		server.mutex.Unlock()
+
+		fmt.Printf("\nJob %s %s\n", job.id, job.status)
+	}
+}
+
+func (server *Server) removeExpiredJobsPeriodically() {
+	for {
+		time.Sleep(time.Minute)
+		server.removeExpiredJobs(time.Now())
+	}
+}
+
+// Removes the jobs that ended longer than the retention ago together with their directories
+func (server *Server) removeExpiredJobs(now time.Time) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	server.jobOrder = slices.DeleteFunc(server.jobOrder, func(id string) bool {
+		job := server.jobs[id]
+		if job.ended.IsZero() || now.Sub(job.ended) < server.retention {
+			return false
+		}
+
+		os.RemoveAll(job.directory)
+		delete(server.jobs, id)
+		return true
+	})
+}
+
+// Returns the status of the job as map for the JSON response. Must be called while holding the server mutex.
+func (server *Server) getJobStatus(job *Job) map[string]any {
+	jsonData := make(map[string]any)
+
+	jsonData["id"] = job.id
+	jsonData["status"] = job.status
+	jsonData["submitted"] = job.submitted.Format(time.RFC3339)
+
+	switch job.status {
+	case JobStatusRunning:
+		stage, percentage := getGenerationProgress()
+		jsonData["stage"] = stage
+		jsonData["progress"] = percentage
+	case JobStatusFinished:
+		results := make(map[string]string)
+		for result, fileName := range jobResults {
+			if _, err := os.Stat(filepath.Join(job.directory, fileName)); err == nil {
+				results[result] = "/jobs/" + job.id + "/" + result
+			}
+		}
+		jsonData["results"] = results
+	}
+
+	return jsonData
+}
+
+// Validates the config with VecartConfig.fromJSON. The input and output paths are replaced by the paths of the job.
+// Configs and presets referenced with 'extends' and 'include' are resolved like for config files, but only inside the
+// job directory. Returns the config, the JSON it was parsed from and the errors that occured while parsing.
+func parseJobConfig(content, imagePath, outputPath string) (VecartConfig, string, []string) {
+	jobDirectory := filepath.Dir(outputPath)
+	jsonData := make(map[string]any)
+	if content != "" {
+		content, _, err := resolveConfigContent(content, filepath.Join(jobDirectory, "config.json"), jobDirectory)
+		if err != nil {
+			return NewConfig(), "", []string{"Could not load config: " + err.Error()}
+		}
+
+		err = json.Unmarshal([]byte(content), &jsonData)
+		if err != nil {
+			return NewConfig(), "", []string{"Could not parse config: " + err.Error()}
+		}
+	}
+
+	pathErrors := restrictJobPaths(jsonData, jobDirectory)
+	if len(pathErrors) > 0 {
+		return NewConfig(), "", pathErrors
+	}
+
+	delete(jsonData, "inputPath")
+	if imagePath != "" {
+		jsonData["inputPath"] = imagePath
+	}
+	jsonData["outputPath"] = outputPath
+	jsonData["preview"] = true
+	jsonData["metricsFile"] = true
+
+	jsonBytes, err := json.MarshalIndent(jsonData, "", "    ")
+	if err != nil {
+		return NewConfig(), "", []string{"Could not parse config: " + err.Error()}
+	}
+
+	configParsingMutex.Lock()
+	defer configParsingMutex.Unlock()
+
+	errors = nil
+	config := NewConfig()
+	if !config.fromJSON(string(jsonBytes)) {
+		configErrors := errors
+		if len(configErrors) == 0 {
+			configErrors = []string{"Invalid config"}
+		}
+		return config, "", configErrors
+	}
+
+	return config, string(jsonBytes), nil
+}
+
+// Resolves the relative region masks and font files of the config in the job directory. Jobs must not access other files of the
+// server, so absolute paths and paths that leave the job directory are rejected.
+func restrictJobPaths(jsonData map[string]any, jobDirectory string) []string {
+	var pathErrors []string
+
+	regions, _ := jsonData["regions"].([]any)
+	for index, region := range regions {
+		regionParameters, ok := region.(map[string]any)
+		if !ok {
+			continue
+		}
+		mask, ok := regionParameters["mask"].(string)
+		if !ok {
+			continue
+		}
+
+		if !filepath.IsLocal(mask) {
+			pathErrors = append(pathErrors, fmt.Sprintf("regions[%d].mask: The path '%s' is not allowed. Paths must be relative and must not leave the job directory.", index, mask))
+			continue
+		}
+		regionParameters["mask"] = filepath.Join(jobDirectory, mask)
+	}
+
+	shapes, _ := jsonData["shapes"].([]any)
+	pathErrors = append(pathErrors, restrictJobFontPaths(shapes, "shapes", jobDirectory)...)
+
+	return pathErrors
+}
+
+// Resolves the font files of the text shapes in the job directory. Groups are searched recursively.
+func restrictJobFontPaths(shapes []any, path string, jobDirectory string) []string {
+	var pathErrors []string
+
+	for index, shape := range shapes {
+		shapeParameters, ok := shape.(map[string]any)
+		if !ok {
+			continue
+		}
+		shapePath := fmt.Sprintf("%s[%d]", path, index)
+
+		if groupShapes, ok := shapeParameters["shapes"].([]any); ok {
+			pathErrors = append(pathErrors, restrictJobFontPaths(groupShapes, shapePath+".shapes", jobDirectory)...)
+		}
+
+		font, ok := shapeParameters["font"].(string)
+		if _, static := Fonts[font]; !ok || static {
+			continue
+		}
+
+		if !filepath.IsLocal(font) {
+			pathErrors = append(pathErrors, fmt.Sprintf("%s.font: The path '%s' is not allowed. Paths must be relative and must not leave the job directory.", shapePath, font))
+			continue
+		}
+		shapeParameters["font"] = filepath.Join(jobDirectory, font)
+	}
+
+	return pathErrors
+}
+
+func saveUpload(file io.Reader, path string) error {
+	outputFile, err := createFile(path)
+	if err != nil {
+		return err
+	}
+	defer outputFile.Close()
+
+	_, err = io.Copy(outputFile, file)
+	return err
+}
+
+func writeJSON(writer http.ResponseWriter, status int, value any) {
+	jsonBytes, err := json.MarshalIndent(value, "", "    ")
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	writer.Write(jsonBytes)
+}
+
+func writeJSONError(writer http.ResponseWriter, status int, message string) {
+	writeJSON(writer, status, map[string]any{"errors": []string{message}})
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/draw"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Sends the request to the handler and returns the status and the decoded JSON response
+func sendRequest(t *testing.T, handler http.Handler, method, path string, body *bytes.Buffer, contentType string) (int, map[string]any) {
+	if body == nil {
+		body = &bytes.Buffer{}
+	}
+	request := httptest.NewRequest(method, path, body)
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	var response map[string]any
+	if strings.HasPrefix(recorder.Header().Get("Content-Type"), "application/json") {
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return recorder.Code, response
+}
+
+// Returns a multipart form with the config and, if withImage is set, a small white PNG
+func getJobForm(t *testing.T, config string, withImage bool) (*bytes.Buffer, string) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	if err := writer.WriteField("config", config); err != nil {
+		t.Fatal(err)
+	}
+
+	if withImage {
+		part, err := writer.CreateFormFile("image", "picture.png")
+		if err != nil {
+			t.Fatal(err)
+		}
+		img := image.NewGray(image.Rect(0, 0, 40, 40))
+		draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+		if err = png.Encode(part, img); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writer.Close()
+
+	return body, writer.FormDataContentType()
+}
+
+func submitJob(t *testing.T, handler http.Handler, config string, withImage bool) (int, map[string]any) {
+	body, contentType := getJobForm(t, config, withImage)
+	return sendRequest(t, handler, "POST", "/jobs", body, contentType)
+}
+
+func countDirectoryEntries(t *testing.T, directory string) int {
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return len(entries)
+}
+
+func TestServerRejectsInvalidJobs(t *testing.T) {
+	resetStaticVariables()
+	server := NewServer(t.TempDir(), time.Hour)
+	handler := server.getHandler(false)
+
+	testCases := []struct {
+		config        string
+		expectedError string
+	}{
+		{`{"darknessThreshold": `, "Could not parse config"},
+		{`{"darknessThreshold": "dark"}`, "darknessThreshold"},
+		{`{"regions": [{"mask": "/etc/passwd"}]}`, "regions[0].mask"},
+		{`{"regions": [{"polygon": [[0, 0], [1, 0], [1, 1]]}, {"mask": "../other/mask.png"}]}`, "regions[1].mask"},
+		{`{"extends": "/etc/vecart.json"}`, "not allowed"},
+		{`{"include": ["ellie", "../library.json"]}`, "not allowed"},
+		{`{"extends": "missing.json"}`, "missing.json"},
+		{`{"shapes": [{"type": "group", "shapes": [{"type": "text", "center": [0, 0], "lineHeight": 1, "text": "A", "font": "/fonts/font.svg"}]}]}`, "shapes[0].shapes[0].font"},
+	}
+	for _, testCase := range testCases {
+		status, response := submitJob(t, handler, testCase.config, true)
+		errors, _ := response["errors"].([]any)
+		if status != http.StatusBadRequest || len(errors) == 0 || !strings.Contains(errors[0].(string), testCase.expectedError) {
+			t.Errorf("%s: expected an error about '%s', got %d %v", testCase.config, testCase.expectedError, status, response)
+		}
+	}
+
+	if entries := countDirectoryEntries(t, server.directory); entries != 0 {
+		t.Errorf("The directories of %d rejected jobs were not removed", entries)
+	}
+	if status, response := sendRequest(t, handler, "GET", "/jobs", nil, ""); status != http.StatusOK || len(response["jobs"].([]any)) != 0 {
+		t.Errorf("Rejected jobs must not be listed, got %d %v", status, response)
+	}
+	resetStaticVariables()
+}
+
+func TestRestrictJobPaths(t *testing.T) {
+	jsonData := map[string]any{"regions": []any{map[string]any{"mask": "masks/face.png"}, map[string]any{"polygon": []any{}}}}
+	if pathErrors := restrictJobPaths(jsonData, "/jobs/1"); len(pathErrors) != 0 {
+		t.Errorf("Unexpected errors %v", pathErrors)
+	}
+	if mask := jsonData["regions"].([]any)[0].(map[string]any)["mask"]; mask != filepath.Join("/jobs/1", "masks/face.png") {
+		t.Errorf("The mask has to be resolved in the job directory, got '%s'", mask)
+	}
+
+	jsonData = map[string]any{"shapes": []any{map[string]any{"font": "IBM-Plex-Sans"}, map[string]any{"font": "fonts/font.svg"}}}
+	Fonts = loadFonts()
+	if pathErrors := restrictJobPaths(jsonData, "/jobs/1"); len(pathErrors) != 0 {
+		t.Errorf("Unexpected errors %v", pathErrors)
+	}
+	shapes := jsonData["shapes"].([]any)
+	if shapes[0].(map[string]any)["font"] != "IBM-Plex-Sans" || shapes[1].(map[string]any)["font"] != filepath.Join("/jobs/1", "fonts/font.svg") {
+		t.Errorf("Only font files have to be resolved in the job directory, got %v", shapes)
+	}
+
+	jsonData = map[string]any{"regions": []any{map[string]any{"mask": "masks/../../face.png"}, map[string]any{"mask": ""}}}
+	if pathErrors := restrictJobPaths(jsonData, "/jobs/1"); len(pathErrors) != 2 {
+		t.Errorf("Expected 2 errors, got %v", pathErrors)
+	}
+}
+
+func TestServerCancelQueuedJob(t *testing.T) {
+	resetStaticVariables()
+	// The jobs are not run, so they stay queued
+	server := NewServer(t.TempDir(), time.Hour)
+	handler := server.getHandler(false)
+
+	status, response := submitJob(t, handler, `{"extends": "ellie", "artworkWidth": 20, "artworkHeight": 0}`, true)
+	if status != http.StatusAccepted || response["status"] != JobStatusQueued || response["id"] != "1" {
+		t.Fatalf("Unexpected response %d %v", status, response)
+	}
+	if _, err := os.Stat(filepath.Join(server.directory, "1", "input.png")); err != nil {
+		t.Error("The image was not stored in the job directory")
+	}
+	if shapes := server.jobs["1"].config.shapes; len(shapes) != 3 {
+		t.Errorf("The preset was not applied, got %d shapes", len(shapes))
+	}
+
+	if status, _ := sendRequest(t, handler, "GET", "/jobs/1/svg", nil, ""); status != http.StatusConflict {
+		t.Errorf("Queued jobs have no results, got %d", status)
+	}
+	if status, response := sendRequest(t, handler, "DELETE", "/jobs/1", nil, ""); status != http.StatusAccepted || response["status"] != JobStatusCancelled {
+		t.Errorf("Unexpected response %d %v", status, response)
+	}
+	if status, _ := sendRequest(t, handler, "DELETE", "/jobs/1", nil, ""); status != http.StatusConflict {
+		t.Errorf("Cancelled jobs can not be cancelled again, got %d", status)
+	}
+	if _, err := os.Stat(filepath.Join(server.directory, "1")); err == nil {
+		t.Error("The directory of the cancelled job was not removed")
+	}
+	if status, _ := sendRequest(t, handler, "GET", "/jobs/2", nil, ""); status != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown job, got %d", status)
+	}
+	resetStaticVariables()
+}
+
+func TestServerRunJob(t *testing.T) {
+	resetStaticVariables()
+	server := NewServer(t.TempDir(), time.Hour)
+	handler := server.getHandler(false)
+	go server.runJobs()
+	defer close(server.queue)
+
+	if status, response := submitJob(t, handler, `{"artworkWidth": 20, "artworkHeight": 0}`, true); status != http.StatusAccepted {
+		t.Fatalf("Unexpected response %d %v", status, response)
+	}
+
+	var response map[string]any
+	for start := time.Now(); time.Since(start) < 30*time.Second; time.Sleep(50 * time.Millisecond) {
+		_, response = sendRequest(t, handler, "GET", "/jobs/1", nil, "")
+		if response["status"] != JobStatusQueued && response["status"] != JobStatusRunning {
+			break
+		}
+	}
+	if response["status"] != JobStatusFinished {
+		t.Fatalf("The job did not finish: %v", response)
+	}
+	if results, _ := response["results"].(map[string]any); results["svg"] != "/jobs/1/svg" || results["metrics"] != "/jobs/1/metrics" {
+		t.Errorf("Unexpected results %v", response["results"])
+	}
+
+	request := httptest.NewRequest("GET", "/jobs/1/svg", nil)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), "<svg") {
+		t.Errorf("Could not download the SVG: %d %s", recorder.Code, recorder.Body.String())
+	}
+	if status, _ := sendRequest(t, handler, "GET", "/jobs/1/model", nil, ""); status != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown result, got %d", status)
+	}
+
+	// The job is kept until the retention has passed
+	server.removeExpiredJobs(time.Now())
+	if status, _ := sendRequest(t, handler, "GET", "/jobs/1", nil, ""); status != http.StatusOK {
+		t.Errorf("The job was removed before the retention passed, got %d", status)
+	}
+	server.removeExpiredJobs(time.Now().Add(2 * time.Hour))
+	if status, _ := sendRequest(t, handler, "GET", "/jobs/1", nil, ""); status != http.StatusNotFound {
+		t.Errorf("The expired job was not removed, got %d", status)
+	}
+	if entries := countDirectoryEntries(t, server.directory); entries != 0 {
+		t.Error("The directory of the expired job was not removed")
+	}
+	resetStaticVariables()
+}
@@ -6,13 +6,19 @@ import (
 )
 
 type Shape struct {
-	Lines    []Polyline
-	Variants []Shape
-	centroid Point
+	Lines       []Polyline
+	Variants    []Shape
+	centroid    Point
+	orientation float64
+	anisotropy  float64
+	// The angle (in degrees) by which the variant is rotated relative to the configured shape
+	rotation float64
+	region   *Region
+	coverage *CoverageMap
 }
 
 func NewShape(lines []Polyline) *Shape {
-	shape := Shape{lines, nil, Point{0, 0}}
+	shape := Shape{Lines: lines}
 	shape.calculateCentroid()
 
 	return &shape
@@ -74,7 +80,7 @@ func removePolyline(s []*Polyline, index int) []*Polyline {
 }
 
 func NewSingleLineShape(line Polyline) *Shape {
-	shape := Shape{[]Polyline{line}, nil, Point{0, 0}}
+	shape := Shape{Lines: []Polyline{line}}
 	shape.calculateCentroid()
 
 	return &shape
@@ -244,6 +250,15 @@ func (shape *Shape) getMaxSize() (float64, float64) {
 	return maxX, maxY
 }
 
+func (shape *Shape) getLength() float64 {
+	length := 0.0
+	for index := range shape.Lines {
+		length += shape.Lines[index].getLength()
+	}
+
+	return length
+}
+
 func (shape *Shape) getMaxAndMinCoordinates() (float64, float64, float64, float64) {
 	minX := math.MaxFloat64
 	maxX := math.MaxFloat64 * -1
@@ -268,7 +283,9 @@ func (shape *Shape) generateShapeVariants() {
 	//Test
 
 	if Config.shapeAngleDeviationRange <= 0 {
-		shape.Variants = append(shape.Variants, shape.copy())
+		variant := shape.copy()
+		variant.calculateOrientation()
+		shape.Variants = append(shape.Variants, variant)
 		return
 	}
 
@@ -287,15 +304,40 @@ func (shape *Shape) generateShapeVariants() {
 func (shape *Shape) getVariant(angle float64) {
 	currentVariant := shape.rotateCopy(angle, shape.centroid)
 	currentVariant.centerOnOrigin()
+	currentVariant.rotation = angle
 	for shapeVariantIndex := range shape.Variants {
 		if shape.Variants[shapeVariantIndex].equalTo(currentVariant, 5, true) {
 			return
 		}
 	}
 
+	currentVariant.calculateOrientation()
 	shape.Variants = append(shape.Variants, *currentVariant)
 }
 
+// Calculates the dominant stroke direction (in radians) of the shape and how pronounced it is.
+// An anisotropy of 0 means the shape has no preferred direction (e.g. circles), 1 means it is a straight line.
+func (shape *Shape) calculateOrientation() {
+	var txx, txy, tyy float64
+
+	for lineIndex := range shape.Lines {
+		points := shape.Lines[lineIndex].points
+		for pointIndex := 1; pointIndex < len(points); pointIndex++ {
+			dx := points[pointIndex].X - points[pointIndex-1].X
+			dy := points[pointIndex].Y - points[pointIndex-1].Y
+			length := math.Sqrt(dx*dx + dy*dy)
+			if length == 0 {
+				continue
+			}
+			txx += dx * dx / length
+			txy += dx * dy / length
+			tyy += dy * dy / length
+		}
+	}
+
+	shape.orientation, shape.anisotropy = getTensorOrientation(txx, txy, tyy)
+}
+
 func (shape *Shape) isSingleCircle() bool {
 	if len(shape.Lines) != 1 {
 		return false
@@ -317,6 +359,8 @@ func (shape *Shape) rotate(angle float64, origin Point) {
 	for index := range shape.Lines {
 		shape.Lines[index].rotate(angle, origin)
 	}
+	shape.calculateCentroid()
+	shape.coverage = nil
 }
 
 func (shape *Shape) rotateCopy(angle float64, origin Point) *Shape {
@@ -331,6 +375,7 @@ func (shape *Shape) transform(x float64, y float64) {
 		shape.Lines[index].transform(x, y)
 	}
 	shape.calculateCentroid()
+	shape.coverage = nil
 }
 
 func (shape *Shape) scale(factor float64) {
@@ -341,6 +386,7 @@ func (shape *Shape) scale(factor float64) {
 	}
 
 	shape.calculateCentroid()
+	shape.coverage = nil
 }
 
 func (shape *Shape) mmToPixel(dpi float64) {
@@ -348,6 +394,7 @@ func (shape *Shape) mmToPixel(dpi float64) {
 		shape.Lines[index].mmToPixel(dpi)
 	}
 	shape.calculateCentroid()
+	shape.coverage = nil
 }
 
 func (shape *Shape) pixelToMM(dpi float64) {
@@ -355,6 +402,7 @@ func (shape *Shape) pixelToMM(dpi float64) {
 		shape.Lines[index].pixelToMM(dpi)
 	}
 	shape.calculateCentroid()
+	shape.coverage = nil
 }
 
 func (shape *Shape) scaleCopy(factor float64) *Shape {
@@ -371,8 +419,16 @@ func (shape *Shape) transformCopy(x, y float64) *Shape {
 	return &copiedShape
 }
 
+// Returns a deep copy of the lines of the shape with the same region and derived values. The variants are not copied.
 func (shape *Shape) copy() Shape {
-	var copiedShape Shape
+	copiedShape := Shape{
+		centroid:    shape.centroid,
+		orientation: shape.orientation,
+		anisotropy:  shape.anisotropy,
+		rotation:    shape.rotation,
+		region:      shape.region,
+		coverage:    shape.coverage,
+	}
 	for _, line := range shape.Lines {
 		copiedShape.Lines = append(copiedShape.Lines, line.copy())
 	}
@@ -396,6 +452,26 @@ func (shape *Shape) toSVG(style string) string {
 	return svg
 }
 
+// Returns the stroke color and width of the shape. Shapes of a region use the stroke settings of the region.
+func (shape *Shape) getStroke() (string, float64) {
+	if shape.region != nil {
+		return shape.region.strokeColor, shape.region.strokeWidth
+	}
+
+	return Config.strokeColor, Config.strokeWidth
+}
+
+// Returns the stroke color and width (in pixels of the outputDpi) the shape is drawn with. If offsetStrokes is
+// enabled the stroke width is simulated by the offset lines and every line is drawn with the pen width.
+func (shape *Shape) getDrawnStroke() (string, float64) {
+	strokeColor, strokeWidth := shape.getStroke()
+	if Config.offsetStrokes {
+		strokeWidth = mmToPixel(Config.penWidth, Config.outputDpi)
+	}
+
+	return strokeColor, strokeWidth
+}
+
 func (shape *Shape) toJSON() any {
 	if len(shape.Lines) == 0 {
 		return []any{}
@@ -32,7 +32,10 @@ func TestVecart(t *testing.T) {
 		UserConfig = Config.toJson()
 		config.outputPath = "static/provedSVG/" + strings.TrimSuffix(basename, filepath.Ext(basename)) + ".svg"
 
-		svg := startVecart()
+		svg, err := startVecart()
+		if err != nil {
+			t.Fatal(err)
+		}
 
 		svgFile, err := StaticAssets.Open(config.outputPath)
 		if err != nil {
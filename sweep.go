@@ -0,0 +1,479 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html"
+	"math"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	SweepSummaryHTML = "html"
+	SweepSummaryCSV  = "csv"
+)
+
+// A parameter sweep runs Vecart for every combination of the given parameter values
+type Sweep struct {
+	configPath      string
+	outputDirectory string
+	parallelRuns    int
+	summary         string
+	parameterNames  []string
+	parameterValues map[string][]any
+}
+
+type SweepVariant struct {
+	name       string
+	parameters map[string]any
+	configPath string
+	outputPath string
+	succeeded  bool
+}
+
+func NewSweep() *Sweep {
+	return &Sweep{outputDirectory: "sweep", parallelRuns: 1, summary: SweepSummaryHTML, parameterValues: make(map[string][]any)}
+}
+
+// Parses a sweep definition. Parameters are either given as a list of values or as a range with 'from', 'to' and
+// 'step' (e.g. "quadrantWidth": {"from": 3, "to": 7, "step": 2}).
+func (sweep *Sweep) fromJSON(jsonString string) bool {
+	var jsonData map[string]any
+	err := json.Unmarshal([]byte(jsonString), &jsonData)
+	if err != nil {
+		fmt.Printf("Could not parse sweep file: '%s'\n", err)
+		return false
+	}
+
+	errorsOcurred = false
+	errors = nil
+
+	getString(jsonData, "config", &sweep.configPath)
+	getString(jsonData, "outputDirectory", &sweep.outputDirectory)
+	getInt(jsonData, "parallelRuns", &sweep.parallelRuns)
+	getString(jsonData, "summary", &sweep.summary)
+
+	for key := range jsonData {
+		if !slices.Contains([]string{"config", "outputDirectory", "parallelRuns", "summary", "parameters"}, key) {
+			errorsOcurred = true
+			errors = append(errors, "Unkown Key in sweep definition '"+key+"'")
+		}
+	}
+
+	parameters, ok := jsonData["parameters"].(map[string]any)
+	if !ok || len(parameters) == 0 {
+		errorsOcurred = true
+		errors = append(errors, "The sweep definition needs a 'parameters' object with at least one parameter!")
+	}
+
+	for name, definition := range parameters {
+		values, ok := getSweepValues(definition)
+		if !ok || len(values) == 0 {
+			errorsOcurred = true
+			errors = append(errors, "Invalid values for sweep parameter '"+name+"' | expected a non empty array or an object with 'from', 'to' and 'step'")
+			continue
+		}
+		sweep.parameterNames = append(sweep.parameterNames, name)
+		sweep.parameterValues[name] = values
+	}
+	slices.Sort(sweep.parameterNames)
+
+	if sweep.configPath == "" {
+		errorsOcurred = true
+		errors = append(errors, "The sweep definition needs a 'config' attribute with the path to the base config!")
+	}
+
+	if sweep.parallelRuns < 1 {
+		errorsOcurred = true
+		errors = append(errors, "parallelRuns must be greater than 0!")
+	}
+
+	if !slices.Contains([]string{SweepSummaryHTML, SweepSummaryCSV}, sweep.summary) {
+		errorsOcurred = true
+		errors = append(errors, "summary must be either 'html' or 'csv'!")
+	}
+
+	return !errorsOcurred
+}
+
+func getSweepValues(definition any) ([]any, bool) {
+	switch value := definition.(type) {
+	case []any:
+		return value, true
+	case map[string]any:
+		from, fromOk := value["from"].(float64)
+		to, toOk := value["to"].(float64)
+		step, stepOk := value["step"].(float64)
+		if !fromOk || !toOk || !stepOk || step <= 0 || len(value) != 3 {
+			return nil, false
+		}
+
+		var values []any
+		for index := 0; from+float64(index)*step <= to+step*1e-9; index++ {
+			// Calculated from the index to prevent the accumulation of rounding errors
+			values = append(values, math.Round((from+float64(index)*step)*1e9)/1e9)
+		}
+		return values, true
+	}
+
+	return nil, false
+}
+
+// Returns every combination of the parameter values
+func (sweep *Sweep) getCombinations() []map[string]any {
+	combinations := []map[string]any{{}}
+
+	for _, name := range sweep.parameterNames {
+		var extendedCombinations []map[string]any
+		for _, combination := range combinations {
+			for _, value := range sweep.parameterValues[name] {
+				extendedCombination := make(map[string]any)
+				for key := range combination {
+					extendedCombination[key] = combination[key]
+				}
+				extendedCombination[name] = value
+				extendedCombinations = append(extendedCombinations, extendedCombination)
+			}
+		}
+		combinations = extendedCombinations
+	}
+
+	return combinations
+}
+
+// Returns a file name that contains the values of all swept parameters, e.g. 'darknessThreshold-10_quadrantWidth-3'
+func (sweep *Sweep) getVariantName(parameters map[string]any) string {
+	var parts []string
+	for _, name := range sweep.parameterNames {
+		parts = append(parts, name+"-"+formatSweepValue(parameters[name]))
+	}
+
+	name := strings.Join(parts, "_")
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>| `, r) {
+			return '-'
+		}
+		return r
+	}, name)
+}
+
+func formatSweepValue(value any) string {
+	switch typedValue := value.(type) {
+	case float64:
+		return strconv.FormatFloat(typedValue, 'f', -1, 64)
+	case string:
+		return typedValue
+	}
+
+	jsonBytes, _ := json.Marshal(value)
+	return string(jsonBytes)
+}
+
+// Runs Vecart for every combination of the swept parameters. Each variant runs in its own process of the Vecart
+// executable since the generation relies on global state.
+func runSweep(sweepPath string) bool {
+	sweep, variants, ok := prepareSweep(sweepPath)
+	if !ok {
+		return false
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		fmt.Println("Could not determine the path of the Vecart executable!")
+		fmt.Println(err)
+		return false
+	}
+
+	fmt.Printf("Running %d variants with %d parallel runs\n", len(variants), sweep.parallelRuns)
+
+	wg := sync.WaitGroup{}
+	outputMutex := sync.Mutex{}
+	finishedVariants := 0
+	runs := make(chan *SweepVariant)
+	for range sweep.parallelRuns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for variant := range runs {
+				variant.succeeded = variant.run(executable)
+
+				outputMutex.Lock()
+				finishedVariants++
+				status := "done"
+				if !variant.succeeded {
+					status = "failed (see " + variant.name + ".log)"
+				}
+				fmt.Printf("[%d/%d] %s %s\n", finishedVariants, len(variants), variant.name, status)
+				outputMutex.Unlock()
+			}
+		}()
+	}
+
+	for _, variant := range variants {
+		runs <- variant
+	}
+	close(runs)
+	wg.Wait()
+
+	if sweep.summary == SweepSummaryCSV {
+		writeStringToFile(sweep.getCSVSummary(variants), filepath.Join(sweep.outputDirectory, "index.csv"))
+	} else {
+		writeStringToFile(sweep.getHTMLSummary(variants), filepath.Join(sweep.outputDirectory, "index.html"))
+	}
+
+	return !slices.ContainsFunc(variants, func(variant *SweepVariant) bool { return !variant.succeeded })
+}
+
+// Reads the sweep definition and the base config and writes the configs of the variants into the output directory
+func prepareSweep(sweepPath string) (*Sweep, []*SweepVariant, bool) {
+	// The variant configs are parsed to skip invalid combinations, which needs the fonts for text shapes
+	Fonts = loadFonts()
+
+	content, err := getFileContentsFromFilePath(sweepPath)
+	if err != nil {
+		fmt.Println("Could not read sweep definition from '" + sweepPath + "'")
+		return nil, nil, false
+	}
+
+	sweep := NewSweep()
+	if !sweep.fromJSON(content) {
+		fmt.Println("Errors occured while parsing the sweep definition:")
+		for _, errorString := range errors {
+			fmt.Println("   " + errorString)
+		}
+		return nil, nil, false
+	}
+
+	baseContent, _, err := loadConfigFile(sweep.configPath)
+	if _, ok := err.(*ConfigReadError); ok {
+		fmt.Println("Could not read base config from '" + sweep.configPath + "'")
+		return nil, nil, false
+	} else if err != nil {
+		fmt.Printf("Could not load base config from '%s': %s\n", sweep.configPath, err)
+		return nil, nil, false
+	}
+
+	var baseConfig map[string]any
+	err = json.Unmarshal([]byte(baseContent), &baseConfig)
+	if err != nil {
+		fmt.Printf("Could not parse base config: '%s'\n", err)
+		return nil, nil, false
+	}
+	// The variant configs are written into the output directory, so they must not depend on the working directory
+	makeConfigPathsAbsolute(baseConfig)
+
+	err = os.MkdirAll(sweep.outputDirectory, 0755)
+	if err != nil {
+		fmt.Printf("Could not create output directory '%s'\n", sweep.outputDirectory)
+		fmt.Println(err)
+		return nil, nil, false
+	}
+
+	variants := sweep.prepareVariants(baseConfig)
+	return sweep, variants, len(variants) > 0
+}
+
+// Writes a config file for every valid combination of the swept parameters. Invalid combinations are reported and skipped.
+func (sweep *Sweep) prepareVariants(baseConfig map[string]any) []*SweepVariant {
+	var variants []*SweepVariant
+
+	for _, parameters := range sweep.getCombinations() {
+		variant := SweepVariant{name: sweep.getVariantName(parameters), parameters: parameters}
+		variant.configPath = filepath.Join(sweep.outputDirectory, variant.name+".json")
+		variant.outputPath = filepath.Join(sweep.outputDirectory, variant.name+".svg")
+
+		variantConfig := make(map[string]any)
+		for key := range baseConfig {
+			variantConfig[key] = baseConfig[key]
+		}
+		for key := range parameters {
+			variantConfig[key] = parameters[key]
+		}
+		variantConfig["outputPath"] = getAbsolutePath(variant.outputPath)
+		variantConfig["preview"] = true
+		variantConfig["metrics"] = true
+		variantConfig["metricsFile"] = true
+		variantConfig["debug"] = false
+
+		jsonBytes, err := json.MarshalIndent(variantConfig, "", "    ")
+		if err != nil {
+			fmt.Printf("Could not create config for variant '%s'\n", variant.name)
+			continue
+		}
+
+		errors = nil
+		config := NewConfig()
+		if !config.fromJSON(string(jsonBytes)) {
+			fmt.Printf("Skipping variant '%s' because of config errors:\n", variant.name)
+			for _, errorString := range errors {
+				fmt.Println("   " + errorString)
+			}
+			continue
+		}
+
+		writeStringToFile(string(jsonBytes), variant.configPath)
+		variants = append(variants, &variant)
+	}
+
+	return variants
+}
+
+// Replaces the relative input paths and region masks of the config with absolute paths
+func makeConfigPathsAbsolute(config map[string]any) {
+	switch inputPath := config["inputPath"].(type) {
+	case string:
+		config["inputPath"] = getAbsolutePath(inputPath)
+	case []any:
+		for index := range inputPath {
+			if path, ok := inputPath[index].(string); ok {
+				inputPath[index] = getAbsolutePath(path)
+			}
+		}
+	}
+
+	regions, _ := config["regions"].([]any)
+	for _, region := range regions {
+		if regionParameters, ok := region.(map[string]any); ok {
+			if mask, ok := regionParameters["mask"].(string); ok {
+				regionParameters["mask"] = getAbsolutePath(mask)
+			}
+		}
+	}
+}
+
+// Returns the absolute path. An empty path (e.g. the inputPath of the example image) stays empty.
+func getAbsolutePath(path string) string {
+	if path == "" {
+		return path
+	}
+
+	absolutePath, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+
+	return absolutePath
+}
+
+// Runs Vecart for the variant and writes the console output of the run to a log file
+func (variant *SweepVariant) run(executable string) bool {
+	logFile, err := createFile(strings.TrimSuffix(variant.outputPath, ".svg") + ".log")
+	if err != nil {
+		return false
+	}
+	defer logFile.Close()
+
+	command := exec.Command(executable, variant.configPath)
+	command.Stdout = logFile
+	command.Stderr = logFile
+
+	err = command.Run()
+	if err != nil {
+		return false
+	}
+
+	_, err = os.Stat(variant.outputPath)
+	return err == nil
+}
+
+// Reads the metrics written by the run of the variant. Returns nil if the metrics are not available.
+func (variant *SweepVariant) getMetrics() map[string]any {
+	content, err := os.ReadFile(strings.TrimSuffix(variant.outputPath, ".svg") + "_metrics.json")
+	if err != nil {
+		return nil
+	}
+
+	var metrics map[string]any
+	if json.Unmarshal(content, &metrics) != nil {
+		return nil
+	}
+
+	return metrics
+}
+
+var sweepMetricKeys = []string{"toneError", "ssim", "inkLength", "nrOfPolylines", "travelDistance", "plotTime"}
+
+// Returns a table with the parameters and the metrics of every variant. Metrics of failed variants are left empty.
+func (sweep *Sweep) getCSVSummary(variants []*SweepVariant) string {
+	var rows [][]string
+
+	header := []string{"name"}
+	header = append(header, sweep.parameterNames...)
+	header = append(header, "succeeded")
+	header = append(header, sweepMetricKeys...)
+	rows = append(rows, header)
+
+	for _, variant := range variants {
+		row := []string{variant.name}
+		for _, name := range sweep.parameterNames {
+			row = append(row, formatSweepValue(variant.parameters[name]))
+		}
+		row = append(row, strconv.FormatBool(variant.succeeded))
+
+		metrics := variant.getMetrics()
+		for _, key := range sweepMetricKeys {
+			value := ""
+			if metric, ok := metrics[key]; ok {
+				value = formatSweepValue(metric)
+			}
+			row = append(row, value)
+		}
+		rows = append(rows, row)
+	}
+
+	builder := strings.Builder{}
+	writer := csv.NewWriter(&builder)
+	writer.WriteAll(rows)
+
+	return builder.String()
+}
+
+// Returns a contact sheet with the preview and the metrics of every variant
+func (sweep *Sweep) getHTMLSummary(variants []*SweepVariant) string {
+	var lines []string
+	lines = append(lines, "<!DOCTYPE html>")
+	lines = append(lines, "<html>")
+	lines = append(lines, "<head>")
+	lines = append(lines, "<meta charset=\"utf-8\">")
+	lines = append(lines, "<title>Vecart Sweep</title>")
+	lines = append(lines, "<style>body { font-family: sans-serif; } .variant { display: inline-block; vertical-align: top; margin: 8px; width: 320px; } img { width: 100%; border: 1px solid #ccc; } td { padding: 0 8px 0 0; }</style>")
+	lines = append(lines, "</head>")
+	lines = append(lines, "<body>")
+
+	for _, variant := range variants {
+		// The name is used as relative URL, so characters like '#' or '%' have to be escaped
+		link := html.EscapeString(url.PathEscape(variant.name))
+		lines = append(lines, "<div class=\"variant\">")
+		if variant.succeeded {
+			lines = append(lines, "<a href=\""+link+".svg\"><img src=\""+link+"_preview.png\"></a>")
+		} else {
+			lines = append(lines, "<p>Failed (see <a href=\""+link+".log\">log</a>)</p>")
+		}
+
+		lines = append(lines, "<table>")
+		for _, name := range sweep.parameterNames {
+			lines = append(lines, "<tr><td><b>"+html.EscapeString(name)+"</b></td><td>"+html.EscapeString(formatSweepValue(variant.parameters[name]))+"</td></tr>")
+		}
+		metrics := variant.getMetrics()
+		for _, key := range sweepMetricKeys {
+			if value, ok := metrics[key]; ok {
+				lines = append(lines, "<tr><td>"+key+"</td><td>"+html.EscapeString(formatSweepValue(value))+"</td></tr>")
+			}
+		}
+		lines = append(lines, "</table>")
+		lines = append(lines, "</div>")
+	}
+
+	lines = append(lines, "</body>")
+	lines = append(lines, "</html>")
+
+	return strings.Join(lines, "\n") + "\n"
+}
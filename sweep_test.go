@@ -0,0 +1,143 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSweepValues(t *testing.T) {
+	testCases := []struct {
+		definition any
+		expected   []any
+		ok         bool
+	}{
+		{[]any{1.0, "a", true}, []any{1.0, "a", true}, true},
+		{map[string]any{"from": 3.0, "to": 7.0, "step": 2.0}, []any{3.0, 5.0, 7.0}, true},
+		// The values are calculated without accumulating rounding errors and include the end of the range
+		{map[string]any{"from": 0.1, "to": 0.3, "step": 0.1}, []any{0.1, 0.2, 0.3}, true},
+		{map[string]any{"from": 3.0, "to": 6.0, "step": 2.0}, []any{3.0, 5.0}, true},
+		{map[string]any{"from": 5.0, "to": 3.0, "step": 1.0}, nil, true},
+		{map[string]any{"from": 3.0, "to": 7.0, "step": 0.0}, nil, false},
+		{map[string]any{"from": 3.0, "to": 7.0}, nil, false},
+		{map[string]any{"from": 3.0, "to": 7.0, "step": 1.0, "by": 2.0}, nil, false},
+		{map[string]any{"from": "3", "to": 7.0, "step": 1.0}, nil, false},
+		{5.0, nil, false},
+	}
+
+	for _, testCase := range testCases {
+		values, ok := getSweepValues(testCase.definition)
+		if ok != testCase.ok || !slices.Equal(values, testCase.expected) {
+			t.Errorf("%v: expected %v (%v), got %v (%v)", testCase.definition, testCase.expected, testCase.ok, values, ok)
+		}
+	}
+}
+
+func TestSweepCombinations(t *testing.T) {
+	sweep := NewSweep()
+	sweep.parameterNames = []string{"darknessThreshold", "quadrantWidth", "strokeColor"}
+	sweep.parameterValues["darknessThreshold"] = []any{10.0, 20.0}
+	sweep.parameterValues["quadrantWidth"] = []any{3.0, 5.0, 7.0}
+	sweep.parameterValues["strokeColor"] = []any{"black"}
+
+	combinations := sweep.getCombinations()
+	if len(combinations) != 6 {
+		t.Fatalf("Expected 6 combinations, got %d", len(combinations))
+	}
+
+	var names []string
+	for _, combination := range combinations {
+		if len(combination) != 3 {
+			t.Errorf("The combination %v does not contain every parameter", combination)
+		}
+		names = append(names, sweep.getVariantName(combination))
+	}
+	slices.Sort(names)
+	if len(slices.Compact(names)) != 6 {
+		t.Errorf("The combinations are not unique: %v", names)
+	}
+	if names[0] != "darknessThreshold-10_quadrantWidth-3_strokeColor-black" {
+		t.Errorf("Unexpected variant name '%s'", names[0])
+	}
+}
+
+func TestSweepVariantName(t *testing.T) {
+	sweep := NewSweep()
+	sweep.parameterNames = []string{"a", "b", "c"}
+
+	// Characters that are not allowed in file names are replaced
+	name := sweep.getVariantName(map[string]any{"a": 0.5, "b": "x/y: z", "c": []any{1.0, 2.0}})
+	if name != "a-0.5_b-x-y--z_c-[1,2]" {
+		t.Errorf("Unexpected variant name '%s'", name)
+	}
+}
+
+func TestSweepSummaries(t *testing.T) {
+	sweep := NewSweep()
+	sweep.parameterNames = []string{"strokeColor"}
+	variants := []*SweepVariant{{
+		name:       "strokeColor-#a,b&c",
+		parameters: map[string]any{"strokeColor": `#a,b&"c`},
+		outputPath: filepath.Join(t.TempDir(), "variant.svg"),
+	}}
+
+	csvSummary := sweep.getCSVSummary(variants)
+	expectedCSV := "name,strokeColor,succeeded," + strings.Join(sweepMetricKeys, ",") + "\n" +
+		`"strokeColor-#a,b&c","#a,b&""c",false,,,,,,` + "\n"
+	if csvSummary != expectedCSV {
+		t.Errorf("Unexpected CSV summary:\n%s", csvSummary)
+	}
+
+	htmlSummary := sweep.getHTMLSummary(variants)
+	if !strings.Contains(htmlSummary, `<a href="strokeColor-%23a%2Cb&amp;c.log">`) ||
+		!strings.Contains(htmlSummary, `<td>#a,b&amp;&#34;c</td>`) {
+		t.Errorf("The variant is not escaped in the HTML summary:\n%s", htmlSummary)
+	}
+}
+
+func TestSweepAbsolutePaths(t *testing.T) {
+	config := map[string]any{
+		"inputPath": []any{"images/a.png", "/images/b.png", ""},
+		"regions":   []any{map[string]any{"mask": "masks/mask.png"}, map[string]any{"polygon": []any{}}},
+	}
+	makeConfigPathsAbsolute(config)
+
+	inputPaths := config["inputPath"].([]any)
+	absolutePath, _ := filepath.Abs("images/a.png")
+	if inputPaths[0] != absolutePath || inputPaths[1] != "/images/b.png" || inputPaths[2] != "" {
+		t.Errorf("Unexpected input paths %v", inputPaths)
+	}
+	if mask := config["regions"].([]any)[0].(map[string]any)["mask"].(string); !filepath.IsAbs(mask) {
+		t.Errorf("The mask path '%s' is not absolute", mask)
+	}
+
+	config = map[string]any{"inputPath": "image.png"}
+	makeConfigPathsAbsolute(config)
+	if !filepath.IsAbs(config["inputPath"].(string)) {
+		t.Errorf("The input path '%s' is not absolute", config["inputPath"])
+	}
+}
+
+func TestSweepTextConfig(t *testing.T) {
+	resetStaticVariables()
+	Fonts = nil
+
+	directory := t.TempDir()
+	writeTestFile(t, filepath.Join(directory, "config.json"), `{"shapes": [{"type": "text", "center": [0, 0], "lineHeight": 2, "text": "Ellie"}]}`)
+	writeTestFile(t, filepath.Join(directory, "sweep.json"), `{"config": "`+filepath.Join(directory, "config.json")+`",
+		"outputDirectory": "`+filepath.Join(directory, "sweep")+`", "parameters": {"quadrantWidth": [3, 5]}}`)
+
+	// The fonts have to be loaded before the variant configs with text shapes are parsed
+	_, variants, ok := prepareSweep(filepath.Join(directory, "sweep.json"))
+	if !ok || len(variants) != 2 {
+		t.Fatalf("Expected two variants but got %d %v", len(variants), errors)
+	}
+	for _, variant := range variants {
+		if _, err := os.Stat(variant.configPath); err != nil {
+			t.Errorf("The config of the variant '%s' was not written", variant.name)
+		}
+	}
+	resetStaticVariables()
+}
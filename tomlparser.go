@@ -0,0 +1,484 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// A parser for TOML configs. Supports key/value pairs with bare, quoted and dotted keys, tables, arrays of tables
+// (e.g. '[[shapes]]' and '[[shapes.shapes]]' for the shapes of a group), strings, numbers, booleans, arrays and inline
+// tables. Dates and times are returned as RFC 3339 strings. The result uses the same types as encoding/json.
+
+type TOMLParser struct {
+	text     string
+	position int
+	line     int
+}
+
+func parseTOML(content string) (map[string]any, error) {
+	parser := TOMLParser{text: strings.ReplaceAll(content, "\r\n", "\n"), line: 1}
+	root := map[string]any{}
+	current := root
+
+	for {
+		parser.skipWhitespaceAndComments(true)
+		if parser.position >= len(parser.text) {
+			return root, nil
+		}
+
+		var err error
+		if parser.text[parser.position] == '[' {
+			current, err = parser.parseTableHeader(root)
+		} else {
+			err = parser.parseKeyValue(current)
+		}
+		if err == nil {
+			err = parser.parseLineEnd()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", parser.line, err)
+		}
+	}
+}
+
+func (parser *TOMLParser) peek() byte {
+	if parser.position >= len(parser.text) {
+		return 0
+	}
+
+	return parser.text[parser.position]
+}
+
+// Skips spaces, tabs and comments. Newlines are only skipped if requested.
+func (parser *TOMLParser) skipWhitespaceAndComments(newlines bool) {
+	for parser.position < len(parser.text) {
+		switch parser.text[parser.position] {
+		case ' ', '\t':
+			parser.position++
+		case '\n':
+			if !newlines {
+				return
+			}
+			parser.line++
+			parser.position++
+		case '#':
+			for parser.position < len(parser.text) && parser.text[parser.position] != '\n' {
+				parser.position++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (parser *TOMLParser) parseLineEnd() error {
+	parser.skipWhitespaceAndComments(false)
+	if parser.position < len(parser.text) && parser.text[parser.position] != '\n' {
+		return fmt.Errorf("expected the end of the line but got '%c'", parser.text[parser.position])
+	}
+
+	return nil
+}
+
+// Parses '[table]' or '[[array of tables]]' and returns the table that the following keys belong to
+func (parser *TOMLParser) parseTableHeader(root map[string]any) (map[string]any, error) {
+	isArray := strings.HasPrefix(parser.text[parser.position:], "[[")
+	if isArray {
+		parser.position += 2
+	} else {
+		parser.position++
+	}
+
+	keys, err := parser.parseKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	closing := "]"
+	if isArray {
+		closing = "]]"
+	}
+	if !strings.HasPrefix(parser.text[parser.position:], closing) {
+		return nil, fmt.Errorf("expected '%s'", closing)
+	}
+	parser.position += len(closing)
+
+	parent, err := getTOMLTable(root, keys[:len(keys)-1])
+	if err != nil {
+		return nil, err
+	}
+	key := keys[len(keys)-1]
+
+	if isArray {
+		array, ok := parent[key].([]any)
+		if parent[key] != nil && !ok {
+			return nil, fmt.Errorf("'%s' is not an array of tables", strings.Join(keys, "."))
+		}
+		table := map[string]any{}
+		parent[key] = append(array, table)
+		return table, nil
+	}
+
+	return getTOMLTable(parent, []string{key})
+}
+
+// Returns the table at the path of keys and creates missing tables. Arrays of tables resolve to their last table.
+func getTOMLTable(table map[string]any, keys []string) (map[string]any, error) {
+	for _, key := range keys {
+		switch value := table[key].(type) {
+		case nil:
+			child := map[string]any{}
+			table[key] = child
+			table = child
+		case map[string]any:
+			table = value
+		case []any:
+			if len(value) == 0 {
+				return nil, fmt.Errorf("'%s' is not a table", key)
+			}
+			child, ok := value[len(value)-1].(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("'%s' is not a table", key)
+			}
+			table = child
+		default:
+			return nil, fmt.Errorf("'%s' is not a table", key)
+		}
+	}
+
+	return table, nil
+}
+
+func (parser *TOMLParser) parseKeyValue(table map[string]any) error {
+	keys, err := parser.parseKeys()
+	if err != nil {
+		return err
+	}
+
+	if parser.peek() != '=' {
+		return fmt.Errorf("expected '=' after key '%s'", strings.Join(keys, "."))
+	}
+	parser.position++
+
+	value, err := parser.parseValue()
+	if err != nil {
+		return err
+	}
+
+	table, err = getTOMLTable(table, keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+
+	key := keys[len(keys)-1]
+	if _, exists := table[key]; exists {
+		return fmt.Errorf("duplicate key '%s'", strings.Join(keys, "."))
+	}
+	table[key] = value
+
+	return nil
+}
+
+// Parses a bare, quoted or dotted key
+func (parser *TOMLParser) parseKeys() ([]string, error) {
+	var keys []string
+
+	for {
+		parser.skipWhitespaceAndComments(false)
+
+		var key string
+		switch parser.peek() {
+		case '"', '\'':
+			value, err := parser.parseString()
+			if err != nil {
+				return nil, err
+			}
+			key = value
+		default:
+			start := parser.position
+			for parser.position < len(parser.text) && isTOMLBareKeyChar(parser.text[parser.position]) {
+				parser.position++
+			}
+			if start == parser.position {
+				return nil, fmt.Errorf("expected a key")
+			}
+			key = parser.text[start:parser.position]
+		}
+		keys = append(keys, key)
+
+		parser.skipWhitespaceAndComments(false)
+		if parser.peek() != '.' {
+			return keys, nil
+		}
+		parser.position++
+	}
+}
+
+func isTOMLBareKeyChar(char byte) bool {
+	return char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z' || char >= '0' && char <= '9' || char == '_' || char == '-'
+}
+
+func (parser *TOMLParser) parseValue() (any, error) {
+	parser.skipWhitespaceAndComments(false)
+
+	switch parser.peek() {
+	case 0, '\n':
+		return nil, fmt.Errorf("missing value")
+	case '"', '\'':
+		return parser.parseString()
+	case '[':
+		return parser.parseArray()
+	case '{':
+		return parser.parseInlineTable()
+	}
+
+	start := parser.position
+	for parser.position < len(parser.text) && !strings.ContainsRune(" \t\n,]}#", rune(parser.text[parser.position])) {
+		parser.position++
+	}
+	text := parser.text[start:parser.position]
+
+	switch text {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+
+	number, err := strconv.ParseFloat(strings.ReplaceAll(text, "_", ""), 64)
+	if err == nil {
+		return number, nil
+	}
+
+	// Hexadecimal, octal and binary integers
+	integer, err := strconv.ParseInt(text, 0, 64)
+	if err == nil {
+		return float64(integer), nil
+	}
+
+	// The date and the time of a date-time may be separated by a space instead of a 'T'
+	if len(text) == len(tomlDateLayout) && parser.peek() == ' ' && parser.position+1 < len(parser.text) && isDigit(parser.text[parser.position+1]) {
+		start := parser.position + 1
+		end := start
+		for end < len(parser.text) && !strings.ContainsRune(" \t\n,]}#", rune(parser.text[end])) {
+			end++
+		}
+		if dateTime, ok := parseTOMLDateTime(text + "T" + parser.text[start:end]); ok {
+			parser.position = end
+			return dateTime, nil
+		}
+	}
+
+	if dateTime, ok := parseTOMLDateTime(text); ok {
+		return dateTime, nil
+	}
+
+	return nil, fmt.Errorf("invalid value '%s'", text)
+}
+
+const tomlDateLayout = "2006-01-02"
+
+// Offset date-times, local date-times, local dates and local times
+var tomlDateTimeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", tomlDateLayout, "15:04:05.999999999"}
+
+// Returns the date or time in the RFC 3339 format. Returns false if the text is not a valid date or time.
+func parseTOMLDateTime(text string) (string, bool) {
+	// 'T' and 'Z' may be lowercase
+	text = strings.ToUpper(text)
+	for _, layout := range tomlDateTimeLayouts {
+		if _, err := time.Parse(layout, text); err == nil {
+			return text, true
+		}
+	}
+
+	return "", false
+}
+
+func isDigit(char byte) bool {
+	return char >= '0' && char <= '9'
+}
+
+func (parser *TOMLParser) parseArray() (any, error) {
+	array := []any{}
+	parser.position++
+
+	for {
+		parser.skipWhitespaceAndComments(true)
+		if parser.peek() == ']' {
+			parser.position++
+			return array, nil
+		}
+
+		value, err := parser.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		array = append(array, value)
+
+		parser.skipWhitespaceAndComments(true)
+		switch parser.peek() {
+		case ',':
+			parser.position++
+		case ']':
+		default:
+			return nil, fmt.Errorf("expected ',' or ']' in array")
+		}
+	}
+}
+
+func (parser *TOMLParser) parseInlineTable() (any, error) {
+	table := map[string]any{}
+	parser.position++
+
+	parser.skipWhitespaceAndComments(false)
+	if parser.peek() == '}' {
+		parser.position++
+		return table, nil
+	}
+
+	for {
+		err := parser.parseKeyValue(table)
+		if err != nil {
+			return nil, err
+		}
+
+		parser.skipWhitespaceAndComments(false)
+		switch parser.peek() {
+		case ',':
+			parser.position++
+		case '}':
+			parser.position++
+			return table, nil
+		default:
+			return nil, fmt.Errorf("expected ',' or '}' in inline table")
+		}
+	}
+}
+
+// Parses basic ("...") and literal ('...') strings including their multi-line variants
+func (parser *TOMLParser) parseString() (string, error) {
+	quote := parser.text[parser.position]
+	delimiter := string(quote)
+	if strings.HasPrefix(parser.text[parser.position:], strings.Repeat(delimiter, 3)) {
+		delimiter = strings.Repeat(delimiter, 3)
+	}
+	parser.position += len(delimiter)
+
+	multiLine := len(delimiter) == 3
+	if multiLine && parser.peek() == '\n' {
+		parser.position++
+		parser.line++
+	}
+
+	start := parser.position
+	for parser.position < len(parser.text) {
+		char := parser.text[parser.position]
+		if char == '\n' {
+			if !multiLine {
+				return "", fmt.Errorf("unterminated string")
+			}
+			parser.line++
+		}
+
+		if quote == '"' && char == '\\' {
+			parser.position += 2
+			continue
+		}
+
+		if strings.HasPrefix(parser.text[parser.position:], delimiter) {
+			value := parser.text[start:parser.position]
+			parser.position += len(delimiter)
+			if quote == '\'' {
+				return value, nil
+			}
+			if multiLine {
+				value = trimTOMLLineEndingBackslashes(value)
+			}
+			return decodeEscapeSequences(value)
+		}
+
+		parser.position++
+	}
+
+	return "", fmt.Errorf("unterminated string")
+}
+
+// A backslash at the end of a line in a multi-line basic string removes the newline and the following whitespace
+func trimTOMLLineEndingBackslashes(value string) string {
+	var builder strings.Builder
+	for index := 0; index < len(value); index++ {
+		if value[index] == '\\' && index+1 < len(value) {
+			rest := strings.TrimLeft(value[index+1:], " \t")
+			if strings.HasPrefix(rest, "\n") {
+				rest = strings.TrimLeft(rest, " \t\n")
+				index = len(value) - len(rest) - 1
+				continue
+			}
+			builder.WriteString(value[index : index+2])
+			index++
+			continue
+		}
+		builder.WriteByte(value[index])
+	}
+
+	return builder.String()
+}
+
+// Decodes the escape sequences of TOML basic strings and YAML double quoted strings
+func decodeEscapeSequences(value string) (string, error) {
+	if !strings.Contains(value, "\\") {
+		return value, nil
+	}
+
+	var builder strings.Builder
+	for index := 0; index < len(value); index++ {
+		char := value[index]
+		if char != '\\' {
+			builder.WriteByte(char)
+			continue
+		}
+
+		index++
+		if index >= len(value) {
+			return "", fmt.Errorf("invalid escape sequence at the end of '%s'", value)
+		}
+
+		switch value[index] {
+		case 'b':
+			builder.WriteByte('\b')
+		case 't':
+			builder.WriteByte('\t')
+		case 'n':
+			builder.WriteByte('\n')
+		case 'f':
+			builder.WriteByte('\f')
+		case 'r':
+			builder.WriteByte('\r')
+		case '0':
+			builder.WriteByte(0)
+		case '"', '\\', '/', '\'':
+			builder.WriteByte(value[index])
+		case 'u', 'U':
+			length := 4
+			if value[index] == 'U' {
+				length = 8
+			}
+			if index+1+length > len(value) {
+				return "", fmt.Errorf("invalid unicode escape sequence in '%s'", value)
+			}
+			codePoint, err := strconv.ParseUint(value[index+1:index+1+length], 16, 32)
+			if err != nil || !utf8.ValidRune(rune(codePoint)) {
+				return "", fmt.Errorf("invalid unicode escape sequence in '%s'", value)
+			}
+			builder.WriteRune(rune(codePoint))
+			index += length
+		default:
+			return "", fmt.Errorf("invalid escape sequence '\\%c'", value[index])
+		}
+	}
+
+	return builder.String(), nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTOMLValues(t *testing.T) {
+	testCases := []struct {
+		content  string
+		expected map[string]any
+	}{
+		{"", map[string]any{}},
+		{"a = 1\nb = -2.5e3\nc = 1_000\nd = 0x10\ne = 0o10\nf = 0b10", map[string]any{"a": 1.0, "b": -2500.0, "c": 1000.0, "d": 16.0, "e": 8.0, "f": 2.0}},
+		{"a = true\nb = false # comment", map[string]any{"a": true, "b": false}},
+		{`a = "tab\tquote\" unicode \u00e9"` + "\nb = 'C:\\path'", map[string]any{"a": "tab\tquote\" unicode é", "b": `C:\path`}},
+		{"a = \"\"\"\nfirst\nsecond\"\"\"\nb = '''\nraw \\n'''", map[string]any{"a": "first\nsecond", "b": "raw \\n"}},
+		{"a = \"\"\"one \\\n    two\"\"\"", map[string]any{"a": "one two"}},
+		{`"quoted key" = 1` + "\nb.c.d = 2", map[string]any{"quoted key": 1.0, "b": map[string]any{"c": map[string]any{"d": 2.0}}}},
+		{"a = [1, [2, 3], {b = 'c'}]\nd = {e = [], f.g = 1}", map[string]any{"a": []any{1.0, []any{2.0, 3.0}, map[string]any{"b": "c"}}, "d": map[string]any{"e": []any{}, "f": map[string]any{"g": 1.0}}}},
+		{"a = [\n  1, # one\n  2,\n]", map[string]any{"a": []any{1.0, 2.0}}},
+		{"[a]\nb = 1\n[a.c]\nd = 2", map[string]any{"a": map[string]any{"b": 1.0, "c": map[string]any{"d": 2.0}}}},
+		{"[[a]]\nb = 1\n[[a]]\nb = 2\n[[a.c]]\nd = 3", map[string]any{"a": []any{map[string]any{"b": 1.0}, map[string]any{"b": 2.0, "c": []any{map[string]any{"d": 3.0}}}}}},
+		{"a = 1\r\nb = 2\r\n", map[string]any{"a": 1.0, "b": 2.0}},
+		// Dates and times are returned as RFC 3339 strings
+		{"a = 1979-05-27T07:32:00Z\nb = 1979-05-27T00:32:00.999999-07:00\nc = 1979-05-27t07:32:00z", map[string]any{"a": "1979-05-27T07:32:00Z", "b": "1979-05-27T00:32:00.999999-07:00", "c": "1979-05-27T07:32:00Z"}},
+		{"a = 1979-05-27 07:32:00\nb = 1979-05-27\nc = 07:32:00.5", map[string]any{"a": "1979-05-27T07:32:00", "b": "1979-05-27", "c": "07:32:00.5"}},
+		{"a = [1979-05-27, 1979-05-28 07:32:00] # dates", map[string]any{"a": []any{"1979-05-27", "1979-05-28T07:32:00"}}},
+	}
+
+	for _, testCase := range testCases {
+		value, err := parseTOML(testCase.content)
+		if err != nil {
+			t.Errorf("%q: unexpected error %s", testCase.content, err)
+			continue
+		}
+		if !reflect.DeepEqual(value, testCase.expected) {
+			t.Errorf("%q: expected %v, got %v", testCase.content, testCase.expected, value)
+		}
+	}
+}
+
+func TestTOMLErrors(t *testing.T) {
+	testCases := []struct {
+		content       string
+		expectedError string
+	}{
+		{"a = 1\na = 2", "line 2: duplicate key 'a'"},
+		{"a = 1 2", "line 1: expected the end of the line"},
+		{"a 1", "line 1: expected '=' after key 'a'"},
+		{"a =", "line 1: missing value"},
+		{"= 1", "line 1: expected a key"},
+		{"a = \"text", "line 1: unterminated string"},
+		{"a = \"text\nb = 1", "line 1: unterminated string"},
+		{`a = "\x"`, "invalid escape sequence"},
+		{"a = [1, 2", "line 1: expected ',' or ']' in array"},
+		{"a = {b = 1", "line 1: expected ',' or '}' in inline table"},
+		{"[a\nb = 1", "line 1: expected ']'"},
+		{"a = 1\n[a]", "line 2: 'a' is not a table"},
+		{"[a]\n[[a]]", "line 2: 'a' is not an array of tables"},
+		{"a = yes", "line 1: invalid value 'yes'"},
+		{"a = 1979-13-27", "line 1: invalid value '1979-13-27'"},
+		{"a = 25:32:00", "line 1: invalid value '25:32:00'"},
+	}
+
+	for _, testCase := range testCases {
+		_, err := parseTOML(testCase.content)
+		if err == nil || !strings.Contains(err.Error(), testCase.expectedError) {
+			t.Errorf("%q: expected the error '%s', got %v", testCase.content, testCase.expectedError, err)
+		}
+	}
+}
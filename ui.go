@@ -0,0 +1,110 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// Starts the HTTP server together with the web UI
+func runUI(args []string) bool {
+	return startServer("ui", args, true)
+}
+
+func handleUI(writer http.ResponseWriter, request *http.Request) {
+	content, err := StaticAssets.ReadFile("static/ui/index.html")
+	if err != nil {
+		http.Error(writer, "Can not read the UI from static Vecart ressources!", http.StatusInternalServerError)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+	writer.Write(content)
+}
+
+// Records the shape for the live view of the running job. The shape is in pixels of the processingDpi.
+func (server *Server) addLiveShape(shape *Shape) {
+	lines := getLiveLines(shape)
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	job := server.running
+	if job == nil {
+		return
+	}
+
+	if job.liveShapes == nil {
+		// ProcessedImage is set before any shape is placed
+		job.canvasWidth, job.canvasHeight = ProcessedImage.Bounds().Dx(), ProcessedImage.Bounds().Dy()
+	}
+	job.liveShapes = append(job.liveShapes, lines...)
+}
+
+// Replaces the recorded shapes of the running job with the shapes that are currently placed. Called after shapes were
+// removed, which can not be recorded like added shapes. Must not be called while shapes are placed.
+func (server *Server) resetLiveShapes() {
+	var lines [][][2]float64
+	for _, quadrant := range quadrants {
+		for shapeIndex := range quadrant.Shapes {
+			lines = append(lines, getLiveLines(&quadrant.Shapes[shapeIndex])...)
+		}
+	}
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	job := server.running
+	if job == nil {
+		return
+	}
+
+	job.canvasWidth, job.canvasHeight = ProcessedImage.Bounds().Dx(), ProcessedImage.Bounds().Dy()
+	job.liveShapes = lines
+	job.liveRevision++
+}
+
+func getLiveLines(shape *Shape) [][][2]float64 {
+	var lines [][][2]float64
+	for lineIndex := range shape.Lines {
+		var points [][2]float64
+		for _, point := range getRenderPoints(&shape.Lines[lineIndex]) {
+			points = append(points, [2]float64{point.X, point.Y})
+		}
+		lines = append(lines, points)
+	}
+
+	return lines
+}
+
+// Returns the lines placed so far starting at the index given by the query parameter 'from' so that the UI only has
+// to fetch the new lines. If the query parameter 'revision' differs from the revision of the job all lines are
+// returned since shapes were removed in the meantime.
+func (server *Server) handleGetLiveShapes(writer http.ResponseWriter, request *http.Request) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	job, ok := server.jobs[request.PathValue("id")]
+	if !ok {
+		writeJSONError(writer, http.StatusNotFound, "Unknown job '"+request.PathValue("id")+"'")
+		return
+	}
+
+	from, err := strconv.Atoi(request.URL.Query().Get("from"))
+	if err != nil || from < 0 || from > len(job.liveShapes) || request.URL.Query().Get("revision") != strconv.Itoa(job.liveRevision) {
+		from = 0
+	}
+
+	lines := job.liveShapes[from:]
+	if lines == nil {
+		lines = [][][2]float64{}
+	}
+
+	writeJSON(writer, http.StatusOK, map[string]any{
+		"width":    job.canvasWidth,
+		"height":   job.canvasHeight,
+		"revision": job.liveRevision,
+		"from":     from,
+		"total":    len(job.liveShapes),
+		"lines":    lines,
+	})
+}
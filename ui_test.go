@@ -0,0 +1,69 @@
+package main
+
+import (
+	"image"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUIPage(t *testing.T) {
+	handler := NewServer(t.TempDir(), time.Hour).getHandler(true)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), "<canvas") {
+		t.Errorf("Unexpected UI page %d", recorder.Code)
+	}
+
+	// The live view is only available with the UI
+	if status, _ := sendRequest(t, NewServer(t.TempDir(), time.Hour).getHandler(false), "GET", "/jobs/1/shapes", nil, ""); status == http.StatusOK {
+		t.Error("The live view must not be available without the UI")
+	}
+}
+
+func TestLiveShapes(t *testing.T) {
+	resetStaticVariables()
+	ProcessedImage = image.NewGray(image.Rect(0, 0, 20, 10))
+	server := NewServer(t.TempDir(), time.Hour)
+	handler := server.getHandler(true)
+
+	// Shapes are only recorded for the running job
+	server.addLiveShape(NewShape([]Polyline{{[]Point{{0, 0}, {1, 1}}, nil}}))
+	job := &Job{id: "1", status: JobStatusRunning}
+	server.jobs[job.id] = job
+	server.running = job
+
+	first := NewShape([]Polyline{{[]Point{{0, 0}, {1, 1}}, nil}})
+	second := NewShape([]Polyline{{[]Point{{2, 2}, {3, 3}}, nil}, {[]Point{{4, 4}, {5, 5}, {6, 4}}, nil}})
+	server.addLiveShape(first)
+	server.addLiveShape(second)
+
+	status, response := sendRequest(t, handler, "GET", "/jobs/1/shapes?from=0&revision=0", nil, "")
+	if status != http.StatusOK || response["width"] != 20.0 || response["height"] != 10.0 || response["total"] != 3.0 || len(response["lines"].([]any)) != 3 {
+		t.Fatalf("Unexpected live shapes %d %v", status, response)
+	}
+	_, response = sendRequest(t, handler, "GET", "/jobs/1/shapes?from=1&revision=0", nil, "")
+	if response["from"] != 1.0 || len(response["lines"].([]any)) != 2 {
+		t.Errorf("Only the lines after the index have to be returned, got %v", response)
+	}
+
+	// The first shape is removed, so the UI has to redraw the remaining shape
+	quadrants = []*Quadrant{{Shapes: []Shape{*second}}}
+	server.resetLiveShapes()
+	_, response = sendRequest(t, handler, "GET", "/jobs/1/shapes?from=3&revision=0", nil, "")
+	if response["revision"] != 1.0 || response["from"] != 0.0 || response["total"] != 2.0 || len(response["lines"].([]any)) != 2 {
+		t.Errorf("All lines have to be returned after shapes were removed, got %v", response)
+	}
+	_, response = sendRequest(t, handler, "GET", "/jobs/1/shapes?from=2&revision=1", nil, "")
+	if len(response["lines"].([]any)) != 0 {
+		t.Errorf("No lines were added since the last request, got %v", response)
+	}
+
+	if status, _ := sendRequest(t, handler, "GET", "/jobs/2/shapes", nil, ""); status != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown job, got %d", status)
+	}
+	resetStaticVariables()
+}
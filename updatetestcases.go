@@ -33,7 +33,12 @@ func updateProvedSVG() {
 		Config = config
 		UserConfig = Config.toJson()
 
-		writeStringToFile(startVecart(), Config.outputPath)
+		svg, err := startVecart()
+		if err != nil {
+			fmt.Println(err)
+		} else {
+			writeStringToFile(svg, Config.outputPath)
+		}
 
 		resetStaticVariables()
 	}
@@ -48,6 +53,17 @@ func resetStaticVariables() {
 	UserConfig = ""
 	RandSource = nil
 	quadrants = nil
+	regions = nil
+	shapesInitialized = false
+	shapeNeighborRange = 0
+	ProcessedImage = nil
+	pageLayout = nil
+	canvasBoundary = nil
+	placementTimedOut = false
+	unfinishedQuadrantsAtTimeout = 0
+	generatedMetrics = nil
+	estimatedPlotTime = 0
+	fontFiles = nil
 	ShapeCount = 0
 	ShapeCountMutex = &sync.Mutex{}
 	currentSpinnerFrame = 0
@@ -5,9 +5,13 @@ import (
 	"image"
 	"math"
 	"math/rand/v2"
+	"path/filepath"
+	"slices"
 	"sort"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -29,25 +33,58 @@ var lastAjustedDarknessChange float64
 var finishQuadrantsMutex sync.Mutex
 var finishQuadrantsStop bool
 
+// The current stage and progress of the generation. Used by the server to report the progress of jobs.
+var generationStage string
+var generationPercentage float64
+var generationProgressMutex sync.Mutex
+
+// Called for every shape placed by finishQuadrants. Used by the UI to show the shapes while they are placed.
+var shapePlacedListener func(shape *Shape)
+
+// Called after placed shapes were removed or moved (e.g. when the worst shapes are removed or after the annealing).
+// Used by the UI to redraw all shapes. The shapes are still in pixels of the processingDpi.
+var shapesChangedListener func()
+
+// Set to request that the running generation ends as soon as possible. generateVectorArt returns an empty string then.
+var generationCancelled atomic.Bool
+
 func initializeQuadrants(image *image.Gray, neighborRange int) {
 	quadrantsPerRow := (*image).Bounds().Max.X / Config.quadrantWidth
 	quadrantsPerColumn := (*image).Bounds().Max.Y / Config.quadrantHeight
 
 	nrOfQuadrants := quadrantsPerRow * quadrantsPerColumn
 
+	quadrants = nil
 	for quadrantId := 0; quadrantId < nrOfQuadrants; quadrantId++ {
 		quadrants = append(quadrants, NewQuadrant(image, uint(quadrantId), uint(nrOfQuadrants),
 			uint(quadrantsPerRow), uint(quadrantsPerColumn)))
 	}
 
 	calculateNeighbors(quadrantsPerRow, neighborRange)
+	if canvasBoundary != nil {
+		canvasBoundary.disableQuadrants(quadrants)
+	}
+	initializeRegions(image)
+
+	if Config.toneModel == ToneModelCoverage {
+		calculateTargetDarkness(image)
+	}
+
+	if Config.gradientOrientation != GradientOrientationNone {
+		calculateEdgeOrientations(image)
+	}
 }
 
 func calculateNeighborRange() int {
 	maxSize := math.MaxFloat64 * -1
 
-	for index := range Config.shapes {
-		maxX, maxY := Config.shapes[index].getMaxSize()
+	allShapes := slices.Clone(Config.shapes)
+	for index := range Config.regions {
+		allShapes = append(allShapes, Config.regions[index].shapes...)
+	}
+
+	for index := range allShapes {
+		maxX, maxY := allShapes[index].getMaxSize()
 		if maxX > maxSize {
 			maxSize = maxX
 		}
@@ -108,11 +145,27 @@ func initializeShapes() {
 		Config.shapes[index].generateShapeVariants()
 		//Config.shapes[index].ensureOriginCover() //TODO reinstate
 	}
+
+	for regionIndex := range Config.regions {
+		regionShapes := Config.regions[regionIndex].shapes
+		for index := range regionShapes {
+			regionShapes[index].mmToPixel(Config.processingDpi)
+			regionShapes[index].centerOnOrigin()
+			regionShapes[index].generateShapeVariants()
+		}
+	}
 }
 
+// The shapes are converted to pixels and their variants are generated only once so that batches can reuse them
+var shapesInitialized bool
+var shapeNeighborRange int
+
 func initialize(image *image.Gray, neighborRange int) {
 	initializeQuadrants(image, neighborRange)
-	initializeShapes()
+	if !shapesInitialized {
+		initializeShapes()
+		shapesInitialized = true
+	}
 }
 
 func countUnfinishedQuadrants(quadrantList *[]*Quadrant) int {
@@ -152,46 +205,40 @@ func getUnfinishedQuadrant(randSource *rand.Rand) *Quadrant {
 }
 
 func scoreShape(quadrant *Quadrant, shape *Shape) float64 {
-	neighborhoodDarknessBefore := 0.0
-	neighborhoodDarknessAfter := 0.0
-	punishment := 0.0
-	var intersectedPixels []*Pixel
-
-	quadrant.accessMutex.Lock()
-	nrOfQuadrants := float64(len(quadrant.Neighbors))
-	neighborhoodDarknessBefore += quadrant.getAdjustedDarkness()
-	intersectedPixels = append(intersectedPixels, quadrant.addShapeWithoutNeighbors(shape)...)
-	neighborhoodDarknessAfter += quadrant.getAdjustedDarkness()
-	quadrant.removeShapeWithoutNeighbors(len(quadrant.Shapes) - 1)
-	quadrant.accessMutex.Unlock()
-
-	for index := range quadrant.Neighbors {
-		currentNeighbor := quadrant.Neighbors[index]
-
-		currentNeighbor.accessMutex.Lock()
-		neighborhoodDarknessBefore += currentNeighbor.getAdjustedDarkness()
-		intersectedPixels = append(intersectedPixels, currentNeighbor.addShapeWithoutNeighbors(shape)...)
-		neighborhoodDarknessAfter += currentNeighbor.getAdjustedDarkness()
-		currentNeighbor.removeShapeWithoutNeighbors(len(currentNeighbor.Shapes) - 1)
-		currentNeighbor.accessMutex.Unlock()
-	}
-
-	if len(intersectedPixels) == 0 && Config.debug{
-		fmt.Println("Placed shape intersects no pixels!")
-	}
+	effect := ShapeEffect{shape: shape}
+	scorer := getShapeScorer()
+
+	neighborhood := quadrant.getNeighborhood()
+	for index := range neighborhood {
+		currentQuadrant := neighborhood[index]
 
-	for _, pixel := range intersectedPixels {
-		if pixel.Darkness <= Config.whitePunishmentBoundry {
-			punishment += Config.whitePunishmentValue
+		currentQuadrant.accessMutex.Lock()
+		effect.darknessWithout += currentQuadrant.getAdjustedDarkness()
+		if scorer.usesResiduals() {
+			effect.pixels = append(effect.pixels, currentQuadrant.FlattenPixels...)
+			effect.residualsWithout = currentQuadrant.appendResiduals(effect.residualsWithout)
+		}
+
+		intersectedPixels := currentQuadrant.addShapeWithoutNeighbors(shape)
+		effect.intersectedPixels = append(effect.intersectedPixels, intersectedPixels...)
+		effect.overlappingPixels += countOverlappingPixels(intersectedPixels)
+		effect.darknessWith += currentQuadrant.getAdjustedDarkness()
+		if scorer.usesResiduals() {
+			effect.residualsWith = currentQuadrant.appendResiduals(effect.residualsWith)
 		}
+
+		currentQuadrant.removeShapeWithoutNeighbors(len(currentQuadrant.Shapes) - 1)
+		currentQuadrant.accessMutex.Unlock()
+	}
+
+	if len(effect.intersectedPixels) == 0 && Config.debug {
+		fmt.Println("Placed shape intersects no pixels!")
 	}
 
-	neighborhoodDarknessBefore /= nrOfQuadrants
-	neighborhoodDarknessAfter /= nrOfQuadrants
+	effect.darknessWithout /= float64(len(quadrant.Neighbors))
+	effect.darknessWith /= float64(len(quadrant.Neighbors))
 
-	//Todo Remove
-	score := ((neighborhoodDarknessBefore - neighborhoodDarknessAfter) / float64(len(intersectedPixels))) - (punishment / float64(len(intersectedPixels)))
-	return score
+	return scorer.score(&effect)
 }
 
 func finishQuadrants(wg *sync.WaitGroup, randSource *rand.Rand) {
@@ -219,16 +266,25 @@ func finishQuadrants(wg *sync.WaitGroup, randSource *rand.Rand) {
 		}
 
 		var shapeCopies []*Shape
+		var shapeVariants []*Shape
+		shapes := currentQuadrant.region.getShapes()
 		currentQuadrant.accessMutex.Lock()
-		for shapeIndex := range Config.shapes {
-			for shapeVariantIndex := range Config.shapes[shapeIndex].Variants {
+		for shapeIndex := range shapes {
+			for shapeVariantIndex := range shapes[shapeIndex].Variants {
+				currentVariant := &shapes[shapeIndex].Variants[shapeVariantIndex]
 				if !Config.highPrecisionShapePositioning {
-					shapeCopies = append(shapeCopies, Config.shapes[shapeIndex].Variants[shapeVariantIndex].transformCopy(darkestPixelMidpoint.X, darkestPixelMidpoint.Y))
+					shapeCopy := currentVariant.transformCopy(darkestPixelMidpoint.X, darkestPixelMidpoint.Y)
+					shapeCopy.region = currentQuadrant.region
+					shapeCopies = append(shapeCopies, shapeCopy)
+					shapeVariants = append(shapeVariants, currentVariant)
 					continue
 				}
 
 				for midPointIndex := range pixelMidpoints {
-					shapeCopies = append(shapeCopies, Config.shapes[shapeIndex].Variants[shapeVariantIndex].transformCopy(pixelMidpoints[midPointIndex].X, pixelMidpoints[midPointIndex].Y))
+					shapeCopy := currentVariant.transformCopy(pixelMidpoints[midPointIndex].X, pixelMidpoints[midPointIndex].Y)
+					shapeCopy.region = currentQuadrant.region
+					shapeCopies = append(shapeCopies, shapeCopy)
+					shapeVariants = append(shapeVariants, currentVariant)
 				}
 			}
 		}
@@ -240,6 +296,8 @@ func finishQuadrants(wg *sync.WaitGroup, randSource *rand.Rand) {
 			shapeScores = append(shapeScores, scoreShape(currentQuadrant, shapeCopies[shapeIndex]))
 		}
 
+		applyOrientationBias(currentQuadrant, shapeVariants, shapeScores)
+
 		bestShapeScore := math.MaxFloat64 * -1
 		for _, score := range shapeScores {
 			if score > bestShapeScore {
@@ -258,7 +316,11 @@ func finishQuadrants(wg *sync.WaitGroup, randSource *rand.Rand) {
 			ShapeCountMutex.Lock()
 			ShapeCount++
 			ShapeCountMutex.Unlock()
-			currentQuadrant.addShape(bestShapes[randSource.IntN(len(bestShapes))])
+			bestShape := bestShapes[randSource.IntN(len(bestShapes))]
+			currentQuadrant.addShape(bestShape)
+			if shapePlacedListener != nil {
+				shapePlacedListener(bestShape)
+			}
 
 		} else {
 			if Config.debug {
@@ -317,7 +379,13 @@ func monitorQuadrants(wg *sync.WaitGroup, alreadyFinishedQuadrants float64, mess
 			}
 		}
 
+		if generationCancelled.Load() {
+			endFinishQuadrantsRoutines()
+			return
+		}
+
 		if nrOfUnfinishedQuadrants == 0 {
+			setGenerationProgress(message, 100)
 			if !Config.debug {
 				fmt.Printf("\r%s %s %3.2f %s", spinnerFinishedFrame, message, 100.0, "%")
 			}
@@ -333,6 +401,8 @@ func monitorQuadrants(wg *sync.WaitGroup, alreadyFinishedQuadrants float64, mess
 				} else {
 					fmt.Printf("\nNo adjusted darkness change for %d seconds. Ending line placement early\n", int(updateFrequency.Seconds()*lastAjustedDarknessChange))
 				}
+				placementTimedOut = true
+				unfinishedQuadrantsAtTimeout = int(nrOfUnfinishedQuadrants)
 				endFinishQuadrantsRoutines()
 				return
 			}
@@ -351,6 +421,7 @@ func monitorQuadrants(wg *sync.WaitGroup, alreadyFinishedQuadrants float64, mess
 			continue
 		}
 		percentage := 100 * ((nrOfNotAlreadyFinishedQuadrants - nrOfUnfinishedQuadrants) / nrOfNotAlreadyFinishedQuadrants)
+		setGenerationProgress(message, percentage)
 
 		start := time.Now()
 		for time.Since(start) < updateFrequency {
@@ -381,6 +452,9 @@ type ShapeScore struct {
 	quadrantIndex int
 	shapeIndex    int
 	score         float64
+	// The score of the darkness scoring function, which decides if a shape is worthless independent of the configured
+	// scoring function
+	darknessScore float64
 }
 
 func removeWorstShapes() {
@@ -401,6 +475,9 @@ func removeWorstShapes() {
 	}
 
 	removeShapes(toBeRemoved)
+	if shapesChangedListener != nil {
+		shapesChangedListener()
+	}
 }
 
 func removeWorthlessShapes() {
@@ -412,12 +489,15 @@ func removeWorthlessShapes() {
 
 	var toBeRemoved []*ShapeScore
 	for index := range shapeScores {
-		if shapeScores[index].score == 0 {
+		if shapeScores[index].darknessScore == 0 {
 			toBeRemoved = append(toBeRemoved, shapeScores[index])
 		}
 	}
 
 	removeShapes(toBeRemoved)
+	if shapesChangedListener != nil {
+		shapesChangedListener()
+	}
 }
 
 func removeShapes(toBeRemoved []*ShapeScore) {
@@ -449,6 +529,10 @@ func generateVectorArt(artworkWidth, artworkHeight int) string {
 	stopSpinnerMutex = sync.Mutex{}
 
 	wg := sync.WaitGroup{}
+	placementTimedOut = false
+	unfinishedQuadrantsAtTimeout = 0
+	generatedMetrics = nil
+	estimatedPlotTime = 0
 	ShapeCount = 0
 	ShapeCountMutex = &sync.Mutex{}
 	alreadyFinishedQuadrants := float64(len(quadrants)) - float64(countUnfinishedQuadrants(&quadrants))
@@ -462,6 +546,10 @@ func generateVectorArt(artworkWidth, artworkHeight int) string {
 	go monitorQuadrants(&wg, alreadyFinishedQuadrants, "Placing Shapes")
 	wg.Wait()
 
+	if generationCancelled.Load() {
+		return ""
+	}
+
 	if Config.shapeRefinement {
 		for i := 0; i < Config.shapeRefinementIterations; i++ {
 			wg.Add(1)
@@ -485,10 +573,34 @@ func generateVectorArt(artworkWidth, artworkHeight int) string {
 			}
 
 			wg.Wait()
+
+			if generationCancelled.Load() {
+				return ""
+			}
 		}
 
 	}
 
+	if Config.annealing {
+		if !Config.debug {
+			wg.Add(1)
+			stopSpinnerBool = false
+			go startSpinner("Annealing Shapes", &wg, &stopSpinnerBool, &stopSpinnerMutex)
+		} else {
+			fmt.Println("\nAnnealing Shapes")
+		}
+		annealShapes(rand.New(rand.NewPCG(RandSource.Uint64(), RandSource.Uint64())))
+		stopSpinner()
+		wg.Wait()
+		if shapesChangedListener != nil {
+			shapesChangedListener()
+		}
+
+		if generationCancelled.Load() {
+			return ""
+		}
+	}
+
 	if !Config.debug {
 		wg.Add(1)
 		stopSpinnerBool = false
@@ -501,7 +613,8 @@ func generateVectorArt(artworkWidth, artworkHeight int) string {
 		removeWorthlessShapes()
 	}
 
-	if Config.smoothEdges {
+	// Shapes always have to be cut at the border of non-rectangular canvases
+	if Config.smoothEdges || canvasBoundary != nil {
 		if !Config.debug {
 			wg.Add(1)
 			stopSpinnerBool = false
@@ -536,33 +649,59 @@ func generateVectorArt(artworkWidth, artworkHeight int) string {
 		}
 	}
 
+	if Config.offsetStrokes {
+		if Config.debug {
+			fmt.Println("Offsetting Strokes")
+		}
+		offsetShapes()
+	}
+
+	if Config.hatchFill {
+		if Config.debug {
+			fmt.Println("Filling Closed Shapes")
+		}
+		hatchShapes()
+	}
+
+	// The plotter commands are generated from the shapes in mm, so no precision is lost by converting them back
+	var polylinesMM [][]Point
+	if getOutputFormat() == OutputFormatEBB {
+		polylinesMM = getRenderPolylines(collectShapes())
+	}
+
 	for quadrantIndex := range quadrants {
 		for shapeIndex := range quadrants[quadrantIndex].Shapes {
 			quadrants[quadrantIndex].Shapes[shapeIndex].mmToPixel(Config.outputDpi)
 		}
 	}
 
+	estimatedPlotTime = estimatePlotTime(getPolylinesMM(collectShapes()))
+	if (Config.metrics || Config.metricsFile) && ProcessedImage != nil {
+		generatedMetrics = calculateMetrics()
+	}
+
 	wg.Wait()
 
-	
+	outputFormat := strings.ToUpper(getOutputFormat())
 	if Config.debug {
-		fmt.Println("Generating SVG")
-		return generateSVG(artworkWidth, artworkHeight, collectShapes())
+		fmt.Println("Generating " + outputFormat)
+		return generateOutput(artworkWidth, artworkHeight, collectShapes(), polylinesMM)
 	}
 
 	wg.Add(1)
 	stopSpinnerBool = false
-	go startSpinner("Generating SVG File", &wg, &stopSpinnerBool, &stopSpinnerMutex)
-	svg := generateSVG(artworkWidth, artworkHeight, collectShapes())
+	go startSpinner("Generating "+outputFormat+" File", &wg, &stopSpinnerBool, &stopSpinnerMutex)
+	output := generateOutput(artworkWidth, artworkHeight, collectShapes(), polylinesMM)
 	stopSpinner()
 	wg.Wait()
 
-	return svg
+	return output
 }
 
 func startSpinner(message string, wg *sync.WaitGroup, stopSpinner *bool, stopSpinnerMutex *sync.Mutex) {
 	fmt.Println()
 	defer wg.Done()
+	setGenerationProgress(message, 0)
 
 	for {
 		stopSpinnerMutex.Lock()
@@ -590,7 +729,25 @@ func stopSpinner() {
 	stopSpinnerMutex.Unlock()
 }
 
+func setGenerationProgress(stage string, percentage float64) {
+	generationProgressMutex.Lock()
+	generationStage = stage
+	generationPercentage = percentage
+	generationProgressMutex.Unlock()
+}
+
+func getGenerationProgress() (string, float64) {
+	generationProgressMutex.Lock()
+	defer generationProgressMutex.Unlock()
+
+	return generationStage, generationPercentage
+}
+
 func canvasContains(point *Point, canvasWidth, canvasHeight float64) bool {
+	if canvasBoundary != nil {
+		return canvasBoundary.contains(point)
+	}
+
 	return !(point.X < 0 || point.X > canvasWidth || point.Y < 0 || point.Y > canvasHeight)
 }
 
@@ -602,6 +759,12 @@ func smoothEdges(artworkWidth, artworkHeight float64) {
 			var smoothedLines []Polyline
 
 			for lineIndex := range currentShape.Lines {
+				// Lines with all vertices inside a non-rectangular canvas can still cross a notch or hole of it
+				if canvasBoundary != nil {
+					smoothedLines = append(smoothedLines, cutLineExcess(&currentShape.Lines[lineIndex], artworkWidth, artworkHeight)...)
+					continue
+				}
+
 				lineCut := false
 				for pointIndex := range currentShape.Lines[lineIndex].points {
 					if !canvasContains(&currentShape.Lines[lineIndex].points[pointIndex], artworkWidth, artworkHeight) {
@@ -649,7 +812,7 @@ func combineLines(iterations int) {
 						notCombinedLines = append(notCombinedLines, (*currentShapeLines)[lineIndex])
 						continue
 					}
-					if !tryToCombineWithNeighborsLines(&(*currentShapeLines)[lineIndex], quadrants[quadrantIndex].Neighbors) {
+					if !tryToCombineWithNeighborsLines(&(*currentShapeLines)[lineIndex], quadrants[quadrantIndex].Shapes[shapeIndex].region, quadrants[quadrantIndex].Neighbors) {
 						notCombinedLines = append(notCombinedLines, (*currentShapeLines)[lineIndex])
 					} else {
 						linesCombined = true
@@ -678,7 +841,7 @@ func removeEmptyShapes() {
 	for quadrantIndex := 0; quadrantIndex < len(quadrants); quadrantIndex++ {
 		for shapeIndex := range quadrants[quadrantIndex].Shapes {
 			if len(quadrants[quadrantIndex].Shapes[shapeIndex].Lines) == 0 {
-				toBeRemoved = append(toBeRemoved, &ShapeScore{quadrantIndex, shapeIndex, 0})
+				toBeRemoved = append(toBeRemoved, &ShapeScore{quadrantIndex, shapeIndex, 0, 0})
 			}
 		}
 	}
@@ -686,9 +849,9 @@ func removeEmptyShapes() {
 	removeShapes(toBeRemoved)
 }
 
-func tryToCombineWithNeighborsLines(line *Polyline, neighbors []*Quadrant) bool {
+func tryToCombineWithNeighborsLines(line *Polyline, region *Region, neighbors []*Quadrant) bool {
 	for index := range neighbors {
-		if tryToCombineWithSpecificNeighborLines(line, neighbors[index]) {
+		if tryToCombineWithSpecificNeighborLines(line, region, neighbors[index]) {
 			return true
 		}
 	}
@@ -696,8 +859,12 @@ func tryToCombineWithNeighborsLines(line *Polyline, neighbors []*Quadrant) bool
 	return false
 }
 
-func tryToCombineWithSpecificNeighborLines(line *Polyline, neighbor *Quadrant) bool {
+func tryToCombineWithSpecificNeighborLines(line *Polyline, region *Region, neighbor *Quadrant) bool {
 	for shapeIndex := range neighbor.Shapes {
+		// Lines of different regions are not combined since they can have different stroke settings
+		if neighbor.Shapes[shapeIndex].region != region {
+			continue
+		}
 		for lineIndex := range neighbor.Shapes[shapeIndex].Lines {
 			if canCombineLines(line, &neighbor.Shapes[shapeIndex].Lines[lineIndex]) {
 				return true
@@ -768,43 +935,48 @@ func cutLineExcess(line *Polyline, canvasWidth, canvasHeight float64) []Polyline
 		lineSegments = line.getLineSegments()
 	}
 
-	segmentIndex := 0
-	for segmentIndex < len(lineSegments) {
-		currentSegment := &lineSegments[segmentIndex]
-		p1, p2 := &currentSegment.points[0], &currentSegment.points[1]
-		p1OutOfBounds := !canvasContains(p1, canvasWidth, canvasHeight)
-		p2OutOfBounds := !canvasContains(p2, canvasWidth, canvasHeight)
-
-		if p1OutOfBounds && p2OutOfBounds {
-			lineSegments = removePolylineFromSlice(lineSegments, segmentIndex)
-			continue
-		}
+	// Segments can cross a non-rectangular border several times
+	if canvasBoundary != nil {
+		lineSegments = canvasBoundary.clipSegments(lineSegments)
+	} else {
+		segmentIndex := 0
+		for segmentIndex < len(lineSegments) {
+			currentSegment := &lineSegments[segmentIndex]
+			p1, p2 := &currentSegment.points[0], &currentSegment.points[1]
+			p1OutOfBounds := !canvasContains(p1, canvasWidth, canvasHeight)
+			p2OutOfBounds := !canvasContains(p2, canvasWidth, canvasHeight)
+
+			if p1OutOfBounds && p2OutOfBounds {
+				lineSegments = removePolylineFromSlice(lineSegments, segmentIndex)
+				continue
+			}
 
-		if p1OutOfBounds && !p2OutOfBounds {
-			intersectionPoint, intersection := getCanvasBorderIntersect(p1, p2, canvasWidth, canvasHeight)
-			if intersection {
-				currentSegment.points[0] = intersectionPoint
+			if p1OutOfBounds && !p2OutOfBounds {
+				intersectionPoint, intersection := getCanvasBorderIntersect(p1, p2, canvasWidth, canvasHeight)
+				if intersection {
+					currentSegment.points[0] = intersectionPoint
+				}
+				segmentIndex++
+				continue
 			}
-			segmentIndex++
-			continue
-		}
 
-		if !p1OutOfBounds && !p2OutOfBounds {
-			segmentIndex++
-			continue
-		}
-		if !p1OutOfBounds && p2OutOfBounds {
-			intersectionPoint, intersection := getCanvasBorderIntersect(p1, p2, canvasWidth, canvasHeight)
-			if intersection {
-				currentSegment.points[1] = intersectionPoint
+			if !p1OutOfBounds && !p2OutOfBounds {
+				segmentIndex++
+				continue
+			}
+			if !p1OutOfBounds && p2OutOfBounds {
+				intersectionPoint, intersection := getCanvasBorderIntersect(p1, p2, canvasWidth, canvasHeight)
+				if intersection {
+					currentSegment.points[1] = intersectionPoint
+				}
+				segmentIndex++
+				continue
 			}
-			segmentIndex++
-			continue
-		}
 
+		}
 	}
 
-	segmentIndex = 1
+	segmentIndex := 1
 	for segmentIndex < len(lineSegments) {
 		p1 := &lineSegments[segmentIndex-1].points[len(lineSegments[segmentIndex-1].points)-1]
 		p2 := &lineSegments[segmentIndex].points[0]
@@ -862,6 +1034,50 @@ func PixelToMM(pixels float64, dpi float64) float64 {
 	return (pixels * 25.4) / dpi
 }
 
+// The units that lengths in the config can be given in and how many millimetres one unit is. Pixels depend on the dpi
+// and are converted with mmToPixel and PixelToMM.
+var lengthUnits = map[string]float64{"mm": 1, "cm": 10, "in": 25.4, "pt": 25.4 / 72}
+
+const lengthPattern = `^\s*[-+]?([0-9]+\.?[0-9]*|\.[0-9]+)([eE][-+]?[0-9]+)?\s*(mm|cm|in|pt|px)?\s*$`
+
+// Converts a length of the config to the given unit. A length is either a number, which is already in the given unit,
+// or a string with an optional unit like "210mm", "8.5in", "2cm" or "40px". Pixels are converted with the given dpi.
+func convertLength(value any, unit string, dpi float64) (float64, error) {
+	switch typedValue := value.(type) {
+	case float64:
+		return typedValue, nil
+	case int:
+		return float64(typedValue), nil
+	case string:
+		text := strings.TrimSpace(typedValue)
+		valueUnit := unit
+		for _, candidate := range []string{"mm", "cm", "in", "pt", "px"} {
+			if strings.HasSuffix(text, candidate) {
+				valueUnit = candidate
+				text = strings.TrimSpace(strings.TrimSuffix(text, candidate))
+				break
+			}
+		}
+
+		number, err := strconv.ParseFloat(text, 64)
+		if err != nil || math.IsInf(number, 0) || math.IsNaN(number) {
+			return 0, fmt.Errorf("invalid length '%s' | expected a number with one of the units mm, cm, in, pt or px", typedValue)
+		}
+
+		millimeters := number * lengthUnits[valueUnit]
+		if valueUnit == "px" {
+			millimeters = PixelToMM(number, dpi)
+		}
+
+		if unit == "px" {
+			return mmToPixel(millimeters, dpi), nil
+		}
+		return millimeters / lengthUnits[unit], nil
+	}
+
+	return 0, fmt.Errorf("unexpected type for length | expected number or string")
+}
+
 // For Debugging purposes only
 func ShapeToSVGFile(shape Shape, filepath string) {
 	var svgLines []string
@@ -873,7 +1089,7 @@ func ShapeToSVGFile(shape Shape, filepath string) {
 	svgLines = append(svgLines, "-->")
 	svgLines = append(svgLines, "<svg viewBox=\"0 0 500 500\" xmlns=\"http://www.w3.org/2000/svg\">")
 
-	style := "stroke:" + Config.strokeColor + "; fill:none; stroke-width: " + strconv.FormatFloat(Config.strokeWidth, 'f', 2, 64) + "px"
+	style := getStyle(Config.strokeColor, Config.strokeWidth, false)
 
 	svgLines = append(svgLines, shape.toSVG(style))
 
@@ -909,6 +1125,52 @@ func collectShapes() []*Shape{
 	return shapes
 }
 
+const (
+	OutputFormatSVG = "svg"
+	OutputFormatDXF = "dxf"
+	OutputFormatPDF = "pdf"
+	OutputFormatEPS = "eps"
+	OutputFormatPS  = "ps"
+	OutputFormatEBB = "ebb"
+)
+
+// Returns the format of the output file, which is determined by the extension of the outputPath
+func getOutputFormat() string {
+	switch extension := strings.ToLower(filepath.Ext(Config.outputPath)); extension {
+	case ".dxf", ".pdf", ".eps", ".ps", ".ebb":
+		return strings.TrimPrefix(extension, ".")
+	}
+
+	return OutputFormatSVG
+}
+
+// Returns the content of the output file in the format of the outputPath. The shapes are in pixels of the outputDpi,
+// the polylines are only used for EBB files and are in mm.
+func generateOutput(artworkWidth, artworkHeight int, shapes []*Shape, polylinesMM [][]Point) string {
+	switch getOutputFormat() {
+	case OutputFormatDXF:
+		return generateDXF(artworkWidth, artworkHeight, shapes)
+	case OutputFormatPDF:
+		return generatePDF(artworkWidth, artworkHeight, shapes)
+	case OutputFormatEPS, OutputFormatPS:
+		return generatePostScript(artworkWidth, artworkHeight, shapes, getOutputFormat() == OutputFormatPS)
+	case OutputFormatEBB:
+		return generateEBB(artworkWidth, artworkHeight, polylinesMM)
+	}
+
+	return generateSVG(artworkWidth, artworkHeight, shapes)
+}
+
+// Returns the style attribute of a line. Filled lines use the stroke color as fill color.
+func getStyle(strokeColor string, strokeWidth float64, fill bool) string {
+	fillColor := "none"
+	if fill {
+		fillColor = strokeColor
+	}
+
+	return "stroke:" + strokeColor + "; fill:" + fillColor + "; stroke-width: " + strconv.FormatFloat(strokeWidth, 'f', 2, 64) + "px"
+}
+
 func generateSVG(artworkWidth, artworkHeight int, shapes []*Shape) string {
 	artworkHeightPixel := strconv.FormatFloat(mmToPixel(PixelToMM(float64(artworkHeight), Config.processingDpi), Config.outputDpi), 'f', 2, 64)
 	artworkWidthPixel := strconv.FormatFloat(mmToPixel(PixelToMM(float64(artworkWidth), Config.processingDpi), Config.outputDpi), 'f', 2, 64)
@@ -923,15 +1185,33 @@ func generateSVG(artworkWidth, artworkHeight int, shapes []*Shape) string {
 		svgLines = append(svgLines, UserConfig)
 	}
 	svgLines = append(svgLines, "-->")
-	svgLines = append(svgLines, "<svg viewBox=\"0 0 "+artworkWidthPixel+" "+artworkHeightPixel+"\" xmlns=\"http://www.w3.org/2000/svg\">")
+	namespaces := "xmlns=\"http://www.w3.org/2000/svg\""
+	if Config.laserLayers {
+		namespaces += " xmlns:inkscape=\"http://www.inkscape.org/namespaces/inkscape\""
+	}
+	if pageLayout != nil {
+		attributes, transform := pageLayout.getSVGAttributes()
+		svgLines = append(svgLines, "<svg "+attributes+" "+namespaces+">")
+		svgLines = append(svgLines, "<g transform=\""+transform+"\">")
+	} else {
+		svgLines = append(svgLines, "<svg viewBox=\"0 0 "+artworkWidthPixel+" "+artworkHeightPixel+"\" "+namespaces+">")
+	}
 
-	style := "stroke:" + Config.strokeColor + "; fill:none; stroke-width: " + strconv.FormatFloat(Config.strokeWidth, 'f', 2, 64) + "px"
+	if Config.laserLayers {
+		svgLines = append(svgLines, generateLaserLayers(artworkWidth, artworkHeight, shapes)...)
+	} else {
+		for _, shape := range shapes {
+			strokeColor, strokeWidth := shape.getDrawnStroke()
+			for lineIndex := range shape.Lines {
+				line := &shape.Lines[lineIndex]
+				svgLines = append(svgLines, line.toSVG(getStyle(strokeColor, strokeWidth, Config.fillClosedShapes && line.isClosed())))
+			}
+		}
+	}
 
-	
-	for _, shape := range shapes {
-		svgLines = append(svgLines, shape.toSVG(style))
+	if pageLayout != nil {
+		svgLines = append(svgLines, "</g>")
 	}
-	
 	svgLines = append(svgLines, "</svg>")
 
 	svg := ""
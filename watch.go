@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const watchInterval = 500 * time.Millisecond
+
+type WatchedFileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// Regenerates the artwork whenever the config, an input image or a region mask changes. A running generation is
+// cancelled before the next one starts. Runs until the process is terminated.
+func runWatch(configPath string) bool {
+	Fonts = loadFonts()
+
+	watchedFiles := []string{configPath}
+	var lastState map[string]WatchedFileState
+	var done chan bool
+
+	for {
+		state := getWatchedFileStates(watchedFiles)
+		if maps.Equal(state, lastState) {
+			time.Sleep(watchInterval)
+			continue
+		}
+
+		if lastState != nil {
+			fmt.Println("\n\nChange detected. Regenerating...")
+		}
+
+		if done != nil {
+			generationCancelled.Store(true)
+			<-done
+		}
+
+		// Editors often write files in several steps. Waiting a moment prevents generating from half written files.
+		time.Sleep(watchInterval / 2)
+
+		var files []string
+		done, files = startWatchRun(configPath)
+		watchedFiles = append([]string{configPath}, files...)
+		lastState = getWatchedFileStates(watchedFiles)
+	}
+}
+
+// Loads the config and starts the generation in the background. Returns a channel that is closed when the generation
+// ended and the files the generation depends on.
+func startWatchRun(configPath string) (chan bool, []string) {
+	done := make(chan bool)
+
+	content, configFiles, err := loadConfigFile(configPath)
+	if _, ok := err.(*ConfigReadError); ok {
+		fmt.Println("Could not read config from '" + configPath + "'")
+		close(done)
+		return done, nil
+	} else if err != nil {
+		fmt.Printf("Could not load config from '%s': %s\n", configPath, err)
+		fmt.Println("\nWaiting for changes...")
+		close(done)
+		return done, configFiles[1:]
+	}
+
+	resetStaticVariables()
+	config := NewConfig()
+	if !config.fromJSON(content) {
+		fmt.Println("Errors occured while parsing Config:")
+		for _, errorString := range errors {
+			fmt.Println("   " + errorString)
+		}
+		fmt.Println("\nWaiting for changes...")
+		close(done)
+		// The error may be caused by one of the referenced configs or fonts, so they are still watched
+		return done, append(configFiles[1:], fontFiles...)
+	}
+
+	Config = config
+	UserConfig = content
+	generationCancelled.Store(false)
+	files := append(configFiles[1:], config.getWatchedFiles()...)
+
+	go func() {
+		start := time.Now()
+		if runBatch() == ExitSuccess && !generationCancelled.Load() {
+			fmt.Printf("\n\nVecart finished in %s\n", time.Since(start).Round(time.Second))
+		}
+		if !generationCancelled.Load() {
+			fmt.Println("\nWaiting for changes...")
+		}
+		close(done)
+	}()
+
+	return done, files
+}
+
+// Returns the input images, the directories that are searched for input images, the region masks and the font files of
+// the config. Shape libraries are included configs and are watched with the other referenced configs.
+func (config *VecartConfig) getWatchedFiles() []string {
+	files := slices.Clone(fontFiles)
+
+	for _, pattern := range config.getInputPathPatterns() {
+		if strings.ContainsAny(pattern, "*?[") {
+			files = append(files, filepath.Dir(pattern))
+		} else {
+			files = append(files, pattern)
+		}
+	}
+	for _, inputPath := range config.getInputImagePaths() {
+		if inputPath != "" {
+			files = append(files, inputPath)
+		}
+	}
+
+	for index := range config.regions {
+		if config.regions[index].maskPath != "" {
+			files = append(files, config.regions[index].maskPath)
+		}
+	}
+
+	return files
+}
+
+// Returns the modification time and size of the files. Files that do not exist have an empty state.
+func getWatchedFileStates(files []string) map[string]WatchedFileState {
+	states := make(map[string]WatchedFileState)
+
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			states[file] = WatchedFileState{}
+			continue
+		}
+		states[file] = WatchedFileState{info.ModTime(), info.Size()}
+	}
+
+	return states
+}
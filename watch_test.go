@@ -0,0 +1,96 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, path string, content string) {
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeTestFont(t *testing.T, path string) {
+	content, err := StaticAssets.ReadFile("static/fonts/IBM-Plex-Sans.svg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, path, string(content))
+}
+
+func TestLoadFontFile(t *testing.T) {
+	resetStaticVariables()
+	Fonts = loadFonts()
+	directory := t.TempDir()
+	fontPath := filepath.Join(directory, "font.svg")
+	writeTestFont(t, fontPath)
+
+	config := NewConfig()
+	if !config.fromJSON(`{"shapes": [{"type": "text", "center": [0, 0], "lineHeight": 1, "text": "A", "font": "` + fontPath + `"}]}`) {
+		t.Fatalf("Could not use the font file: %v", errors)
+	}
+	if len(config.shapes) != 1 || len(config.shapes[0].Lines) == 0 {
+		t.Error("The text was not created with the font file")
+	}
+
+	resetStaticVariables()
+	config = NewConfig()
+	if config.fromJSON(`{"shapes": [{"type": "text", "center": [0, 0], "lineHeight": 1, "text": "A", "font": "missing.svg"}]}`) {
+		t.Error("A missing font file has to be an error")
+	}
+	resetStaticVariables()
+}
+
+func TestWatchedFiles(t *testing.T) {
+	resetStaticVariables()
+	Fonts = loadFonts()
+	directory := t.TempDir()
+	fontPath := filepath.Join(directory, "font.svg")
+	writeTestFont(t, fontPath)
+
+	config := NewConfig()
+	config.fromJSON(`{"inputPath": ["` + filepath.Join(directory, "a.png") + `", "` + filepath.Join(directory, "*.jpg") + `"],
+		"regions": [{"mask": "` + filepath.Join(directory, "mask.png") + `"}],
+		"shapes": [{"type": "group", "shapes": [{"type": "text", "center": [0, 0], "lineHeight": 1, "text": "A", "font": "` + fontPath + `"}]}]}`)
+
+	files := config.getWatchedFiles()
+	for _, file := range []string{fontPath, filepath.Join(directory, "a.png"), directory, filepath.Join(directory, "mask.png")} {
+		if !slices.Contains(files, file) {
+			t.Errorf("'%s' is not watched: %v", file, files)
+		}
+	}
+	resetStaticVariables()
+}
+
+func TestWatchInvalidConfig(t *testing.T) {
+	resetStaticVariables()
+	Fonts = loadFonts()
+	directory := t.TempDir()
+	basePath := filepath.Join(directory, "base.json")
+	libraryPath := filepath.Join(directory, "library.json")
+	fontPath := filepath.Join(directory, "font.svg")
+	configPath := filepath.Join(directory, "config.json")
+	writeTestFile(t, basePath, `{"darknessThreshold": "dark"}`)
+	writeTestFile(t, libraryPath, `{"shapes": [{"type": "text", "center": [0, 0], "lineHeight": 1, "text": "A", "font": "`+fontPath+`"}]}`)
+	writeTestFile(t, configPath, `{"extends": "base.json", "include": "library.json"}`)
+
+	// The error is caused by the extended config and the font file does not exist yet, so both have to stay watched
+	done, files := startWatchRun(configPath)
+	<-done
+	for _, file := range []string{basePath, libraryPath, fontPath} {
+		if !slices.Contains(files, file) {
+			t.Errorf("'%s' is not watched after the config could not be parsed: %v", file, files)
+		}
+	}
+
+	writeTestFile(t, configPath, `{"extends": "base.json", "include": "library.json"`)
+	done, files = startWatchRun(configPath)
+	<-done
+	if len(files) != 0 {
+		t.Errorf("The references of a config that can not be read are unknown, got %v", files)
+	}
+	resetStaticVariables()
+}
@@ -0,0 +1,483 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A parser for the subset of YAML that is needed for configs: block mappings and sequences, flow collections
+// ('[0, 0]', '{x: 1}'), plain (also multi-line) and quoted scalars and comments. Anchors, tags, multiple documents and
+// block scalars ('|' and '>') are not supported. The result uses the same types as encoding/json (map[string]any,
+// []any, string, float64, bool and nil) so that it can be handled like a parsed JSON config.
+
+type YAMLLine struct {
+	number int
+	indent int
+	text   string
+}
+
+type YAMLParser struct {
+	lines []YAMLLine
+}
+
+func parseYAML(content string) (any, error) {
+	parser := YAMLParser{}
+	err := parser.splitLines(content)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(parser.lines) == 0 {
+		return map[string]any{}, nil
+	}
+
+	value, index, err := parser.parseBlock(0, parser.lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+
+	if index < len(parser.lines) {
+		return nil, fmt.Errorf("line %d: unexpected content", parser.lines[index].number)
+	}
+
+	return value, nil
+}
+
+// Splits the content into lines without comments, empty lines and document markers
+func (parser *YAMLParser) splitLines(content string) error {
+	for index, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		text := strings.TrimRight(stripYAMLComment(line), " \t")
+		trimmed := strings.TrimLeft(text, " ")
+		if trimmed == "" || trimmed == "---" || trimmed == "..." {
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "\t") {
+			return fmt.Errorf("line %d: tabs are not allowed for indentation", index+1)
+		}
+
+		parser.lines = append(parser.lines, YAMLLine{index + 1, len(text) - len(trimmed), trimmed})
+	}
+
+	return nil
+}
+
+// Removes a comment from the line. A comment starts with a '#' at the start of the line or after a whitespace and
+// outside of quoted strings.
+func stripYAMLComment(line string) string {
+	var quote byte
+	for index := 0; index < len(line); index++ {
+		char := line[index]
+		switch {
+		case quote == '"' && char == '\\':
+			index++
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+		case char == '"' || char == '\'':
+			if index == 0 || strings.ContainsRune(" \t[{,:-", rune(line[index-1])) {
+				quote = char
+			}
+		case char == '#':
+			if index == 0 || line[index-1] == ' ' || line[index-1] == '\t' {
+				return line[:index]
+			}
+		}
+	}
+
+	return line
+}
+
+// Parses the block that starts at the line with the given index. Returns the value and the index of the first line
+// after the block.
+func (parser *YAMLParser) parseBlock(index, indent int) (any, int, error) {
+	line := parser.lines[index]
+
+	if isYAMLSequenceItem(line.text) {
+		return parser.parseSequence(index, indent)
+	}
+
+	if _, _, ok := splitYAMLMappingEntry(line.text); ok {
+		return parser.parseMapping(index, indent)
+	}
+
+	return parser.parseInlineValue(index, indent)
+}
+
+func (parser *YAMLParser) parseSequence(index, indent int) (any, int, error) {
+	sequence := []any{}
+
+	// A sequence that starts at the indentation of its key ends at the next key
+	for index < len(parser.lines) && parser.lines[index].indent == indent && isYAMLSequenceItem(parser.lines[index].text) {
+		line := parser.lines[index]
+
+		rest := strings.TrimLeft(line.text[1:], " ")
+		var value any
+		var err error
+		if rest == "" {
+			value, index, err = parser.parseNestedBlock(index, indent)
+		} else {
+			// The content after the dash is parsed like a block that is indented up to the content
+			itemIndent := indent + len(line.text) - len(rest)
+			parser.lines[index] = YAMLLine{line.number, itemIndent, rest}
+			value, index, err = parser.parseBlock(index, itemIndent)
+		}
+		if err != nil {
+			return nil, index, err
+		}
+
+		sequence = append(sequence, value)
+	}
+
+	if index < len(parser.lines) && parser.lines[index].indent > indent {
+		return nil, index, fmt.Errorf("line %d: unexpected indentation", parser.lines[index].number)
+	}
+
+	return sequence, index, nil
+}
+
+func (parser *YAMLParser) parseMapping(index, indent int) (any, int, error) {
+	mapping := map[string]any{}
+
+	for index < len(parser.lines) && parser.lines[index].indent == indent {
+		line := parser.lines[index]
+		key, rest, ok := splitYAMLMappingEntry(line.text)
+		if !ok {
+			return nil, index, fmt.Errorf("line %d: expected 'key: value'", line.number)
+		}
+
+		if _, exists := mapping[key]; exists {
+			return nil, index, fmt.Errorf("line %d: duplicate key '%s'", line.number, key)
+		}
+
+		var value any
+		var err error
+		switch {
+		case rest == "":
+			// Sequences may start at the same indentation as the key they belong to
+			if index+1 < len(parser.lines) && parser.lines[index+1].indent == indent && isYAMLSequenceItem(parser.lines[index+1].text) {
+				value, index, err = parser.parseSequence(index+1, indent)
+			} else {
+				value, index, err = parser.parseNestedBlock(index, indent)
+			}
+		case rest[0] == '|' || rest[0] == '>':
+			return nil, index, fmt.Errorf("line %d: block scalars are not supported", line.number)
+		case rest[0] == '&' || rest[0] == '*' || rest[0] == '!':
+			return nil, index, fmt.Errorf("line %d: anchors, aliases and tags are not supported", line.number)
+		default:
+			parser.lines[index] = YAMLLine{line.number, line.indent, rest}
+			value, index, err = parser.parseInlineValue(index, indent+1)
+		}
+		if err != nil {
+			return nil, index, err
+		}
+
+		mapping[key] = value
+	}
+
+	if index < len(parser.lines) && parser.lines[index].indent > indent {
+		return nil, index, fmt.Errorf("line %d: unexpected indentation", parser.lines[index].number)
+	}
+
+	return mapping, index, nil
+}
+
+// Parses the block below the line with the given index. Returns nil if the next line is not indented further.
+func (parser *YAMLParser) parseNestedBlock(index, indent int) (any, int, error) {
+	if index+1 >= len(parser.lines) || parser.lines[index+1].indent <= indent {
+		return nil, index + 1, nil
+	}
+
+	return parser.parseBlock(index+1, parser.lines[index+1].indent)
+}
+
+// Parses a scalar or flow collection. Flow collections may continue on the following lines. Plain scalars continue on
+// the following lines that are indented at least by minIndent. The lines of multi-line plain scalars are joined with
+// a space.
+func (parser *YAMLParser) parseInlineValue(index, minIndent int) (any, int, error) {
+	line := parser.lines[index]
+	text := line.text
+	index++
+
+	switch text[0] {
+	case '[', '{':
+		for !isYAMLFlowClosed(text) && index < len(parser.lines) {
+			text += " " + parser.lines[index].text
+			index++
+		}
+	case '"', '\'':
+	default:
+		for index < len(parser.lines) && parser.lines[index].indent >= minIndent {
+			continuation := parser.lines[index]
+			if _, _, ok := splitYAMLMappingEntry(continuation.text); ok {
+				return nil, index, fmt.Errorf("line %d: mapping entries are not allowed in multi-line plain scalars", continuation.number)
+			}
+			text += " " + continuation.text
+			index++
+		}
+	}
+
+	flowParser := YAMLFlowParser{text: text}
+	value, err := flowParser.parseValue(false)
+	if err == nil {
+		flowParser.skipSpaces()
+		if flowParser.position < len(flowParser.text) {
+			err = fmt.Errorf("unexpected '%s'", flowParser.text[flowParser.position:])
+		}
+	}
+	if err != nil {
+		return nil, index, fmt.Errorf("line %d: %s", line.number, err)
+	}
+
+	return value, index, nil
+}
+
+func isYAMLSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// Splits 'key: value' into key and value. Returns false if the text is not a mapping entry.
+func splitYAMLMappingEntry(text string) (string, string, bool) {
+	if text[0] == '[' || text[0] == '{' {
+		return "", "", false
+	}
+
+	if text[0] == '"' || text[0] == '\'' {
+		flowParser := YAMLFlowParser{text: text}
+		key, err := flowParser.parseQuoted()
+		if err != nil {
+			return "", "", false
+		}
+		rest := text[flowParser.position:]
+		if rest != ":" && !strings.HasPrefix(rest, ": ") {
+			return "", "", false
+		}
+		return key, strings.TrimSpace(rest[1:]), true
+	}
+
+	if strings.HasSuffix(text, ":") && !strings.Contains(text, ": ") {
+		return strings.TrimSpace(text[:len(text)-1]), "", true
+	}
+
+	key, rest, ok := strings.Cut(text, ": ")
+	if !ok {
+		return "", "", false
+	}
+
+	return strings.TrimSpace(key), strings.TrimSpace(rest), true
+}
+
+// Returns true if all brackets of the flow collection are closed
+func isYAMLFlowClosed(text string) bool {
+	depth := 0
+	var quote byte
+	for index := 0; index < len(text); index++ {
+		char := text[index]
+		switch {
+		case quote == '"' && char == '\\':
+			index++
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+		case char == '"' || char == '\'':
+			quote = char
+		case char == '[' || char == '{':
+			depth++
+		case char == ']' || char == '}':
+			depth--
+		}
+	}
+
+	return depth <= 0
+}
+
+type YAMLFlowParser struct {
+	text     string
+	position int
+}
+
+func (parser *YAMLFlowParser) skipSpaces() {
+	for parser.position < len(parser.text) && parser.text[parser.position] == ' ' {
+		parser.position++
+	}
+}
+
+// Parses the value at the current position. Inside of flow collections plain scalars end at ',', ']' and '}'.
+func (parser *YAMLFlowParser) parseValue(inFlow bool) (any, error) {
+	parser.skipSpaces()
+	if parser.position >= len(parser.text) {
+		return nil, nil
+	}
+
+	switch parser.text[parser.position] {
+	case '[':
+		return parser.parseFlowSequence()
+	case '{':
+		return parser.parseFlowMapping()
+	case '"', '\'':
+		return parser.parseQuoted()
+	}
+
+	return parseYAMLPlainScalar(parser.parsePlain(inFlow, false)), nil
+}
+
+func (parser *YAMLFlowParser) parsePlain(inFlow, isKey bool) string {
+	start := parser.position
+	for parser.position < len(parser.text) {
+		char := parser.text[parser.position]
+		if inFlow && (char == ',' || char == ']' || char == '}') {
+			break
+		}
+		if isKey && char == ':' && (parser.position+1 == len(parser.text) || strings.ContainsRune(" ,]}", rune(parser.text[parser.position+1]))) {
+			break
+		}
+		parser.position++
+	}
+
+	return strings.TrimSpace(parser.text[start:parser.position])
+}
+
+func (parser *YAMLFlowParser) parseQuoted() (string, error) {
+	quote := parser.text[parser.position]
+	parser.position++
+	start := parser.position
+
+	for parser.position < len(parser.text) {
+		char := parser.text[parser.position]
+		if quote == '"' && char == '\\' {
+			parser.position += 2
+			continue
+		}
+		if char == quote {
+			if quote == '\'' && parser.position+1 < len(parser.text) && parser.text[parser.position+1] == '\'' {
+				parser.position += 2
+				continue
+			}
+			value := parser.text[start:parser.position]
+			parser.position++
+			if quote == '\'' {
+				return strings.ReplaceAll(value, "''", "'"), nil
+			}
+			return decodeEscapeSequences(value)
+		}
+		parser.position++
+	}
+
+	return "", fmt.Errorf("unterminated string")
+}
+
+func (parser *YAMLFlowParser) parseFlowSequence() (any, error) {
+	sequence := []any{}
+	parser.position++
+
+	for {
+		parser.skipSpaces()
+		if parser.position >= len(parser.text) {
+			return nil, fmt.Errorf("unterminated sequence")
+		}
+		if parser.text[parser.position] == ']' {
+			parser.position++
+			return sequence, nil
+		}
+
+		value, err := parser.parseValue(true)
+		if err != nil {
+			return nil, err
+		}
+		sequence = append(sequence, value)
+
+		err = parser.parseFlowSeparator(']')
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (parser *YAMLFlowParser) parseFlowMapping() (any, error) {
+	mapping := map[string]any{}
+	parser.position++
+
+	for {
+		parser.skipSpaces()
+		if parser.position >= len(parser.text) {
+			return nil, fmt.Errorf("unterminated mapping")
+		}
+		if parser.text[parser.position] == '}' {
+			parser.position++
+			return mapping, nil
+		}
+
+		var key string
+		var err error
+		if parser.text[parser.position] == '"' || parser.text[parser.position] == '\'' {
+			key, err = parser.parseQuoted()
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			key = parser.parsePlain(true, true)
+		}
+
+		parser.skipSpaces()
+		var value any
+		if parser.position < len(parser.text) && parser.text[parser.position] == ':' {
+			parser.position++
+			value, err = parser.parseValue(true)
+			if err != nil {
+				return nil, err
+			}
+		}
+		mapping[key] = value
+
+		err = parser.parseFlowSeparator('}')
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Consumes the ',' between two values of a flow collection. The closing bracket is left for the caller.
+func (parser *YAMLFlowParser) parseFlowSeparator(closing byte) error {
+	parser.skipSpaces()
+	if parser.position >= len(parser.text) {
+		return fmt.Errorf("missing '%c'", closing)
+	}
+
+	switch parser.text[parser.position] {
+	case ',':
+		parser.position++
+		return nil
+	case closing:
+		return nil
+	}
+
+	return fmt.Errorf("expected ',' or '%c' but got '%c'", closing, parser.text[parser.position])
+}
+
+// Resolves null, booleans and numbers. Everything else is a string.
+func parseYAMLPlainScalar(text string) any {
+	switch text {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+
+	if strings.ContainsAny(text[:1], "0123456789+-.") && text != "." {
+		number, err := strconv.ParseFloat(text, 64)
+		if err == nil {
+			return number
+		}
+		integer, err := strconv.ParseInt(text, 0, 64)
+		if err == nil {
+			return float64(integer)
+		}
+	}
+
+	return text
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestYAMLValues(t *testing.T) {
+	testCases := []struct {
+		content  string
+		expected any
+	}{
+		{"", map[string]any{}},
+		{"a: 1\nb: -2.5e3\nc: 0x10", map[string]any{"a": 1.0, "b": -2500.0, "c": 16.0}},
+		{"a: true\nb: False\nc: ~\nd:\ne: null", map[string]any{"a": true, "b": false, "c": nil, "d": nil, "e": nil}},
+		{"a: text # comment\nb: text#no comment\nc: '# no comment'", map[string]any{"a": "text", "b": "text#no comment", "c": "# no comment"}},
+		{`a: "tab\tquote\" unicode \u00e9"` + "\nb: 'it''s'", map[string]any{"a": "tab\tquote\" unicode é", "b": "it's"}},
+		{`"quoted key": 1`, map[string]any{"quoted key": 1.0}},
+		{"a: [1, [2, 3], {b: c}]\nd: {e: [f, 'g'], h: }", map[string]any{"a": []any{1.0, []any{2.0, 3.0}, map[string]any{"b": "c"}}, "d": map[string]any{"e": []any{"f", "g"}, "h": nil}}},
+		{"a: [1,\n  2,\n  3]", map[string]any{"a": []any{1.0, 2.0, 3.0}}},
+		{"a: [1 2]", map[string]any{"a": []any{"1 2"}}},
+		{"- 1\n- - 2\n  - 3\n- a: 4\n  b: 5", []any{1.0, []any{2.0, 3.0}, map[string]any{"a": 4.0, "b": 5.0}}},
+		{"a:\n- 1\n- 2\nb: 3", map[string]any{"a": []any{1.0, 2.0}, "b": 3.0}},
+		{"a:\n  b:\n    c: 1\n  d: 2", map[string]any{"a": map[string]any{"b": map[string]any{"c": 1.0}, "d": 2.0}}},
+		{"---\na: 1\n...", map[string]any{"a": 1.0}},
+		{"a: 1\r\nb: 2\r\n", map[string]any{"a": 1.0, "b": 2.0}},
+		// Multi-line plain scalars are joined with spaces
+		{"text: a long\n  text over\n  three lines\nb: 1", map[string]any{"text": "a long text over three lines", "b": 1.0}},
+		{"text:\n  a long\n  text", map[string]any{"text": "a long text"}},
+		{"- a long\n  text\n- b", []any{"a long text", "b"}},
+		{"a: 1\n  2", map[string]any{"a": "1 2"}},
+	}
+
+	for _, testCase := range testCases {
+		value, err := parseYAML(testCase.content)
+		if err != nil {
+			t.Errorf("%q: unexpected error %s", testCase.content, err)
+			continue
+		}
+		if !reflect.DeepEqual(value, testCase.expected) {
+			t.Errorf("%q: expected %v, got %v", testCase.content, testCase.expected, value)
+		}
+	}
+}
+
+func TestYAMLErrors(t *testing.T) {
+	testCases := []struct {
+		content       string
+		expectedError string
+	}{
+		{"a: 1\na: 2", "line 2: duplicate key 'a'"},
+		{"a:\n\tb: 1", "line 2: tabs are not allowed"},
+		{"a: |\n  text", "line 1: block scalars are not supported"},
+		{"a: &anchor 1", "line 1: anchors, aliases and tags are not supported"},
+		{"a: [1, 2", "line 1: missing ']'"},
+		{"a: {b: 1", "line 1: missing '}'"},
+		{"a: [1, 2] 3", "line 1: unexpected '3'"},
+		{`a: "text`, "line 1: unterminated string"},
+		{`a: "\x"`, "invalid escape sequence"},
+		{"a: 1\n  b: 2", "line 2: mapping entries are not allowed in multi-line plain scalars"},
+		{"- 1\nb: 2", "line 2: unexpected content"},
+		{"a: 1\n- 2", "line 2: expected 'key: value'"},
+		{"a:\n    b: 1\n  c: 2", "line 3: unexpected indentation"},
+	}
+
+	for _, testCase := range testCases {
+		_, err := parseYAML(testCase.content)
+		if err == nil || !strings.Contains(err.Error(), testCase.expectedError) {
+			t.Errorf("%q: expected the error '%s', got %v", testCase.content, testCase.expectedError, err)
+		}
+	}
+}